@@ -0,0 +1,275 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	cfg "github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/chain"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/chain/archive"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rpcbus"
+	"github.com/urfave/cli"
+)
+
+// archiveProgressStep is the number of blocks between two progress reports.
+const archiveProgressStep = 1000
+
+var (
+	// ArchiveFileFlag flag to set the chain archive file.
+	ArchiveFileFlag = cli.StringFlag{
+		Name:  "file",
+		Usage: "chain archive file",
+		Value: "chain.archive",
+	}
+	// FromHeightFlag flag to set the first block height of a range.
+	FromHeightFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "first block height (genesis block is never archived)",
+		Value: 1,
+	}
+	// ToHeightFlag flag to set the last block height of a range.
+	ToHeightFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "last block height, 0 stands for the chain tip",
+	}
+
+	// ArchiveFlags flags usable by export and import commands.
+	ArchiveFlags = []cli.Flag{
+		ArchiveFileFlag,
+		FromHeightFlag,
+		ToHeightFlag,
+	}
+)
+
+// loadCommandConfig loads the node configuration for a subcommand. Unlike the
+// node action, config file is set only by the global --config flag.
+func loadCommandConfig(ctx *cli.Context) error {
+	return cfg.Load("dusk", nil, func() (string, error) {
+		return ctx.GlobalString(ConfigFlag.Name), nil
+	})
+}
+
+// exportAction streams the blocks of the local chain into an archive file. An
+// existing archive is resumed after its last complete block.
+func exportAction(ctx *cli.Context) error {
+	if err := loadCommandConfig(ctx); err != nil {
+		return err
+	}
+
+	driver, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = driver.Close()
+	}()
+
+	path := ctx.String(ArchiveFileFlag.Name)
+
+	w, err := archive.Create(path, cfg.Get().General.Network)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := w.Close(); err != nil {
+			log.WithError(err).Error("could not close archive")
+		}
+	}()
+
+	from := ctx.Uint64(FromHeightFlag.Name)
+	if from == 0 {
+		// Genesis block is embedded in every node
+		from = 1
+	}
+
+	if last, ok := w.Last(); ok && last >= from {
+		log.WithField("height", last).Info("resuming export")
+		from = last + 1
+	}
+
+	var tip uint64
+
+	err = db.View(func(t database.Transaction) error {
+		tip, err = t.FetchCurrentHeight()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	to := ctx.Uint64(ToHeightFlag.Name)
+	if to == 0 || to > tip {
+		to = tip
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	start := time.Now()
+
+	for height := from; height <= to; height++ {
+		select {
+		case <-interrupt:
+			log.WithField("height", height-1).Warn("export interrupted")
+			return nil
+		default:
+		}
+
+		var b *block.Block
+
+		err = db.View(func(t database.Transaction) error {
+			hash, err := t.FetchBlockHashByHeight(height)
+			if err != nil {
+				return err
+			}
+
+			b, err = t.FetchBlock(hash)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("could not fetch block %d: %w", height, err)
+		}
+
+		if err := w.Append(b); err != nil {
+			return err
+		}
+
+		if height%archiveProgressStep == 0 {
+			log.WithField("height", height).WithField("to", to).Info("exporting blocks")
+		}
+	}
+
+	log.WithField("from", from).
+		WithField("to", to).
+		WithField("file", path).
+		WithField("duration", time.Since(start).String()).
+		Info("export completed")
+
+	return nil
+}
+
+// importAction accepts the blocks of an archive file through the same path as
+// the blocks received on synchronizing. An interrupted import is resumed by
+// running it again, as the blocks up to the chain tip are skipped.
+func importAction(ctx *cli.Context) error {
+	if err := loadCommandConfig(ctx); err != nil {
+		return err
+	}
+
+	path := ctx.String(ArchiveFileFlag.Name)
+
+	r, err := archive.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = r.Close()
+	}()
+
+	if r.Network() != cfg.Get().General.Network {
+		return fmt.Errorf("%w: archive of %s", archive.ErrNetwork, r.Network())
+	}
+
+	driver, db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = driver.Close()
+	}()
+
+	parentCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gctx, gcancel := context.WithTimeout(parentCtx, time.Duration(cfg.Get().RPC.Rusk.ConnectionTimeout)*time.Millisecond)
+	defer gcancel()
+
+	proxy, ruskConn := setupGRPCClients(gctx)
+
+	defer func() {
+		_ = ruskConn.Close()
+	}()
+
+	c, err := LaunchChain(parentCtx, nil, proxy, eventbus.New(), rpcbus.New(), nil, db)
+	if err != nil {
+		return err
+	}
+
+	from := ctx.Uint64(FromHeightFlag.Name)
+	to := ctx.Uint64(ToHeightFlag.Name)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	var imported, skipped uint64
+
+	start := time.Now()
+
+	for {
+		select {
+		case <-interrupt:
+			log.WithField("height", c.TipHeight()).Warn("import interrupted")
+			return nil
+		default:
+		}
+
+		b, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not read archive after height %d: %w", c.TipHeight(), err)
+		}
+
+		height := b.Header.Height
+		if height < from {
+			continue
+		}
+
+		if to > 0 && height > to {
+			break
+		}
+
+		err = c.ImportBlock(*b)
+		if err == chain.ErrBlockAlreadyAccepted {
+			skipped++
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not import block %d: %w", height, err)
+		}
+
+		imported++
+
+		if height%archiveProgressStep == 0 {
+			log.WithField("height", height).Info("importing blocks")
+		}
+	}
+
+	log.WithField("imported", imported).
+		WithField("skipped", skipped).
+		WithField("tip", c.TipHeight()).
+		WithField("duration", time.Since(start).String()).
+		Info("import completed")
+
+	return nil
+}
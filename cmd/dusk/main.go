@@ -43,6 +43,18 @@ func init() {
 			Usage:   "serializes the genesis block and prints it",
 			Action:  genesis.Action,
 		},
+		{
+			Name:   "export",
+			Usage:  "exports the local chain blocks into an archive file",
+			Flags:  ArchiveFlags,
+			Action: exportAction,
+		},
+		{
+			Name:   "import",
+			Usage:  "imports the blocks of an archive file into the local chain",
+			Flags:  ArchiveFlags,
+			Action: importAction,
+		},
 	}
 	app.Flags = append(app.Flags, CLIFlags...)
 	app.Flags = append(app.Flags, GlobalFlags...)
@@ -8,6 +8,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/api"
@@ -17,12 +19,15 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
-	"github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
+	// Register heavy database driver.
+	_ "github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/loop"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/mempool"
 	"github.com/dusk-network/dusk-blockchain/pkg/gql"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/kadcast"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/inventory"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/responding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
@@ -74,6 +79,29 @@ func LaunchChain(ctx context.Context, cl *loop.Consensus, proxy transactions.Pro
 	return chainProcess, nil
 }
 
+// openDatabase opens the blockchain database with the driver set in
+// database.driver config. Both heavy (persistent) and lite (in-memory) drivers
+// are supported. The latter is suitable for ephemeral devnet/CI nodes only.
+func openDatabase() (database.Driver, database.DB, error) {
+	name := cfg.Get().Database.Driver
+
+	drvr, err := database.From(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unknown database driver %q, supported drivers: %s", name, strings.Join(database.Drivers(), ", "))
+	}
+
+	if name == lite.DriverName {
+		log.WithField("driver", name).Warn("in-memory database driver in use, chain data will not be persisted")
+	}
+
+	db, err := drvr.Open(cfg.Get().Database.Dir, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return drvr, db, nil
+}
+
 func (s *Server) launchKadcastPeer(ctx context.Context, p *peer.MessageProcessor, g *protocol.Gossip) {
 	// launch kadcast client
 	kadPeer := kadcast.NewKadcastPeer(ctx, s.eventBus, p, g)
@@ -85,16 +113,41 @@ func (s *Server) launchKadcastPeer(ctx context.Context, p *peer.MessageProcessor
 // launches a new `CommitteeStore`, launches the Blockchain process, creates
 // and launches a monitor client (if configuration demands it), and inits the
 // Stake and Blind Bid channels.
+// A light node (network.serviceFlag = 2) runs without consensus keys,
+// consensus loop, mempool and candidate storage.
 func Setup() *Server {
 	parentCtx, parentCancel := context.WithCancel(context.Background())
 
+	services := peer.LocalServices()
+
+	var light bool
+
+	switch services {
+	case protocol.FullNode:
+	case protocol.LightNode:
+		light = true
+	default:
+		log.WithField("services", services.String()).Fatal("unsupported network.serviceFlag")
+	}
+
+	log.WithField("services", services.String()).Info("node type")
+
 	eventBus := eventbus.New()
 	rpcBus := rpcbus.New()
 
-	driver, db := heavy.CreateDBConnection()
+	driver, db, err := openDatabase()
+	if err != nil {
+		log.WithError(err).Fatal("could not open database")
+	}
+
+	// Txs are relayed by announcing their hashes, if enabled
+	var inv *inventory.Inventory
+	if cfg.Get().Network.TxRelay.Announce && !light {
+		inv = inventory.NewDefault()
+	}
 
 	processor := peer.NewMessageProcessor(eventBus)
-	registerPeerServices(processor, db, eventBus, rpcBus)
+	registerPeerServices(processor, db, eventBus, rpcBus, light, inv)
 
 	// Instantiate gRPC client
 	// TODO: get address from config
@@ -105,10 +158,18 @@ func Setup() *Server {
 
 	log.Info("grpc connection with rusk service established")
 
-	m := mempool.NewMempool(db, eventBus, rpcBus, proxy.Prober())
-	m.Run(parentCtx)
+	var m *mempool.Mempool
+
+	if !light {
+		m = mempool.NewMempool(db, eventBus, rpcBus, proxy.Prober())
+		if inv != nil {
+			m.AnnounceTxs(inv)
+		}
+
+		m.Run(parentCtx)
 
-	processor.Register(topics.Tx, m.ProcessTx)
+		processor.Register(topics.Tx, m.ProcessTx)
+	}
 
 	// Instantiate API server
 	if cfg.Get().API.Enabled {
@@ -123,34 +184,46 @@ func Setup() *Server {
 		}
 	}
 
-	keys, err := loadConsensusKeys()
-	if err != nil {
-		log.WithError(err).Fatal("could not load consensus keys")
-	}
+	var cl *loop.Consensus
 
-	e := &consensus.Emitter{
-		EventBus:    eventBus,
-		RPCBus:      rpcBus,
-		Keys:        keys,
-		TimerLength: time.Duration(cfg.Get().Consensus.ConsensusTimeOut) * time.Second,
-	}
+	if !light {
+		keys, err := loadConsensusKeys()
+		if err != nil {
+			log.WithError(err).Fatal("could not load consensus keys")
+		}
+
+		e := &consensus.Emitter{
+			EventBus:    eventBus,
+			RPCBus:      rpcBus,
+			Keys:        keys,
+			TimerLength: time.Duration(cfg.Get().Consensus.ConsensusTimeOut) * time.Second,
+		}
 
-	cl := loop.New(e)
-	processor.Register(topics.Candidate, cl.ProcessCandidate)
+		cl = loop.New(e)
+		processor.Register(topics.Candidate, cl.ProcessCandidate)
+	}
 
 	c, err := LaunchChain(parentCtx, cl, proxy, eventBus, rpcBus, nil, db)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	processor.Register(topics.Block, c.ProcessBlockFromNetwork)
+	// Full blocks go through the compact block broker, which rebuilds the
+	// compact ones
+	cbb := responding.NewCompactBlockBroker(db, rpcBus, eventBus, c.ProcessBlockFromNetwork)
+
+	processor.Register(topics.Block, cbb.ProcessBlock)
+	processor.Register(topics.CompactBlock, cbb.ProcessCompactBlock)
+	processor.Register(topics.GetBlockTxs, cbb.ProvideBlockTxs)
+	processor.Register(topics.BlockTxs, cbb.ProcessBlockTxs)
+	processor.Register(topics.Headers, c.ProcessHeaders)
 
 	// Instantiate GraphQL server
 	var gqlServer *gql.Server
 
 	if cfg.Get().Gql.Enabled {
 		var e error
-		if gqlServer, e = gql.NewHTTPServer(eventBus, rpcBus); e != nil {
+		if gqlServer, e = gql.NewHTTPServer(eventBus, rpcBus, db); e != nil {
 			log.WithError(e).Error("graphq server failed to run")
 		} else {
 			if e = gqlServer.Start(parentCtx); e != nil {
@@ -188,10 +261,12 @@ func Setup() *Server {
 
 	// Schedule mempool updates requesting a few seconds after all components
 	// are fully launched
-	go func() {
-		time.Sleep(5 * time.Second)
-		m.RequestUpdates()
-	}()
+	if m != nil {
+		go func() {
+			time.Sleep(5 * time.Second)
+			m.RequestUpdates()
+		}()
+	}
 
 	if err := c.RestartConsensus(); err != nil {
 		log.WithError(err).Warn("StartConsensus returned err")
@@ -236,26 +311,40 @@ func (s *Server) Close() {
 	s.eventBus.Close()
 }
 
-func registerPeerServices(processor *peer.MessageProcessor, db database.DB, eventBus *eventbus.EventBus, rpcBus *rpcbus.RPCBus) {
+func registerPeerServices(processor *peer.MessageProcessor, db database.DB, eventBus *eventbus.EventBus, rpcBus *rpcbus.RPCBus, light bool, inv *inventory.Inventory) {
 	processor.Register(topics.Ping, responding.ProcessPing)
 	dataBroker := responding.NewDataBroker(db, rpcBus)
-	dataRequestor := responding.NewDataRequestor(db, rpcBus)
+	dataRequestor := responding.NewDataRequestor(db, rpcBus, eventBus)
 	bhb := responding.NewBlockHashBroker(db)
-	cb := responding.NewCandidateBroker(db)
-	cp := consensus.NewPublisher(eventBus)
+
+	if inv != nil {
+		dataBroker.TrackInventory(inv)
+		dataRequestor.TrackInventory(inv)
+	}
 
 	processor.Register(topics.GetData, dataBroker.MarshalObjects)
-	processor.Register(topics.MemPool, dataBroker.MarshalMempoolTxs)
 	processor.Register(topics.Ping, responding.ProcessPing)
 	processor.Register(topics.Pong, responding.ProcessPong)
 	processor.Register(topics.Inv, dataRequestor.RequestMissingItems)
 	processor.Register(topics.GetBlocks, bhb.AdvertiseMissingBlocks)
+	processor.Register(topics.GetHeaders, bhb.ProvideHeaders)
+	processor.Register(topics.Challenge, responding.CompleteChallenge)
+
+	// Light nodes take no part in consensus and keep no mempool
+	if light {
+		dataRequestor.BlocksOnly()
+		return
+	}
+
+	cb := responding.NewCandidateBroker(db)
+	cp := consensus.NewPublisher(eventBus)
+
+	processor.Register(topics.MemPool, dataBroker.MarshalMempoolTxs)
 	processor.Register(topics.GetCandidate, cb.ProvideCandidate)
 	processor.Register(topics.NewBlock, cp.Process)
 	processor.Register(topics.Reduction, cp.Process)
 	processor.Register(topics.Agreement, cp.Process)
 	processor.Register(topics.AggrAgreement, cp.Process)
-	processor.Register(topics.Challenge, responding.CompleteChallenge)
 }
 
 func setupGRPCClients(ctx context.Context) (transactions.Proxy, *grpc.ClientConn) {
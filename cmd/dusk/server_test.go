@@ -0,0 +1,109 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package main
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	cfg "github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/config/genesis"
+	consensuskey "github.com/dusk-network/dusk-blockchain/pkg/core/consensus/key"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
+	"github.com/dusk-network/dusk-protobuf/autogen/go/rusk"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// ruskStateMock provides the minimum of the Rusk State service needed to boot
+// a node on an empty chain.
+type ruskStateMock struct {
+	rusk.UnimplementedStateServer
+}
+
+func (s *ruskStateMock) GetProvisioners(context.Context, *rusk.GetProvisionersRequest) (*rusk.GetProvisionersResponse, error) {
+	return &rusk.GetProvisionersResponse{}, nil
+}
+
+func (s *ruskStateMock) GetStateRoot(context.Context, *rusk.GetStateRootRequest) (*rusk.GetStateRootResponse, error) {
+	return &rusk.GetStateRootResponse{StateRoot: genesis.Decode().Header.StateHash}, nil
+}
+
+func startRuskMock(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	rusk.RegisterStateServer(srv, &ruskStateMock{})
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func mockNodeConfig(t *testing.T, driver string) {
+	dir := t.TempDir()
+
+	keysFile := filepath.Join(dir, "consensus.keys")
+	keys := consensuskey.NewRandKeys()
+	require.NoError(t, keys.Save("password", keysFile))
+	t.Setenv("DUSK_CONSENSUS_KEYS_PASS", "password")
+
+	r := cfg.Registry{}
+	r.General.Network = "testnet"
+	r.Database.Driver = driver
+	r.Database.Dir = filepath.Join(dir, "chain")
+	r.Mempool.PoolType = "hashmap"
+	r.Mempool.MaxSizeMB = 1
+	r.Mempool.Updates.Disabled = true
+	r.Consensus.KeysFile = keysFile
+	r.Consensus.ConsensusTimeOut = cfg.DefaultConsensusTimeOutSeconds
+	r.State.BlockGasLimit = cfg.DefaultBlockGasLimit
+	r.RPC.Rusk.Network = "tcp"
+	r.RPC.Rusk.Address = startRuskMock(t)
+	r.RPC.Rusk.ConnectionTimeout = 5000
+	r.RPC.Rusk.ContractTimeout = 1000
+	r.RPC.Rusk.DefaultTimeout = 1000
+
+	cfg.Mock(&r)
+}
+
+// TestSetupWithDriver ensures a node boots with any of the supported database
+// drivers.
+func TestSetupWithDriver(t *testing.T) {
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	for _, driver := range []string{heavy.DriverName, lite.DriverName} {
+		t.Run(driver, func(t *testing.T) {
+			mockNodeConfig(t, driver)
+
+			srv := Setup()
+			require.Equal(t, driver, srv.dbDriver.Name())
+
+			srv.Close()
+		})
+	}
+}
+
+func TestOpenDatabaseUnknownDriver(t *testing.T) {
+	r := cfg.Registry{}
+	r.Database.Driver = "unknown_v0.1.0"
+	cfg.Mock(&r)
+
+	_, _, err := openDatabase()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), heavy.DriverName)
+	require.Contains(t, err.Error(), lite.DriverName)
+}
@@ -0,0 +1,72 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package dbcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
+)
+
+// RunCheck verifies the integrity of a heavy database located at dir and
+// prints the report in JSON format. If repair is true, secondary indexes are
+// rebuilt. An error is returned if any issue is left unrepaired.
+//
+// The node using the database must be stopped.
+func RunCheck(dir string, repair bool) error {
+	if len(dir) == 0 {
+		return errors.New("no database directory provided")
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+
+	drvr, err := database.From(heavy.DriverName)
+	if err != nil {
+		return err
+	}
+
+	db, err := drvr.Open(dir, !repair)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = drvr.Close()
+	}()
+
+	report, err := db.(heavy.DB).Check(repair)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+
+	var unrepaired int
+
+	for _, i := range report.Issues {
+		if !repair || !i.Repairable {
+			unrepaired++
+		}
+	}
+
+	if unrepaired > 0 {
+		return fmt.Errorf("%d issue(s) found", unrepaired)
+	}
+
+	return nil
+}
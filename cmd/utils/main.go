@@ -10,8 +10,10 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/dusk-network/dusk-blockchain/cmd/utils/dbcheck"
 	"github.com/dusk-network/dusk-blockchain/cmd/utils/grpcclient"
 	"github.com/dusk-network/dusk-blockchain/cmd/utils/mock"
+	"github.com/dusk-network/dusk-blockchain/cmd/utils/replay"
 	"github.com/dusk-network/dusk-blockchain/cmd/utils/tps"
 
 	"github.com/dusk-network/dusk-blockchain/cmd/utils/metrics"
@@ -30,6 +32,8 @@ func main() {
 		setConfigCMD,
 		tpsCMD,
 		automateCMD,
+		dbCheckCMD,
+		replayCMD,
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -116,6 +120,33 @@ var (
 		Value: 5,
 	}
 
+	dbDirFlag = cli.StringFlag{
+		Name:  "dbdir",
+		Usage: "heavy database directory , eg: --dbdir=/var/dusk/chain",
+		Value: "chain",
+	}
+
+	repairFlag = cli.BoolFlag{
+		Name:  "repair",
+		Usage: "rebuild secondary indexes from the primary header and tx records",
+	}
+
+	captureFlag = cli.StringSliceFlag{
+		Name:  "capture",
+		Usage: "capture file, repeated for rotated files oldest first, eg: --capture=dusk.cap.1 --capture=dusk.cap",
+	}
+
+	replayDBDirFlag = cli.StringFlag{
+		Name:  "dbdir",
+		Usage: "copy of the heavy database of the recording node, opened read-only, in-memory if empty, eg: --dbdir=/tmp/chain",
+	}
+
+	speedFlag = cli.Float64Flag{
+		Name:  "speed",
+		Usage: "replay speed relative to the recording, 0 for no delay, eg: --speed=10",
+		Value: 1,
+	}
+
 	metricsCMD = cli.Command{
 		Name:      "metrics",
 		Usage:     "expose a metrics endpoint",
@@ -183,6 +214,35 @@ var (
 		},
 		Description: `Automate consensus participation of a node until the process exits`,
 	}
+
+	// dbcheck command
+	// Example ./bin/utils dbcheck --dbdir=/tmp/dusk-node/chain --repair.
+	dbCheckCMD = cli.Command{
+		Name:      "dbcheck",
+		Usage:     "verify the integrity of a stopped node database",
+		Action:    dbCheckAction,
+		ArgsUsage: "",
+		Flags: []cli.Flag{
+			dbDirFlag,
+			repairFlag,
+		},
+		Description: `Walk the whole heavy database and report chain linkage, index and registry inconsistencies`,
+	}
+
+	// replay command
+	// Example ./bin/utils replay --capture=/tmp/dusk-node/dusk.cap --speed=10.
+	replayCMD = cli.Command{
+		Name:      "replay",
+		Usage:     "replay the messages recorded by a node capture",
+		Action:    replayAction,
+		ArgsUsage: "",
+		Flags: []cli.Flag{
+			captureFlag,
+			speedFlag,
+			replayDBDirFlag,
+		},
+		Description: `Feed a capture of inbound messages into a fresh message processor, without rate limiting nor duplicate filtering, wired to the responding brokers and the consensus publisher of a node, and report the messages replayed per topic`,
+	}
 )
 
 // metricsAction will expose the metrics endpoint.
@@ -228,3 +288,18 @@ func automateAction(ctx *cli.Context) error {
 	sendStakeTimeout := ctx.Int(sendStakeTimeoutFlag.Name)
 	return grpcclient.AutomateStakes(address, sendStakeTimeout)
 }
+
+func dbCheckAction(ctx *cli.Context) error {
+	dir := ctx.String(dbDirFlag.Name)
+	repair := ctx.Bool(repairFlag.Name)
+
+	return dbcheck.RunCheck(dir, repair)
+}
+
+func replayAction(ctx *cli.Context) error {
+	files := ctx.StringSlice(captureFlag.Name)
+	speed := ctx.Float64(speedFlag.Name)
+	dir := ctx.String(replayDBDirFlag.Name)
+
+	return replay.RunReplay(files, speed, dir, replay.DefaultSetup)
+}
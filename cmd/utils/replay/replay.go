@@ -0,0 +1,234 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/capture"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/responding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rpcbus"
+)
+
+// TopicReport counts the replayed messages of a topic.
+type TopicReport struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
+// Report of a replay.
+type Report struct {
+	Messages int                     `json:"messages"`
+	Topics   map[string]*TopicReport `json:"topics"`
+}
+
+func (r *Report) add(topic topics.Topic, err error) {
+	t, ok := r.Topics[topic.String()]
+	if !ok {
+		t = &TopicReport{}
+		r.Topics[topic.String()] = t
+	}
+
+	t.Replayed++
+	if err != nil {
+		t.Failed++
+	}
+}
+
+// Setup registers on the processor the components processing the replayed
+// messages. They are connected through the eventbus and the rpcbus of the
+// replay, and read the chain from db.
+type Setup func(eb *eventbus.EventBus, rb *rpcbus.RPCBus, db database.DB, processor *peer.MessageProcessor) error
+
+// DefaultSetup wires the components a node registers on its processor which
+// do not depend on Rusk: the responding brokers and the consensus publisher.
+// The blocks they rebuild are published on the eventbus in place of being
+// accepted by the chain.
+func DefaultSetup(eb *eventbus.EventBus, rb *rpcbus.RPCBus, db database.DB, processor *peer.MessageProcessor) error {
+	dataBroker := responding.NewDataBroker(db, rb)
+	dataRequestor := responding.NewDataRequestor(db, rb, eb)
+	bhb := responding.NewBlockHashBroker(db)
+	cb := responding.NewCandidateBroker(db)
+	cp := consensus.NewPublisher(eb)
+
+	processor.Register(topics.Ping, responding.ProcessPing)
+	processor.Register(topics.Pong, responding.ProcessPong)
+	processor.Register(topics.GetData, dataBroker.MarshalObjects)
+	processor.Register(topics.MemPool, dataBroker.MarshalMempoolTxs)
+	processor.Register(topics.Inv, dataRequestor.RequestMissingItems)
+	processor.Register(topics.GetBlocks, bhb.AdvertiseMissingBlocks)
+	processor.Register(topics.GetHeaders, bhb.ProvideHeaders)
+	processor.Register(topics.Challenge, responding.CompleteChallenge)
+	processor.Register(topics.GetCandidate, cb.ProvideCandidate)
+	processor.Register(topics.NewBlock, cp.Process)
+	processor.Register(topics.Reduction, cp.Process)
+	processor.Register(topics.Agreement, cp.Process)
+	processor.Register(topics.AggrAgreement, cp.Process)
+
+	publish := func(_ string, m message.Message) ([]bytes.Buffer, error) {
+		eb.Publish(topics.Block, m)
+		return nil, nil
+	}
+
+	cbb := responding.NewCompactBlockBroker(db, rb, eb, publish)
+
+	processor.Register(topics.Block, cbb.ProcessBlock)
+	processor.Register(topics.CompactBlock, cbb.ProcessCompactBlock)
+	processor.Register(topics.GetBlockTxs, cbb.ProvideBlockTxs)
+	processor.Register(topics.BlockTxs, cbb.ProcessBlockTxs)
+
+	return nil
+}
+
+// RunReplay feeds the capture files, oldest first, into a fresh replay
+// MessageProcessor, at the recorded pace divided by speed, or without delay
+// if speed is 0. The processor has neither rate limiter nor duplicate filter,
+// so that every recorded message is processed.
+//
+// The components processing the messages are registered by setup, or by
+// DefaultSetup if nil. To reproduce an issue of a specific component, a
+// caller wires it in its own Setup, on the provided eventbus and rpcbus.
+// They read the heavy database located at dir, opened read-only, so that a
+// copy of the recording node database can be replayed against. An empty
+// in-memory database is used if dir is empty.
+//
+// The report is printed in JSON format.
+func RunReplay(files []string, speed float64, dir string, setup Setup) error {
+	if len(files) == 0 {
+		return errors.New("no capture file provided")
+	}
+
+	if speed < 0 {
+		return fmt.Errorf("invalid speed %f", speed)
+	}
+
+	if setup == nil {
+		setup = DefaultSetup
+	}
+
+	drvr, db, err := openDatabase(dir)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = drvr.Close()
+	}()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	eb := eventbus.New()
+	rb := rpcbus.New()
+
+	defer func() {
+		rb.Close()
+		eb.Close()
+	}()
+
+	processor := peer.NewReplayProcessor(eb)
+	if err = setup(eb, rb, db, processor); err != nil {
+		return err
+	}
+
+	report, err := Replay(ctx, files, speed, eb, processor)
+	if err != nil {
+		return err
+	}
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
+
+// openDatabase opens the heavy database located at dir read-only, or an
+// in-memory lite database if dir is empty.
+func openDatabase(dir string) (database.Driver, database.DB, error) {
+	if len(dir) == 0 {
+		drvr, db := lite.CreateDBConnection()
+		return drvr, db, nil
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, nil, err
+	}
+
+	drvr, err := database.From(heavy.DriverName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := drvr.Open(dir, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return drvr, db, nil
+}
+
+// Replay feeds the capture files into the processor. The topics without a
+// registered processing function are republished on the eventbus.
+// The processor is expected to be created with peer.NewReplayProcessor.
+func Replay(ctx context.Context, files []string, speed float64, eb eventbus.Publisher, processor *peer.MessageProcessor) (*Report, error) {
+	republish := func(_ string, m message.Message) ([]bytes.Buffer, error) {
+		eb.Publish(m.Category(), m)
+		return nil, nil
+	}
+
+	for _, t := range topics.Topics {
+		if !processor.Registered(t.Topic) {
+			processor.Register(t.Topic, republish)
+		}
+	}
+
+	report := &Report{Topics: make(map[string]*TopicReport)}
+
+	for _, file := range files {
+		r, err := capture.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		n, err := capture.Replay(ctx, r, speed, func(rec *capture.Record) error {
+			if len(rec.Packet) == 0 {
+				return errors.New("empty packet")
+			}
+
+			_, err := processor.Collect(rec.Source, rec.Packet, nil, rec.Services, rec.Metadata)
+			report.add(topics.Topic(rec.Packet[0]), err)
+			return err
+		})
+
+		_ = r.Close()
+
+		report.Messages += n
+
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
@@ -12,6 +12,7 @@ require (
 	github.com/facebookgo/grace v0.0.0-20180706040059-75cf19382434
 	github.com/go-chi/render v1.0.1
 	github.com/gogo/protobuf v1.3.2
+	github.com/golang/snappy v0.0.1
 	github.com/google/gofountain v0.0.0-20160820054803-4928733085e9
 	github.com/gorilla/mux v1.7.4
 	github.com/gorilla/pat v1.0.1
@@ -51,7 +52,6 @@ require (
 	github.com/facebookgo/subset v0.0.0-20200203212716-c811ad88dec4 // indirect
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
 	github.com/golang/protobuf v1.4.2 // indirect
-	github.com/golang/snappy v0.0.1 // indirect
 	github.com/google/go-cmp v0.5.2 // indirect
 	github.com/gorilla/context v1.1.1 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
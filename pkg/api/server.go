@@ -8,6 +8,8 @@ package api
 
 import (
 	"context"
+	"expvar"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -97,9 +99,9 @@ func (s *Server) Start(srv *Server) error {
 func (s *Server) InitRouting() *pat.Router {
 	r := pat.New()
 
-	r.Handle("/healthcheck", healthcheck.Handler(
+	checks := []healthcheck.Option{
 		// WithTimeout allows you to set a max overall timeout.
-		healthcheck.WithTimeout(5*time.Second),
+		healthcheck.WithTimeout(5 * time.Second),
 
 		healthcheck.WithChecker(
 			"status", healthcheck.CheckerFunc(
@@ -108,7 +110,23 @@ func (s *Server) InitRouting() *pat.Router {
 				},
 			),
 		),
-	))
+	}
+
+	if cfg.Get().Kadcast.Enabled {
+		checks = append(checks, healthcheck.WithChecker(
+			"kadcast", healthcheck.CheckerFunc(
+				func(ctx context.Context) error {
+					if state := capi.KadcastState(); state != "connected" {
+						return fmt.Errorf("kadcast stream is %q", state)
+					}
+
+					return nil
+				},
+			),
+		))
+	}
+
+	r.Handle("/healthcheck", healthcheck.Handler(checks...))
 
 	// init consensus API services
 	capi.StartAPI(s.eventBus, s.rpcBus)
@@ -118,6 +136,11 @@ func (s *Server) InitRouting() *pat.Router {
 	r.HandleFunc("/consensus/eventqueuestatus", capi.GetEventQueueStatusHandler).Methods("GET")
 	r.HandleFunc("/p2p/logs", capi.GetP2PLogsHandler).Methods("GET")
 	r.HandleFunc("/p2p/count", capi.GetP2PCountHandler).Methods("GET")
+	r.HandleFunc("/p2p/kadcast", capi.GetKadcastStatusHandler).Methods("GET")
+	r.HandleFunc("/p2p/addressbook", capi.GetAddressBookHandler).Methods("GET")
+
+	// runtime metrics, such as the kadcast queues
+	r.Handle("/debug/vars", expvar.Handler()).Methods("GET")
 
 	return r
 }
@@ -25,7 +25,141 @@ type networkConfiguration struct {
 	MaxDupeMapItems  uint32
 	MaxDupeMapExpire uint32
 
+	// DupeFilter selects the duplicate filter of the inbound messages
+	DupeFilter dupeFilter
+
 	ServiceFlag uint8
+
+	// Misbehaviour scoring of peers
+	Scoring scoring
+
+	// RateLimits of inbound messages per source peer, keyed by topic name
+	RateLimits map[string]rateLimit
+
+	// CompactBlocks relay of the accepted blocks
+	CompactBlocks compactBlocks
+
+	// Sync of the blocks the node is missing
+	Sync syncConfiguration
+
+	// AddressBook of the peers met over the TCP gossip network
+	AddressBook addressBook
+
+	// Compression of the TCP gossip frames, negotiated in the handshake
+	Compression compression
+
+	// Encryption of the TCP gossip connections
+	Encryption encryption
+
+	// Capture of the inbound messages, for replay
+	Capture captureConfiguration
+
+	// TxRelay of the mempool transactions
+	TxRelay txRelay
+}
+
+type txRelay struct {
+	// Announce relays the tx hashes in Inv messages, the peers fetching the
+	// unknown txs with GetData, instead of broadcasting the full txs
+	Announce bool
+	// Window is how long the tx hashes are batched before being announced
+	Window string
+	// MaxInvItems is the number of tx hashes announced at once
+	MaxInvItems uint
+	// Fanout is the number of random nodes the batches are also announced
+	// to while fewer relay peers are known
+	Fanout uint8
+	// MaxKnown is the number of tx hashes tracked per peer
+	MaxKnown uint
+	// RequestTimeout is how long a peer is given to send a requested tx
+	// before it is requested from another peer which announced it
+	RequestTimeout string
+}
+
+type dupeFilter struct {
+	// Type is either "map" (default), the cuckoo filter reset on expiry, or
+	// "bloom", the rotating bloom filters of fixed memory footprint
+	Type string
+	// FalsePositiveRate of the bloom filters
+	FalsePositiveRate float64
+	// Generations is the number of rotating bloom filters
+	Generations uint
+}
+
+type captureConfiguration struct {
+	// File records the inbound messages. The capture is disabled if empty
+	File string
+	// MaxSize in MB of the capture file before it is rotated
+	MaxSize uint
+	// MaxFiles is the number of rotated capture files kept
+	MaxFiles uint
+}
+
+type encryption struct {
+	// Mode is either "disabled" (default), "optional" or "required"
+	Mode string
+	// KeyFile persists the node key identifying the node. A new key is
+	// generated if the file does not exist, an ephemeral one if empty
+	KeyFile string
+}
+
+type compression struct {
+	Enabled bool
+	// Threshold is the payload size in bytes above which frames are
+	// compressed
+	Threshold uint
+}
+
+type addressBook struct {
+	// File persisting the address book. The address book is disabled if
+	// empty
+	File string
+	// Reconnect is the number of known-good peers dialed at startup
+	Reconnect uint
+	// MaxFailures is the number of consecutive failures after which a peer
+	// is no longer known-good
+	MaxFailures uint
+	// MaxEntries bounds the number of peers recorded
+	MaxEntries uint
+}
+
+type syncConfiguration struct {
+	// HeadersFirst fetches and verifies the header chain before downloading
+	// the blocks from several peers over Kadcast
+	HeadersFirst bool
+	// Peers is the number of random peers asked for headers
+	Peers uint
+	// WindowSize is the number of blocks requested at once from a peer
+	WindowSize uint
+	// StallTimeout is how long a peer has to deliver its window before the
+	// window is reassigned
+	StallTimeout string
+}
+
+type compactBlocks struct {
+	// Enabled propagates accepted blocks as compact blocks. Compact blocks
+	// are always rebuilt on reception
+	Enabled bool
+	// Timeout is how long a compact block waits for its missing txs
+	Timeout string
+}
+
+type rateLimit struct {
+	// Rate is the number of messages per second refilling the bucket
+	Rate float64
+	// Burst is the capacity of the bucket
+	Burst int
+}
+
+type scoring struct {
+	// DisconnectScore is the score under which a peer is disconnected
+	DisconnectScore int
+	// BanScore is the score under which the peer host is banned
+	BanScore int
+	// BanDuration is how long a banned host is refused
+	BanDuration string
+	// RecoveryInterval is the time to recover one score point
+	RecoveryInterval string
 }
 
 type clientConfiguration struct {
@@ -40,12 +174,85 @@ type kadcastConfiguration struct {
 	BootstrapAddr []string
 
 	Grpc clientConfiguration
+
+	// Reconnection policy of the listener stream
+	Reconnect reconnect
+
+	// Processing of inbound messages
+	Workers workers
+
+	// Routing selects the Kadcast implementation, either the "rusk" network
+	// service over gRPC (default) or the in-process "native" one. The native
+	// node listens on Address and bootstraps off BootstrapAddr over UDP
+	Routing string
+	Native  nativeRouting
+}
+
+type nativeRouting struct {
+	// BucketSize is the max number of peers per k-bucket
+	BucketSize uint
+	// Redundancy is the number of peers per k-bucket a message is
+	// broadcast to
+	Redundancy uint
+	// RefreshInterval is the idle time after which a k-bucket is refreshed
+	RefreshInterval string
+
+	// Raptor-coded broadcast of large messages (blocks, candidates)
+	Raptor raptor
+}
+
+type raptor struct {
+	Enabled bool
+	// MinSize is the size in bytes from which a message is raptor-coded
+	MinSize uint
+	// Timeout is the time after which an incomplete message is discarded
+	Timeout string
+}
+
+type workers struct {
+	// Count is the number of goroutines processing inbound messages
+	Count uint
+	// QueueSize is the capacity of each priority queue (consensus, block
+	// and tx messages)
+	QueueSize uint
+	// DropPolicy on queue overflow is either "oldest" or "newest"
+	DropPolicy string
+}
+
+type reconnect struct {
+	// MinBackoff is the delay before the first reconnection attempt. It is
+	// doubled on each failed attempt up to MaxBackoff
+	MinBackoff string
+	MaxBackoff string
+
+	// MaxAttempts is the number of consecutive failed attempts after which
+	// the connection is reported as failed. Reconnection is still attempted
+	// every MaxBackoff. Zero never reports a failure
+	MaxAttempts uint
 }
 
 // pkg/core/database package configs.
 type databaseConfiguration struct {
 	Driver string
 	Dir    string
+
+	// TxIndex enables the index of txs by contract ID and tx type
+	TxIndex bool
+
+	// Discarding of old blocks transactions data
+	Pruning pruning
+}
+
+type pruning struct {
+	// Enabled is false by default which keeps the full chain data
+	Enabled bool
+
+	// Depth is the number of blocks below the persisted block whose
+	// transactions data is kept
+	Depth uint64
+
+	// Interval is the number of accepted blocks between pruning runs
+	Interval uint64
 }
 
 // pprof configs.
@@ -113,6 +320,8 @@ type apiConfiguration struct {
 type notificationConfiguration struct {
 	BrokersNum       uint
 	ClientsPerBroker uint
+	// EvictedTxs notifies the txs evicted from the mempool as well
+	EvictedTxs bool
 }
 
 // Performance parameters.
@@ -138,8 +347,34 @@ type mempoolConfiguration struct {
 	// Enables mempool updates at startup
 	Updates updates
 
+	// Re-verification of the mempool content after each accepted block
+	Revalidation revalidation
+
 	// Artificial delay applied when mempool is empty
 	ExtractionDelaySecs int64
+
+	// TxTTL is the maximum time a transaction can stay in the mempool
+	// (e.g "2h"). Empty value disables transactions expiry.
+	TxTTL string
+	// ReapInterval is the period of removing expired transactions (e.g "1m").
+	ReapInterval string
+
+	// SnapshotFile is the file the mempool content is persisted into on
+	// shutdown and reloaded from at start-up. Empty value disables it.
+	SnapshotFile string
+	// SnapshotInterval is the period of persisting the mempool content
+	// (e.g "5m"). Empty value persists it on shutdown only.
+	SnapshotInterval string
+
+	// MinFee is the minimum gas price a transaction should pay to be
+	// accepted in the mempool.
+	MinFee uint64
+	// FeeFloorThreshold is the mempool fill level (in percents of MaxSizeMB)
+	// above which the minimum fee starts rising.
+	FeeFloorThreshold uint32
+	// FeeFloorMultiplier is the factor applied to MinFee when the mempool is
+	// full. Between FeeFloorThreshold and 100% the floor rises linearly.
+	FeeFloorMultiplier uint64
 }
 
 type updates struct {
@@ -150,6 +385,21 @@ type updates struct {
 	Disabled bool
 }
 
+type revalidation struct {
+	// Enabled is false by default which disables post-block revalidation
+	Enabled bool
+
+	// BatchSize is the number of transactions verified per batch
+	BatchSize uint32
+
+	// Workers is the number of concurrent verifications in a batch
+	Workers uint32
+
+	// MaxDuration caps the time spent on revalidation per block (e.g "2s").
+	// The revalidation of the next block resumes where it stopped
+	MaxDuration string
+}
+
 type consensusConfiguration struct {
 	// Path to a file that stores Consensus Keys / BLS public and secret keys
 	// if file does not exist, it will be created at startup.
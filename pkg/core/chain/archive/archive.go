@@ -0,0 +1,339 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+// Package archive implements a portable file format to store a consecutive
+// range of blockchain blocks.
+//
+// An archive starts with a header:
+//
+//	magic (8 bytes) | version (uint8) | network (var bytes)
+//
+// followed by a record per block:
+//
+//	length (uint32) | message.MarshalBlock (length bytes) | crc32c (uint32)
+//
+// Each record carries its own checksum so that a partially written archive
+// (e.g an interrupted export) can be detected and resumed from the last
+// complete block.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+)
+
+// Version is the version of the archive file format.
+const Version uint8 = 1
+
+// maxRecordSize caps the size of a single block record.
+const maxRecordSize = 256 * 1024 * 1024
+
+var (
+	magic = []byte("DUSKARCH")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// ErrInvalidArchive the file is not a chain archive.
+	ErrInvalidArchive = errors.New("archive: invalid file")
+	// ErrVersion the archive is written in an unsupported format.
+	ErrVersion = errors.New("archive: unsupported version")
+	// ErrChecksum a block record is corrupted.
+	ErrChecksum = errors.New("archive: checksum mismatch")
+	// ErrNetwork the archive is produced on a different network.
+	ErrNetwork = errors.New("archive: network mismatch")
+	// ErrNotConsecutive a block does not follow the last archived one.
+	ErrNotConsecutive = errors.New("archive: block height is not consecutive")
+)
+
+// Reader reads blocks from an archive file.
+type Reader struct {
+	f       *os.File
+	r       *bufio.Reader
+	network string
+
+	// offset of the next record.
+	offset int64
+}
+
+// Open opens an archive file for reading and validates its header.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{f: f, r: bufio.NewReader(f)}
+
+	if err := r.readHeader(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Reader) readHeader() error {
+	m := make([]byte, len(magic))
+	if _, err := io.ReadFull(r.r, m); err != nil || !bytes.Equal(m, magic) {
+		return ErrInvalidArchive
+	}
+
+	v, err := r.r.ReadByte()
+	if err != nil {
+		return ErrInvalidArchive
+	}
+
+	if v != Version {
+		return fmt.Errorf("%w: %d", ErrVersion, v)
+	}
+
+	n, err := readUint32(r.r)
+	if err != nil || n > maxRecordSize {
+		return ErrInvalidArchive
+	}
+
+	network := make([]byte, n)
+	if _, err := io.ReadFull(r.r, network); err != nil {
+		return ErrInvalidArchive
+	}
+
+	r.network = string(network)
+	r.offset = int64(len(magic) + 1 + 4 + len(network))
+
+	return nil
+}
+
+// Network returns the name of the network the archive is produced on.
+func (r *Reader) Network() string {
+	return r.network
+}
+
+// Next reads the next block. It returns io.EOF once all blocks are read and
+// io.ErrUnexpectedEOF if the archive ends with an incomplete record.
+func (r *Reader) Next() (*block.Block, error) {
+	n, err := readUint32(r.r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if n > maxRecordSize {
+		return nil, ErrChecksum
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	sum, err := readUint32(r.r)
+	if err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if crc32.Checksum(data, crcTable) != sum {
+		return nil, ErrChecksum
+	}
+
+	b := block.NewBlock()
+	if err := message.UnmarshalBlock(bytes.NewBuffer(data), b); err != nil {
+		return nil, err
+	}
+
+	r.offset += int64(4 + n + 4)
+
+	return b, nil
+}
+
+// Close closes the archive file.
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
+
+// Writer appends blocks to an archive file.
+type Writer struct {
+	f *os.File
+	w *bufio.Writer
+
+	last  uint64
+	empty bool
+}
+
+// Create opens an archive file for writing. If the file exists, it is
+// resumed: all complete records are kept, an incomplete trailing one is
+// discarded and new blocks are appended after the last archived block.
+func Create(path, network string) (*Writer, error) {
+	w := &Writer{empty: true}
+
+	offset, err := w.resume(path, network)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	w.f = f
+	w.w = bufio.NewWriter(f)
+
+	if offset == 0 {
+		if err := w.writeHeader(network); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+
+		return w, nil
+	}
+
+	// Drop an incomplete record, if any
+	if err := f.Truncate(offset); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// resume scans an existing archive and returns the offset right after its
+// last complete record. Zero offset means a new archive is to be written.
+func (w *Writer) resume(path, network string) (int64, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && info.Size() == 0) {
+		return 0, nil
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer r.Close()
+
+	if r.Network() != network {
+		return 0, fmt.Errorf("%w: %s", ErrNetwork, r.Network())
+	}
+
+	for {
+		b, err := r.Next()
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return r.offset, nil
+		}
+
+		if err != nil {
+			return 0, err
+		}
+
+		w.last = b.Header.Height
+		w.empty = false
+	}
+}
+
+func (w *Writer) writeHeader(network string) error {
+	if _, err := w.w.Write(magic); err != nil {
+		return err
+	}
+
+	if err := w.w.WriteByte(Version); err != nil {
+		return err
+	}
+
+	if err := writeUint32(w.w, uint32(len(network))); err != nil {
+		return err
+	}
+
+	_, err := w.w.WriteString(network)
+	return err
+}
+
+// Last returns the height of the last archived block. The second value is
+// false if the archive has no blocks.
+func (w *Writer) Last() (uint64, bool) {
+	return w.last, !w.empty
+}
+
+// Append writes a block to the archive. The block must directly follow the
+// last archived one.
+func (w *Writer) Append(b *block.Block) error {
+	if !w.empty && b.Header.Height != w.last+1 {
+		return fmt.Errorf("%w: %d after %d", ErrNotConsecutive, b.Header.Height, w.last)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := message.MarshalBlock(buf, b); err != nil {
+		return err
+	}
+
+	data := buf.Bytes()
+
+	if err := writeUint32(w.w, uint32(len(data))); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+
+	if err := writeUint32(w.w, crc32.Checksum(data, crcTable)); err != nil {
+		return err
+	}
+
+	w.last = b.Header.Height
+	w.empty = false
+
+	return nil
+}
+
+// Close flushes all pending records and closes the archive file.
+func (w *Writer) Close() error {
+	if err := w.w.Flush(); err != nil {
+		_ = w.f.Close()
+		return err
+	}
+
+	if err := w.f.Sync(); err != nil {
+		_ = w.f.Close()
+		return err
+	}
+
+	return w.f.Close()
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+func writeUint32(w io.Writer, v uint32) error {
+	var b [4]byte
+
+	binary.LittleEndian.PutUint32(b[:], v)
+
+	_, err := w.Write(b[:])
+	return err
+}
@@ -0,0 +1,120 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package archive
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	assert "github.com/stretchr/testify/require"
+)
+
+func writeBlocks(t *testing.T, path string, from, to uint64) []*block.Block {
+	w, err := Create(path, "testnet")
+	assert.NoError(t, err)
+
+	blocks := make([]*block.Block, 0)
+
+	for h := from; h <= to; h++ {
+		b := helper.RandomBlock(h, 1)
+		assert.NoError(t, w.Append(b))
+
+		blocks = append(blocks, b)
+	}
+
+	assert.NoError(t, w.Close())
+	return blocks
+}
+
+func readBlocks(t *testing.T, path string) ([]*block.Block, error) {
+	r, err := Open(path)
+	assert.NoError(t, err)
+
+	defer r.Close()
+
+	blocks := make([]*block.Block, 0)
+
+	for {
+		b, err := r.Next()
+		if err == io.EOF {
+			return blocks, nil
+		}
+
+		if err != nil {
+			return blocks, err
+		}
+
+		blocks = append(blocks, b)
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.archive")
+	blocks := writeBlocks(t, path, 1, 5)
+
+	read, err := readBlocks(t, path)
+	assert.NoError(t, err)
+	assert.Equal(t, len(blocks), len(read))
+
+	for i := range blocks {
+		assert.True(t, blocks[i].Equals(read[i]))
+	}
+}
+
+func TestArchiveResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.archive")
+	writeBlocks(t, path, 1, 3)
+
+	// Simulate an export interrupted in the middle of a record
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(path, info.Size()-10))
+
+	_, err = readBlocks(t, path)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	w, err := Create(path, "testnet")
+	assert.NoError(t, err)
+
+	last, ok := w.Last()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), last)
+
+	// Only consecutive blocks are accepted
+	assert.True(t, errors.Is(w.Append(helper.RandomBlock(4, 1)), ErrNotConsecutive))
+	assert.NoError(t, w.Append(helper.RandomBlock(3, 1)))
+	assert.NoError(t, w.Close())
+
+	read, err := readBlocks(t, path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(read))
+
+	// Resuming on a different network is rejected
+	_, err = Create(path, "mainnet")
+	assert.True(t, errors.Is(err, ErrNetwork))
+}
+
+func TestArchiveChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.archive")
+	writeBlocks(t, path, 1, 2)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	// Flip a byte of the last record checksum
+	data[len(data)-1] ^= 0xff
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+
+	read, err := readBlocks(t, path)
+	assert.Equal(t, ErrChecksum, err)
+	assert.Equal(t, 1, len(read))
+}
@@ -12,6 +12,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus"
@@ -25,6 +26,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/loop"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/verifiers"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/dupemap"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	"github.com/dusk-network/dusk-blockchain/pkg/util"
@@ -112,6 +114,9 @@ type Chain struct {
 
 	blacklisted dupemap.TmpMap
 	verified    sortedset.SafeSet
+
+	// pruning is set while a database pruning is in progress.
+	pruning uint32
 }
 
 // New returns a new chain object. It accepts the EventBus (for messages coming
@@ -133,7 +138,7 @@ func New(ctx context.Context, db database.DB, eventBus *eventbus.EventBus, rpcBu
 		verified:          sortedset.NewSafeSet(),
 	}
 
-	chain.synchronizer = newSynchronizer(db, chain)
+	chain.synchronizer = newSynchronizer(db, chain, eventBus)
 
 	provisioners, err := proxy.Executor().GetProvisioners(ctx)
 	if err != nil {
@@ -151,6 +156,8 @@ func New(ctx context.Context, db database.DB, eventBus *eventbus.EventBus, rpcBu
 		return nil, err
 	}
 
+	chain.indexTxs()
+
 	return chain, nil
 }
 
@@ -377,10 +384,35 @@ func (c *Chain) ProcessSyncTimerExpired(strPeerAddr string) error {
 
 	log.WithField("state", "inSync").Traceln("change sync state")
 
+	if c.downloader != nil {
+		c.downloader.reset()
+	}
+
 	c.state = c.inSync
 	return nil
 }
 
+// ProcessHeaders hands the headers received from the network over to the
+// synchronizer, during a headers-first sync.
+func (c *Chain) ProcessHeaders(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+	headers := m.Payload().(message.Headers)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return nil, c.synchronizer.processHeaders(srcPeerID, c.tip.Header.Height, headers.Headers)
+}
+
+// VerifyHeaderCertificate checks the certificate of a header with the
+// current provisioners.
+func (c *Chain) VerifyHeaderCertificate(h, prev *block.Header) error {
+	if err := agreement.CheckBlockCertificate(*c.p, block.Block{Header: h}, prev.Seed); err != nil {
+		return score.Wrap(score.InvalidCertificate, err)
+	}
+
+	return nil
+}
+
 // acceptSuccessiveBlock will accept a block which directly follows the chain
 // tip, and advertises it to the node's peers.
 func (c *Chain) acceptSuccessiveBlock(blk block.Block, metadata *message.Metadata) error {
@@ -553,7 +585,7 @@ func (c *Chain) isValidHeader(newBlock, prevBlock block.Block, provisioners user
 	var err error
 	if err = agreement.CheckBlockCertificate(provisioners, newBlock, prevBlock.Header.Seed); err != nil {
 		l.WithError(err).Error("certificate verification failed")
-		return err
+		return score.Wrap(score.InvalidCertificate, err)
 	}
 
 	return nil
@@ -672,6 +704,10 @@ func (c *Chain) postAcceptBlock(blk block.Block, l *logrus.Entry) {
 	}
 
 	diagnostics.LogPublishErrors("chain/chain.go, topics.AcceptedBlock", errList)
+
+	// 3. Discard transactions data of old blocks, if enabled
+	c.tryPrune(blk.Header.Height)
+
 	l.Debug("procedure ended")
 }
 
@@ -736,6 +772,14 @@ func (c *Chain) ExecuteStateTransition(ctx context.Context, txs []transactions.C
 func (c *Chain) kadcastBlock(blk block.Block, metadata *message.Metadata) error {
 	log.WithField("blk_height", blk.Header.Height).Trace("propagate block")
 
+	if config.Get().Network.CompactBlocks.Enabled {
+		return c.kadcastCompactBlock(blk, metadata)
+	}
+
+	return c.kadcastFullBlock(blk, metadata)
+}
+
+func (c *Chain) kadcastFullBlock(blk block.Block, metadata *message.Metadata) error {
 	buf := new(bytes.Buffer)
 	if err := message.MarshalBlock(buf, &blk); err != nil {
 		return err
@@ -749,6 +793,72 @@ func (c *Chain) kadcastBlock(blk block.Block, metadata *message.Metadata) error
 	return nil
 }
 
+// kadcastCompactBlock propagates the header and the short tx IDs of a block,
+// to be rebuilt by the receivers out of their mempool. The txs missing from
+// the own mempool are prefilled, as the receivers are unlikely to have them
+// either: the Distribute tx is never in a mempool, and the other ones were
+// received late. Without a mempool to look them up, the full block is
+// propagated.
+func (c *Chain) kadcastCompactBlock(blk block.Block, metadata *message.Metadata) error {
+	prefilled, err := c.unknownTxs(blk)
+	if err != nil {
+		log.WithError(err).Debug("could not look up block txs, propagate full block")
+		return c.kadcastFullBlock(blk, metadata)
+	}
+
+	cb, err := message.NewCompactBlock(&blk, prefilled...)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := cb.Encode(buf); err != nil {
+		return err
+	}
+
+	if err := topics.Prepend(buf, topics.CompactBlock); err != nil {
+		return err
+	}
+
+	c.eventBus.Publish(topics.Kadcast, message.NewWithMetadata(topics.CompactBlock, *buf, metadata))
+	return nil
+}
+
+// unknownTxs returns the indexes of the block txs missing from the mempool.
+// The block is looked up before being accepted, as the mempool drops its
+// txs afterwards.
+func (c *Chain) unknownTxs(blk block.Block) ([]int, error) {
+	key := message.NewShortIDKey(blk.Header.Hash, 0)
+	ids := make([]uint64, len(blk.Txs))
+
+	for i, tx := range blk.Txs {
+		txID, err := tx.CalculateHash()
+		if err != nil {
+			return nil, err
+		}
+
+		ids[i] = key.ShortID(txID)
+	}
+
+	timeoutGetMempoolTXs := time.Duration(config.Get().Timeout.TimeoutGetMempoolTXs) * time.Second
+
+	resp, err := c.rpcBus.Call(topics.GetMempoolTxsByShortIDs, rpcbus.NewRequest(message.ShortIDsLookup{Key: key, IDs: ids}), timeoutGetMempoolTXs)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := resp.([]transactions.ContractCall)
+	unknown := make([]int, 0)
+
+	for i := range blk.Txs {
+		if i >= len(txs) || txs[i] == nil {
+			unknown = append(unknown, i)
+		}
+	}
+
+	return unknown, nil
+}
+
 // getRoundUpdate constructs RoundUpdate and returns a deep copy.
 func (c *Chain) getRoundUpdate() consensus.RoundUpdate {
 	r := consensus.RoundUpdate{
@@ -18,7 +18,12 @@ import (
 
 // RestartConsensus implements Stop and Start Consensus.
 // This is a safer approach to ensure we do not duplicate Consensus loop.
+// It is a no-op on a light node, which runs without consensus loop.
 func (c *Chain) RestartConsensus() error {
+	if c.loop == nil {
+		return nil
+	}
+
 	c.StopConsensus()
 	return c.startConsensus()
 }
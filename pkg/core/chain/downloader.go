@@ -0,0 +1,270 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package chain
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+)
+
+const (
+	defaultSyncPeers        = 8
+	defaultSyncWindowSize   = 16
+	defaultSyncStallTimeout = 5 * time.Second
+)
+
+// window is a range of consecutive heights whose blocks are requested from
+// a single peer.
+type window struct {
+	from, to uint64
+	// peer is empty while the window is not assigned.
+	peer     string
+	deadline time.Time
+}
+
+// downloader drives the headers-first sync. It collects the verified header
+// chain from the peers and downloads the blocks in windows from the peers
+// agreeing on it, several at once. The windows of the peers delivering no
+// block in time are reassigned to another one.
+// NOTE: as the synchronizer, the downloader is not thread-safe.
+type downloader struct {
+	publisher    eventbus.Publisher
+	numPeers     int
+	windowSize   uint64
+	stallTimeout time.Duration
+
+	// owner is the peer which initiated the sync. It is empty when no
+	// sync is running.
+	owner string
+	// headers of the verified chain, by height.
+	headers map[uint64]*block.Header
+	// last is the height of the highest verified header.
+	last uint64
+	// peers agreeing on the header chain, with the window they serve.
+	peers   map[string]*window
+	windows []*window
+	// next is the lowest height not yet in a window.
+	next uint64
+}
+
+func newDownloader(publisher eventbus.Publisher) *downloader {
+	cfg := config.Get().Network.Sync
+
+	d := &downloader{
+		publisher:    publisher,
+		numPeers:     defaultSyncPeers,
+		windowSize:   defaultSyncWindowSize,
+		stallTimeout: defaultSyncStallTimeout,
+	}
+
+	if cfg.Peers > 0 {
+		d.numPeers = int(cfg.Peers)
+	}
+
+	if cfg.WindowSize > 0 {
+		d.windowSize = uint64(cfg.WindowSize)
+	}
+
+	if len(cfg.StallTimeout) > 0 {
+		var err error
+
+		d.stallTimeout, err = time.ParseDuration(cfg.StallTimeout)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse sync stall timeout")
+		}
+	}
+
+	d.reset()
+	return d
+}
+
+// reset drops the state of the previous sync.
+func (d *downloader) reset() {
+	d.owner = ""
+	d.headers = make(map[uint64]*block.Header)
+	d.peers = make(map[string]*window)
+	d.windows = nil
+	d.last = 0
+	d.next = 0
+}
+
+func (d *downloader) running() bool {
+	return len(d.owner) > 0
+}
+
+// start a sync from currentHeight, requesting the headers following the
+// locator from random peers. The request to the owner is returned, as it
+// is sent back as a response.
+func (d *downloader) start(owner string, currentHeight uint64, locator []byte) ([]bytes.Buffer, error) {
+	d.reset()
+	d.owner = owner
+	d.last = currentHeight
+	d.next = currentHeight + 1
+
+	msg := &message.GetBlocks{Locators: [][]byte{locator}}
+
+	buf := topics.GetHeaders.ToBuffer()
+	if err := msg.Encode(&buf); err != nil {
+		return nil, err
+	}
+
+	if d.publisher != nil {
+		req := message.NewWithMetadata(topics.KadcastSendToMany, buf, &message.Metadata{NumNodes: byte(d.numPeers)})
+		d.publisher.Publish(topics.KadcastSendToMany, req)
+	}
+
+	return []bytes.Buffer{buf}, nil
+}
+
+// header returns the verified header at height, if any.
+func (d *downloader) header(height uint64) *block.Header {
+	return d.headers[height]
+}
+
+// addHeaders extends the verified chain with consecutive headers, following
+// either the chain tip or the last verified header, and registers the peer
+// which provided them.
+func (d *downloader) addHeaders(peer string, headers []*block.Header) {
+	for _, h := range headers {
+		if h.Height > d.last {
+			d.headers[h.Height] = h
+			d.last = h.Height
+		}
+	}
+
+	if _, ok := d.peers[peer]; !ok {
+		d.peers[peer] = nil
+	}
+}
+
+// delivered extends the deadline of the window of a peer delivering its
+// blocks.
+func (d *downloader) delivered(peer string) {
+	if w := d.peers[peer]; w != nil {
+		w.deadline = time.Now().Add(d.stallTimeout)
+	}
+}
+
+// schedule reassigns the stalled windows and assigns new ones, up to the
+// target height, to the idle peers. has reports the heights no longer to be
+// downloaded.
+func (d *downloader) schedule(target uint64, has func(height uint64) bool) {
+	now := time.Now()
+	windows := d.windows[:0]
+
+	for _, w := range d.windows {
+		if w.complete(has) {
+			if w.peer != "" {
+				d.peers[w.peer] = nil
+			}
+
+			continue
+		}
+
+		if w.peer != "" && now.After(w.deadline) {
+			slog.WithField("r_addr", w.peer).
+				WithField("from", w.from).
+				WithField("to", w.to).
+				Warn("sync window stalled")
+
+			delete(d.peers, w.peer)
+			w.peer = ""
+		}
+
+		windows = append(windows, w)
+	}
+
+	d.windows = windows
+
+	limit := d.last
+	if target < limit {
+		limit = target
+	}
+
+	for d.next <= limit {
+		to := d.next + d.windowSize - 1
+		if to > limit {
+			to = limit
+		}
+
+		d.windows = append(d.windows, &window{from: d.next, to: to})
+		d.next = to + 1
+	}
+
+	for _, w := range d.windows {
+		if w.peer != "" {
+			continue
+		}
+
+		peer, ok := d.idlePeer()
+		if !ok {
+			return
+		}
+
+		d.request(peer, w, has, now)
+	}
+}
+
+func (d *downloader) idlePeer() (string, bool) {
+	for peer, w := range d.peers {
+		if w == nil {
+			return peer, true
+		}
+	}
+
+	return "", false
+}
+
+// request the missing blocks of a window from a peer.
+func (d *downloader) request(peer string, w *window, has func(height uint64) bool, now time.Time) {
+	inv := &message.Inv{}
+
+	for height := w.from; height <= w.to; height++ {
+		if !has(height) {
+			inv.AddItem(message.InvTypeBlock, d.headers[height].Hash)
+		}
+	}
+
+	w.peer = peer
+	w.deadline = now.Add(d.stallTimeout)
+	d.peers[peer] = w
+
+	if len(inv.InvList) == 0 {
+		return
+	}
+
+	buf := topics.GetData.ToBuffer()
+	if err := inv.Encode(&buf); err != nil {
+		slog.WithError(err).Warn("could not encode sync window request")
+		return
+	}
+
+	slog.WithField("r_addr", peer).
+		WithField("from", w.from).
+		WithField("to", w.to).
+		Debug("request sync window")
+
+	if d.publisher != nil {
+		d.publisher.Publish(topics.KadcastSendToOne, message.NewWithMetadata(topics.KadcastSendToOne, buf, &message.Metadata{Source: peer}))
+	}
+}
+
+func (w *window) complete(has func(height uint64) bool) bool {
+	for height := w.from; height <= w.to; height++ {
+		if !has(height) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package chain
+
+import (
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/verifiers"
+)
+
+// ImportBlock accepts a block read from a chain archive. The block goes
+// through the same sanity checks, certificate verification and state
+// transition as a block received while synchronizing.
+//
+// Blocks up to the chain tip are skipped with ErrBlockAlreadyAccepted. That
+// allows resuming an interrupted import from the beginning of the archive.
+func (c *Chain) ImportBlock(blk block.Block) error {
+	if err := verifiers.CheckHash(&blk); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if blk.Header.Height <= c.tip.Header.Height {
+		return ErrBlockAlreadyAccepted
+	}
+
+	return c.acceptBlock(blk, true)
+}
+
+// TipHeight returns the height of the chain tip.
+func (c *Chain) TipHeight() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.tip.Header.Height
+}
@@ -29,4 +29,8 @@ type Ledger interface {
 	StopConsensus()
 
 	ProcessSyncTimerExpired(strPeerAddr string) error
+
+	// VerifyHeaderCertificate checks the certificate of a header following
+	// prev.
+	VerifyHeaderCertificate(h, prev *block.Header) error
 }
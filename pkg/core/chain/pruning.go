@@ -0,0 +1,53 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package chain
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+)
+
+// tryPrune starts pruning the database in the background every
+// database.pruning.interval blocks. A run is skipped if the previous one has
+// not completed yet.
+func (c *Chain) tryPrune(height uint64) {
+	cfg := config.Get().Database.Pruning
+	if !cfg.Enabled || cfg.Interval == 0 || height%cfg.Interval != 0 {
+		return
+	}
+
+	pruner, ok := c.db.(database.Pruner)
+	if !ok {
+		log.WithField("driver", config.Get().Database.Driver).
+			Warn("database driver does not support pruning")
+		return
+	}
+
+	if !atomic.CompareAndSwapUint32(&c.pruning, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreUint32(&c.pruning, 0)
+
+		start := time.Now()
+
+		pruned, err := pruner.Prune(cfg.Depth)
+		if err != nil {
+			log.WithError(err).Error("database pruning failed")
+			return
+		}
+
+		log.WithField("pruned", pruned).
+			WithField("depth", cfg.Depth).
+			WithField("duration", time.Since(start).Milliseconds()).
+			Info("database pruning completed")
+	}()
+}
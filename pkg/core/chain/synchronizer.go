@@ -9,6 +9,7 @@ package chain
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/config"
@@ -16,6 +17,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -63,6 +65,25 @@ func (s *synchronizer) inSync(srcPeerAddr string, currentHeight uint64, blk bloc
 func (s *synchronizer) outSync(srcPeerAddr string, currentHeight uint64, blk block.Block, metadata *message.Metadata) ([]bytes.Buffer, error) {
 	var err error
 
+	timerID := srcPeerAddr
+
+	// In headers-first sync, only the blocks of the verified header chain
+	// are accepted
+	if s.downloader != nil && s.downloader.running() {
+		if h := s.downloader.header(blk.Header.Height); h != nil && !bytes.Equal(h.Hash, blk.Header.Hash) {
+			slog.WithField("r_addr", srcPeerAddr).
+				WithField("height", blk.Header.Height).
+				Debug("discard block not matching the header chain")
+			return nil, nil
+		}
+
+		// Any peer delivering blocks keeps the sync alive
+		timerID = s.downloader.owner
+
+		s.downloader.delivered(srcPeerAddr)
+		defer s.scheduleDownload()
+	}
+
 	// Once we validate successfully the next block from the syncing
 	// Peer we can consider terminating Consensus for efficiency
 	// purposes.
@@ -109,9 +130,11 @@ func (s *synchronizer) outSync(srcPeerAddr string, currentHeight uint64, blk blo
 			return nil, err
 		}
 
+		s.height = blk.Header.Height
+
 		// Peer does provide a valid consecutive block
 		// outSyncTimer should restart its counter
-		if err = s.timer.Reset(srcPeerAddr); err != nil {
+		if err = s.timer.Reset(timerID); err != nil {
 			slog.WithError(err).WithField("state", "outsync").
 				Warn("timer error")
 		}
@@ -120,6 +143,10 @@ func (s *synchronizer) outSync(srcPeerAddr string, currentHeight uint64, blk blo
 			// Sync Target reached. outSyncTimer is not anymore needed
 			s.timer.Cancel()
 
+			if s.downloader != nil {
+				s.downloader.reset()
+			}
+
 			// if we reach the target we get into sync mode
 			// and trigger the consensus again
 			if err = s.chain.RestartConsensus(); err != nil {
@@ -152,16 +179,25 @@ type synchronizer struct {
 	}
 
 	timer *outSyncTimer
+
+	// downloader of the headers-first sync, nil if disabled.
+	downloader *downloader
+	// height is the current height, as of the last processed message.
+	height uint64
 }
 
 // newSynchronizer returns an initialized synchronizer, ready for use.
-func newSynchronizer(db database.DB, chain Ledger) *synchronizer {
+func newSynchronizer(db database.DB, chain Ledger, publisher eventbus.Publisher) *synchronizer {
 	s := &synchronizer{
 		db:        db,
 		sequencer: newSequencer(),
 		chain:     chain,
 	}
 
+	if config.Get().Network.Sync.HeadersFirst {
+		s.downloader = newDownloader(publisher)
+	}
+
 	s.timer = newSyncTimer(syncTimeout, chain.ProcessSyncTimerExpired)
 
 	slog.WithField("state", "insync").Debug(changeStatelabel)
@@ -176,6 +212,8 @@ func (s *synchronizer) processBlock(srcPeerID string, currentHeight uint64, blk
 	s.sequencer.cleanup(currentHeight)
 	s.sequencer.dump()
 
+	s.height = currentHeight
+
 	currState := s.state
 	res, err = currState(srcPeerID, currentHeight, blk, metadata)
 	return
@@ -201,10 +239,129 @@ func (s *synchronizer) startSync(strPeerAddr string, tipHeight, currentHeight ui
 		return nil, err
 	}
 
+	if s.downloader != nil {
+		return s.downloader.start(strPeerAddr, currentHeight, hash)
+	}
+
 	msgGetBlocks := createGetBlocksMsg(hash)
 	return marshalGetBlocks(msgGetBlocks)
 }
 
+// processHeaders verifies the headers received from a peer during a
+// headers-first sync and schedules the download of their blocks.
+func (s *synchronizer) processHeaders(srcPeerAddr string, currentHeight uint64, headers []*block.Header) error {
+	if s.downloader == nil || !s.downloader.running() || len(headers) == 0 {
+		return nil
+	}
+
+	s.height = currentHeight
+
+	verified, err := s.verifyHeaders(currentHeight, headers)
+	if err != nil {
+		return err
+	}
+
+	if len(verified) > 0 {
+		s.downloader.addHeaders(srcPeerAddr, verified)
+
+		// The target follows the header chain, up to a sync round
+		if last := s.downloader.last; last > s.hrange.to {
+			s.setSyncTarget(last, s.hrange.from+config.MaxInvBlocks)
+		}
+	}
+
+	s.scheduleDownload()
+	return nil
+}
+
+// verifyHeaders returns the prefix of headers linking to the chain known so
+// far, with valid hashes and certificates.
+//
+// Certificates are checked with the current provisioners, which are exact
+// for the block following the tip only: a peer is held responsible for an
+// invalid certificate at that height. Beyond it, the headers are truncated
+// at the first failing check, and the blocks are verified in full anyway on
+// acceptance.
+func (s *synchronizer) verifyHeaders(currentHeight uint64, headers []*block.Header) ([]*block.Header, error) {
+	prev, err := s.knownHeader(currentHeight, headers[0].Height-1)
+	if err != nil {
+		slog.WithError(err).Debug("headers do not link to the known chain")
+		return nil, nil
+	}
+
+	for i, h := range headers {
+		if h.Height != prev.Height+1 || !bytes.Equal(h.PrevBlockHash, prev.Hash) {
+			return headers[:i], nil
+		}
+
+		hash, err := h.CalculateHash()
+		if err != nil || !bytes.Equal(hash, h.Hash) {
+			return headers[:i], nil
+		}
+
+		if known, err := s.knownHeader(currentHeight, h.Height); err == nil {
+			// Overlapping the known chain
+			if !bytes.Equal(known.Hash, h.Hash) {
+				return headers[:i], nil
+			}
+		} else if err := s.chain.VerifyHeaderCertificate(h, prev); err != nil {
+			if h.Height == currentHeight+1 {
+				return nil, err
+			}
+
+			return headers[:i], nil
+		}
+
+		prev = h
+	}
+
+	return headers, nil
+}
+
+// knownHeader returns the header at height of either the local chain or the
+// verified header chain.
+func (s *synchronizer) knownHeader(currentHeight, height uint64) (*block.Header, error) {
+	if height > currentHeight {
+		if h := s.downloader.header(height); h != nil {
+			return h, nil
+		}
+
+		return nil, errors.New("header not found")
+	}
+
+	var h *block.Header
+
+	err := s.db.View(func(t database.Transaction) error {
+		hash, err := t.FetchBlockHashByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		h, err = t.FetchBlockHeader(hash)
+		return err
+	})
+
+	return h, err
+}
+
+// scheduleDownload assigns the windows of the headers-first sync.
+func (s *synchronizer) scheduleDownload() {
+	if !s.downloader.running() {
+		return
+	}
+
+	has := func(height uint64) bool {
+		if height <= s.height {
+			return true
+		}
+
+		_, err := s.sequencer.get(height)
+		return err == nil
+	}
+
+	s.downloader.schedule(s.hrange.to, has)
+}
+
 func (s *synchronizer) setSyncTarget(tipHeight, maxHeight uint64) {
 	s.hrange.to = tipHeight
 	if tipHeight > maxHeight {
@@ -7,8 +7,11 @@
 package chain
 
 import (
+	"bytes"
 	"testing"
+	"time"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/config/genesis"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
@@ -17,6 +20,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
 	assert "github.com/stretchr/testify/require"
 )
 
@@ -51,6 +55,129 @@ func TestFutureBlocks(t *testing.T) {
 	assert.NotEmpty(s.sequencer.blockPool[height])
 }
 
+func TestHeadersFirstSync(t *testing.T) {
+	assert := assert.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	cfg := r
+	cfg.Network.Sync.HeadersFirst = true
+	cfg.Network.Sync.WindowSize = 2
+	cfg.Network.Sync.StallTimeout = "50ms"
+	config.Mock(&cfg)
+
+	bus := eventbus.New()
+	sent := make(chan message.Message, 10)
+	bus.Subscribe(topics.KadcastSendToOne, eventbus.NewChanListener(sent))
+	bus.Subscribe(topics.KadcastSendToMany, eventbus.NewChanListener(sent))
+
+	s, _ := setupSynchronizerTest()
+	s.downloader = newDownloader(bus)
+
+	blks := linkedBlocks(genesis.Decode(), 6)
+	headers := make([]*block.Header, len(blks))
+
+	for i, blk := range blks {
+		headers[i] = blk.Header
+	}
+
+	// A block ahead starts the sync, asking random peers for headers
+	resp, err := s.processBlock("peerA", 0, *blks[5], nil)
+	assert.NoError(err)
+	assert.Equal(uint8(topics.GetHeaders), resp[0].Bytes()[0])
+	assert.Equal(topics.KadcastSendToMany, (<-sent).Category())
+
+	// The windows are spread over the peers agreeing on the headers
+	assert.NoError(s.processHeaders("peerA", 0, headers))
+	assert.NoError(s.processHeaders("peerB", 0, headers))
+
+	owners := make(map[string]uint64)
+
+	for i := 0; i < 2; i++ {
+		peer, inv := windowRequest(t, <-sent)
+		assert.Len(inv.InvList, 2)
+
+		owners[peer] = blockHeight(blks, inv.InvList[0].Hash)
+	}
+
+	assert.Len(owners, 2)
+
+	var fast, slow string
+
+	for peer, from := range owners {
+		if from == 1 {
+			fast = peer
+		} else {
+			slow = peer
+		}
+	}
+
+	// A block out of the header chain is discarded
+	fork := helper.RandomBlock(1, 1)
+	_, err = s.processBlock("peerC", 0, *fork, nil)
+	assert.NoError(err)
+	assert.Empty(sent)
+
+	// The window of the slow peer stalls, and is handed over to the peer
+	// delivering its window
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		_, err = s.processBlock(fast, uint64(i), *blks[i], nil)
+		assert.NoError(err)
+	}
+
+	peer, inv := windowRequest(t, <-sent)
+	assert.Equal(fast, peer)
+	assert.Equal(uint64(3), blockHeight(blks, inv.InvList[0].Hash))
+	assert.NotContains(s.downloader.peers, slow)
+}
+
+// linkedBlocks returns a chain of blocks following prev.
+func linkedBlocks(prev *block.Block, n int) []*block.Block {
+	blks := make([]*block.Block, n)
+
+	for i := range blks {
+		blk := helper.RandomBlock(prev.Header.Height+1, 1)
+		blk.Header.PrevBlockHash = prev.Header.Hash
+
+		hash, err := blk.CalculateHash()
+		if err != nil {
+			panic(err)
+		}
+
+		blk.Header.Hash = hash
+		blks[i] = blk
+		prev = blk
+	}
+
+	return blks
+}
+
+// windowRequest decodes a GetData sent to a single peer.
+func windowRequest(t *testing.T, m message.Message) (string, message.Inv) {
+	assert.Equal(t, topics.KadcastSendToOne, m.Category())
+
+	buf := m.Payload().(message.SafeBuffer).Buffer
+
+	msg, err := message.Unmarshal(&buf, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, topics.GetData, msg.Category())
+
+	return m.Metadata().Source, msg.Payload().(message.Inv)
+}
+
+func blockHeight(blks []*block.Block, hash []byte) uint64 {
+	for _, blk := range blks {
+		if bytes.Equal(blk.Header.Hash, hash) {
+			return blk.Header.Height
+		}
+	}
+
+	return 0
+}
+
 func setupSynchronizerTest() (*synchronizer, chan consensus.Results) {
 	c := make(chan consensus.Results, 1)
 	m := &mockChain{tipHeight: 0, catchBlockChan: c}
@@ -64,7 +191,7 @@ func setupSynchronizerTest() (*synchronizer, chan consensus.Results) {
 		panic(err)
 	}
 
-	return newSynchronizer(db, m), c
+	return newSynchronizer(db, m, nil), c
 }
 
 type mockChain struct {
@@ -98,3 +225,7 @@ func (m *mockChain) StopConsensus() {}
 func (m *mockChain) ProcessSyncTimerExpired(string) error {
 	return nil
 }
+
+func (m *mockChain) VerifyHeaderCertificate(h, prev *block.Header) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package chain
+
+import (
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+)
+
+// indexTxs builds in the background the tx index of the blocks stored before
+// database.txIndex was enabled. The lookups by contract or type fail until it
+// is done.
+func (c *Chain) indexTxs() {
+	if !config.Get().Database.TxIndex {
+		return
+	}
+
+	indexer, ok := c.db.(database.TxIndexer)
+	if !ok {
+		return
+	}
+
+	go func() {
+		start := time.Now()
+
+		indexed, err := indexer.IndexTxs()
+		if err != nil {
+			log.WithError(err).Error("tx index build failed")
+			return
+		}
+
+		if indexed == 0 {
+			return
+		}
+
+		log.WithField("indexed", indexed).
+			WithField("duration", time.Since(start).Milliseconds()).
+			Info("tx index build completed")
+	}()
+}
@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package capi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// addressBook lists the entries of the peer address book, once registered
+// by the peer connector.
+var addressBook = struct {
+	sync.RWMutex
+	peers func() []KnownPeerJSON
+}{}
+
+// SetAddressBook registers the function listing the address book entries.
+func SetAddressBook(peers func() []KnownPeerJSON) {
+	addressBook.Lock()
+	addressBook.peers = peers
+	addressBook.Unlock()
+}
+
+// GetAddressBookHandler will return the address book entries as
+// []KnownPeerJSON json.
+func GetAddressBookHandler(res http.ResponseWriter, req *http.Request) {
+	addressBook.RLock()
+	peers := addressBook.peers
+	addressBook.RUnlock()
+
+	known := make([]KnownPeerJSON, 0)
+	if peers != nil {
+		known = peers()
+	}
+
+	b, err := json.Marshal(known)
+	if err != nil {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	_, _ = res.Write(b)
+}
@@ -29,6 +29,10 @@ func StartAPI(eb *eventbus.EventBus, rb *rpcbus.RPCBus) {
 	eventBus = eb
 	rpcBus = rb
 
+	if eventBus != nil {
+		subscribeKadcastStatus(eventBus)
+	}
+
 	log.
 		WithField("eventBus", eventBus).
 		WithField("rpcBus", rpcBus).
@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package capi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+)
+
+// kadcastStatus keeps the latest state notified by the Kadcast reader, which
+// notifies its initial state once listening. The state is empty until then.
+var kadcastStatus = struct {
+	sync.RWMutex
+	status KadcastStatusJSON
+}{}
+
+func subscribeKadcastStatus(eb *eventbus.EventBus) {
+	eb.Subscribe(topics.KadcastStatus, eventbus.NewCallbackListener(onKadcastStatus))
+}
+
+func onKadcastStatus(m message.Message) {
+	state, err := message.ConvStr(m.Payload())
+	if err != nil {
+		log.WithError(err).Warn("invalid kadcast status")
+		return
+	}
+
+	kadcastStatus.Lock()
+	kadcastStatus.status = KadcastStatusJSON{
+		State:     state,
+		UpdatedAt: time.Now(),
+	}
+	kadcastStatus.Unlock()
+}
+
+// KadcastState returns the latest state of the Kadcast listener stream.
+func KadcastState() string {
+	kadcastStatus.RLock()
+	defer kadcastStatus.RUnlock()
+
+	return kadcastStatus.status.State
+}
+
+// GetKadcastStatusHandler will return KadcastStatusJSON json.
+func GetKadcastStatusHandler(res http.ResponseWriter, req *http.Request) {
+	kadcastStatus.RLock()
+	status := kadcastStatus.status
+	kadcastStatus.RUnlock()
+
+	b, err := json.Marshal(status)
+	if err != nil {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	_, _ = res.Write(b)
+}
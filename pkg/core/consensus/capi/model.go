@@ -47,6 +47,28 @@ type Count struct {
 	Count int `json:"count"`
 }
 
+// KadcastStatusJSON is used as JSON wrapper for the Kadcast connection state.
+type KadcastStatusJSON struct {
+	State     string    `json:"state"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KnownPeerJSON is used as JSON wrapper for an address book entry.
+type KnownPeerJSON struct {
+	Address string `json:"address"`
+	// ID is the node key of the peer on encrypted connections
+	ID       string    `json:"id,omitempty"`
+	Inbound  bool      `json:"inbound"`
+	LastSeen time.Time `json:"last_seen"`
+	// Handshakes is the number of successful handshakes
+	Handshakes uint32 `json:"handshakes"`
+	// Failures is the number of consecutive failed connection attempts
+	Failures uint32 `json:"failures"`
+	// Latency of the last handshake
+	Latency     time.Duration `json:"latency_ns"`
+	BannedUntil time.Time     `json:"banned_until"`
+}
+
 // PeerCount is the struct used to save a peer or remove from a PeerCount collection in the API monitoring database.
 type PeerCount struct {
 	ID       string    `storm:"id" json:"id"`
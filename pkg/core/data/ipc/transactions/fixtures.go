@@ -211,6 +211,70 @@ func MockTxWithParams(txtype TxType, gasSpent uint64) ContractCall {
 	return t
 }
 
+// MockTxWithFee mocks a transaction paying the specified gas price.
+func MockTxWithFee(gasPrice uint64) ContractCall {
+	t := RandTx()
+
+	decoded, err := t.Decode()
+	if err != nil {
+		panic(err)
+	}
+
+	// Locate the gas price within the raw payload. It follows the
+	// nullifiers, the notes, the anchor and the gas limit.
+	offset := 8 + 32*len(decoded.Nullifiers) + 8
+
+	for _, note := range decoded.Notes {
+		var buf bytes.Buffer
+		if err := MarshalNote(&buf, note); err != nil {
+			panic(err)
+		}
+
+		offset += buf.Len()
+	}
+
+	offset += 32 + 8
+
+	binary.LittleEndian.PutUint64(t.Payload.Data[offset:], gasPrice)
+
+	decoded.Fee.GasPrice = gasPrice
+
+	hash, err := decoded.Hash(t.TxType)
+	if err != nil {
+		panic(err)
+	}
+
+	copy(t.Hash[:], hash)
+
+	return t
+}
+
+// MockTxWithCall mocks a transaction calling the specified contract.
+func MockTxWithCall(contractID, callData []byte) ContractCall {
+	t := RandTx()
+
+	// The payload of RandTx ends with the call flag, unset
+	data := append([]byte{}, t.Payload.Data...)
+	binary.LittleEndian.PutUint64(data[len(data)-8:], 1)
+
+	data = append(data, contractID...)
+	t.Payload.Data = append(data, callData...)
+
+	decoded, err := t.Decode()
+	if err != nil {
+		panic(err)
+	}
+
+	hash, err := decoded.Hash(t.TxType)
+	if err != nil {
+		panic(err)
+	}
+
+	copy(t.Hash[:], hash)
+
+	return t
+}
+
 /**************************/
 /** Transfer Transaction **/
 /**************************/
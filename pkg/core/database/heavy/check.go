@@ -0,0 +1,540 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package heavy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/utils"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Kinds of the issues reported by DB.Check.
+const (
+	// IssueRegistry TipPrefix or PersistedPrefix does not point at a stored block.
+	IssueRegistry = "registry"
+	// IssueBrokenLink a header does not link to its predecessor.
+	IssueBrokenLink = "broken_link"
+	// IssueOrphanHeader a header is not part of the chain ending at the tip.
+	IssueOrphanHeader = "orphan_header"
+	// IssueHeightIndex a HeightPrefix entry is missing or points at a wrong block.
+	IssueHeightIndex = "height_index"
+	// IssueOrphanTx a TxPrefix record belongs to an unknown block.
+	IssueOrphanTx = "orphan_tx"
+	// IssueTxIndex a TxIDPrefix entry is missing or points at a missing tx.
+	IssueTxIndex = "tx_index"
+	// IssueSecondaryIndex a ContractIndexPrefix or TypeIndexPrefix entry is
+	// missing or points at a missing tx.
+	IssueSecondaryIndex = "secondary_index"
+	// IssueCandidate a candidate block has not been cleared.
+	IssueCandidate = "candidate"
+)
+
+// repairBatchSize is the number of repair operations written at once.
+const repairBatchSize = 10000
+
+// CheckIssue is a single inconsistency found in the store.
+type CheckIssue struct {
+	Kind   string `json:"kind"`
+	Key    string `json:"key"`
+	Detail string `json:"detail"`
+	// Repairable is true if the issue is fixed by rebuilding the indexes.
+	Repairable bool `json:"repairable"`
+}
+
+// CheckReport summarizes the result of DB.Check.
+type CheckReport struct {
+	TipHeight       uint64 `json:"tip_height"`
+	PersistedHeight uint64 `json:"persisted_height"`
+	Headers         uint64 `json:"headers"`
+	Txs             uint64 `json:"txs"`
+	Candidates      uint64 `json:"candidates"`
+	// TxIndexBuilt is true if the contract and type tx index covers the
+	// whole chain. Missing entries are then reported.
+	TxIndexBuilt bool `json:"tx_index_built"`
+
+	Issues []CheckIssue `json:"issues"`
+
+	// Repaired is the number of index records put or deleted.
+	Repaired uint64 `json:"repaired"`
+}
+
+// checker holds the state of a single DB.Check run.
+type checker struct {
+	snapshot *leveldb.Snapshot
+	report   *CheckReport
+
+	// Hashes of the blocks linked from the tip down to genesis.
+	chain map[string]uint64
+	// Hashes of all stored headers.
+	headers map[string]struct{}
+
+	// Height of the highest pruned block, if pruned.
+	prunedHeight uint64
+	pruned       bool
+
+	// Repair operations, if enabled.
+	batch *leveldb.Batch
+}
+
+// Check walks the whole store and verifies the consistency of the primary
+// (header, tx) records and the secondary (height, txID, contract, type)
+// indexes. The chain is walked from the tip down to genesis. If repair is
+// true, secondary indexes are rebuilt from the primary records and candidate
+// blocks are cleared.
+//
+// Check is meant to run offline, on a store not used by a running node.
+func (db DB) Check(repair bool) (*CheckReport, error) {
+	if repair && db.readOnly {
+		return nil, errors.New("database is read-only")
+	}
+
+	snapshot, err := db.storage.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	defer snapshot.Release()
+
+	c := &checker{
+		snapshot: snapshot,
+		report:   &CheckReport{Issues: make([]CheckIssue, 0)},
+		chain:    make(map[string]uint64),
+		headers:  make(map[string]struct{}),
+	}
+
+	if repair {
+		c.batch = new(leveldb.Batch)
+	}
+
+	flush := func() error {
+		if c.batch == nil || c.batch.Len() < repairBatchSize {
+			return nil
+		}
+
+		return db.write(c)
+	}
+
+	steps := []func() error{
+		c.checkTxIndexBuilt,
+		c.checkPruned,
+		c.checkChain,
+		c.checkHeaders,
+		c.checkHeightIndex,
+		// Dangling tx entries are deleted before missing ones are put
+		c.checkTxIndex,
+		c.checkSecondaryIndex,
+		c.checkTxs,
+		c.checkCandidates,
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return c.report, err
+		}
+
+		if err := flush(); err != nil {
+			return c.report, err
+		}
+	}
+
+	if c.batch != nil {
+		if err := db.write(c); err != nil {
+			return c.report, err
+		}
+	}
+
+	return c.report, nil
+}
+
+func (db DB) write(c *checker) error {
+	c.report.Repaired += uint64(c.batch.Len())
+
+	if err := db.storage.Write(c.batch, writeOptions); err != nil {
+		return err
+	}
+
+	c.batch.Reset()
+	return nil
+}
+
+func (c *checker) issue(kind string, key []byte, repairable bool, format string, args ...interface{}) {
+	c.report.Issues = append(c.report.Issues, CheckIssue{
+		Kind:       kind,
+		Key:        hex.EncodeToString(key),
+		Detail:     fmt.Sprintf(format, args...),
+		Repairable: repairable,
+	})
+}
+
+func (c *checker) put(key, value []byte) {
+	if c.batch != nil {
+		c.batch.Put(key, value)
+	}
+}
+
+func (c *checker) delete(key []byte) {
+	if c.batch != nil {
+		c.batch.Delete(key)
+	}
+}
+
+func (c *checker) fetchHeader(hash []byte) (*block.Header, error) {
+	value, err := c.snapshot.Get(append(HeaderPrefix, hash...), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	header := block.NewHeader()
+	if err := message.UnmarshalHeader(bytes.NewBuffer(value), header); err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+func heightKey(height uint64) []byte {
+	buf := new(bytes.Buffer)
+	_ = utils.WriteUint64(buf, height)
+
+	return append(HeightPrefix, buf.Bytes()...)
+}
+
+// checkChain walks the chain from the tip down to genesis, verifying the
+// hash linkage and the height index of each block.
+func (c *checker) checkChain() error {
+	tipHash, err := c.snapshot.Get(TipPrefix, nil)
+	if err != nil {
+		c.issue(IssueRegistry, TipPrefix, false, "chain tip not found: %v", err)
+		return nil
+	}
+
+	persistedHash, err := c.snapshot.Get(PersistedPrefix, nil)
+	if err != nil {
+		c.issue(IssueRegistry, PersistedPrefix, false, "persisted block not found: %v", err)
+	}
+
+	header, err := c.fetchHeader(tipHash)
+	if err != nil {
+		c.issue(IssueRegistry, TipPrefix, false, "chain tip %s not stored: %v", hex.EncodeToString(tipHash), err)
+		return nil
+	}
+
+	c.report.TipHeight = header.Height
+
+	for {
+		c.chain[string(header.Hash)] = header.Height
+
+		key := heightKey(header.Height)
+
+		indexed, err := c.snapshot.Get(key, nil)
+		if err != nil || !bytes.Equal(indexed, header.Hash) {
+			c.issue(IssueHeightIndex, key, true, "height %d does not point at block %s", header.Height, hex.EncodeToString(header.Hash))
+			c.put(key, header.Hash)
+		}
+
+		if header.Height == 0 {
+			break
+		}
+
+		prev, err := c.fetchHeader(header.PrevBlockHash)
+		if err != nil {
+			c.issue(IssueBrokenLink, header.Hash, false, "previous block of height %d not stored: %v", header.Height, err)
+			break
+		}
+
+		if prev.Height+1 != header.Height {
+			c.issue(IssueBrokenLink, header.Hash, false, "previous block of height %d is at height %d", header.Height, prev.Height)
+			break
+		}
+
+		header = prev
+	}
+
+	if len(persistedHash) > 0 {
+		height, ok := c.chain[string(persistedHash)]
+		if !ok {
+			c.issue(IssueRegistry, PersistedPrefix, false, "persisted block %s is not part of the chain", hex.EncodeToString(persistedHash))
+		}
+
+		c.report.PersistedHeight = height
+	}
+
+	return nil
+}
+
+// checkHeaders looks for headers not linked to the chain tip.
+func (c *checker) checkHeaders() error {
+	iter := c.snapshot.NewIterator(util.BytesPrefix(HeaderPrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		hash := append([]byte{}, iter.Key()[len(HeaderPrefix):]...)
+
+		c.report.Headers++
+		c.headers[string(hash)] = struct{}{}
+
+		if _, ok := c.chain[string(hash)]; !ok {
+			c.issue(IssueOrphanHeader, hash, false, "block is not linked to the chain tip")
+		}
+	}
+
+	return iter.Error()
+}
+
+// checkHeightIndex looks for height entries pointing at blocks out of chain.
+func (c *checker) checkHeightIndex() error {
+	iter := c.snapshot.NewIterator(util.BytesPrefix(HeightPrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		var height uint64
+		if err := utils.ReadUint64(bytes.NewReader(iter.Key()[len(HeightPrefix):]), &height); err != nil {
+			return err
+		}
+
+		if h, ok := c.chain[string(iter.Value())]; ok && h == height {
+			continue
+		}
+
+		// Entries of the chain blocks are already reported by checkChain
+		if c.isChainHeight(height) {
+			continue
+		}
+
+		key := append([]byte{}, iter.Key()...)
+		c.issue(IssueHeightIndex, key, true, "height %d points at block out of chain", height)
+		c.delete(key)
+	}
+
+	return iter.Error()
+}
+
+func (c *checker) isChainHeight(height uint64) bool {
+	if height > c.report.TipHeight || len(c.chain) == 0 {
+		return false
+	}
+
+	// The chain walk covers all heights down to the lowest linked block
+	return c.report.TipHeight-height < uint64(len(c.chain))
+}
+
+// checkTxs verifies each tx record belongs to a stored block and is indexed
+// by its txID, and by its contract and type if the tx index is built.
+func (c *checker) checkTxs() error {
+	iter := c.snapshot.NewIterator(util.BytesPrefix(TxPrefix), nil)
+	defer iter.Release()
+
+	hashLen := block.HeaderHashSize
+
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) <= len(TxPrefix)+hashLen {
+			return fmt.Errorf("malformed tx key %s", hex.EncodeToString(key))
+		}
+
+		c.report.Txs++
+
+		hash := key[len(TxPrefix) : len(TxPrefix)+hashLen]
+		txID := key[len(TxPrefix)+hashLen:]
+
+		if _, ok := c.headers[string(hash)]; !ok {
+			c.issue(IssueOrphanTx, txID, false, "tx of unknown block %s", hex.EncodeToString(hash))
+			continue
+		}
+
+		if _, ok := c.chain[string(hash)]; !ok {
+			continue
+		}
+
+		indexKey := append(append([]byte{}, TxIDPrefix...), txID...)
+
+		indexed, err := c.snapshot.Get(indexKey, nil)
+		if err != nil || !bytes.Equal(indexed, hash) {
+			c.issue(IssueTxIndex, txID, true, "tx is not indexed")
+			c.put(indexKey, append([]byte{}, hash...))
+		}
+
+		if c.report.TxIndexBuilt {
+			if err := c.checkTxEntries(hash, txID, iter.Value()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return iter.Error()
+}
+
+// checkTxEntries verifies a chain tx is indexed by its contract and type.
+func (c *checker) checkTxEntries(hash, txID, value []byte) error {
+	tx, txIndex, err := utils.DecodeBlockTx(value, database.AnyTxType)
+	if err != nil {
+		return err
+	}
+
+	keys, entry := indexEntries(tx, hash, c.chain[string(hash)], txIndex, txID)
+
+	for _, key := range keys {
+		indexed, err := c.snapshot.Get(key, nil)
+		if err == nil && bytes.Equal(indexed, entry) {
+			continue
+		}
+
+		c.issue(IssueSecondaryIndex, key, true, "tx %s is not indexed", hex.EncodeToString(txID))
+		c.put(key, entry)
+	}
+
+	return nil
+}
+
+// checkTxIndexBuilt reads whether the contract and type tx index is built.
+func (c *checker) checkTxIndexBuilt() error {
+	value, err := c.snapshot.Get(TxIndexedPrefix, nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var next uint64
+	if err := utils.ReadUint64(bytes.NewReader(value), &next); err != nil {
+		return err
+	}
+
+	c.report.TxIndexBuilt = next == txIndexBuilt
+	return nil
+}
+
+// checkPruned reads the height of the highest pruned block, if any.
+func (c *checker) checkPruned() error {
+	height, pruned, err := transaction{snapshot: c.snapshot}.fetchPrunedHeight()
+	if err != nil {
+		return err
+	}
+
+	c.prunedHeight, c.pruned = height, pruned
+	return nil
+}
+
+// checkSecondaryIndex looks for contract and type entries pointing at txs
+// missing or out of chain, whether the tx index is enabled or not.
+func (c *checker) checkSecondaryIndex() error {
+	prefixes := []struct {
+		prefix  []byte
+		keySize int
+	}{
+		{ContractIndexPrefix, len(ContractIndexPrefix) + contractIDSize + positionSize},
+		{TypeIndexPrefix, len(TypeIndexPrefix) + 4 + positionSize},
+	}
+
+	for _, p := range prefixes {
+		if err := c.checkIndexEntries(p.prefix, p.keySize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) checkIndexEntries(prefix []byte, keySize int) error {
+	iter := c.snapshot.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	hashLen := block.HeaderHashSize
+
+	for iter.Next() {
+		key := append([]byte{}, iter.Key()...)
+		value := iter.Value()
+
+		if len(key) != keySize || len(value) <= hashLen {
+			c.issue(IssueSecondaryIndex, key, true, "malformed index entry")
+			c.delete(key)
+			continue
+		}
+
+		hash, txID := value[:hashLen], value[hashLen:]
+		height := binary.BigEndian.Uint64(key[keySize-positionSize:])
+
+		if h, ok := c.chain[string(hash)]; !ok || h != height {
+			c.issue(IssueSecondaryIndex, key, true, "index entry points at block %s out of chain", hex.EncodeToString(hash))
+			c.delete(key)
+			continue
+		}
+
+		txKey := append(append(append([]byte{}, TxPrefix...), hash...), txID...)
+
+		exists, err := c.snapshot.Has(txKey, nil)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			c.issue(IssueSecondaryIndex, key, true, "index entry points at missing tx %s", hex.EncodeToString(txID))
+			c.delete(key)
+		}
+	}
+
+	return iter.Error()
+}
+
+// checkTxIndex looks for txID entries pointing at missing tx records.
+func (c *checker) checkTxIndex() error {
+	iter := c.snapshot.NewIterator(util.BytesPrefix(TxIDPrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		txID := iter.Key()[len(TxIDPrefix):]
+
+		txKey := append(append(append([]byte{}, TxPrefix...), iter.Value()...), txID...)
+
+		exists, err := c.snapshot.Has(txKey, nil)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			continue
+		}
+
+		// The txID entries of the pruned blocks are kept
+		if height, ok := c.chain[string(iter.Value())]; ok && c.pruned && height <= c.prunedHeight {
+			continue
+		}
+
+		// If the tx is stored in a chain block, checkTxs fixes the entry
+		c.issue(IssueTxIndex, txID, true, "tx index points at missing tx of block %s", hex.EncodeToString(iter.Value()))
+		c.delete(append([]byte{}, iter.Key()...))
+	}
+
+	return iter.Error()
+}
+
+// checkCandidates reports candidate blocks left over.
+func (c *checker) checkCandidates() error {
+	iter := c.snapshot.NewIterator(util.BytesPrefix(CandidatePrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		c.report.Candidates++
+
+		key := append([]byte{}, iter.Key()...)
+		c.issue(IssueCandidate, key[len(CandidatePrefix):], true, "candidate block not cleared")
+		c.delete(key)
+	}
+
+	return iter.Error()
+}
@@ -0,0 +1,205 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package heavy
+
+import (
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	assert "github.com/stretchr/testify/require"
+)
+
+// linkedChain returns a chain of blocks from genesis to the specified height.
+func linkedChain(height uint64) []*block.Block {
+	blocks := make([]*block.Block, 0, height+1)
+
+	for h := uint64(0); h <= height; h++ {
+		b := helper.RandomBlock(h, 1)
+
+		if h > 0 {
+			b.Header.PrevBlockHash = blocks[h-1].Header.Hash
+
+			hash, err := b.CalculateHash()
+			if err != nil {
+				panic(err)
+			}
+
+			b.Header.Hash = hash
+		}
+
+		blocks = append(blocks, b)
+	}
+
+	return blocks
+}
+
+func issueKinds(r *CheckReport) map[string]int {
+	kinds := make(map[string]int)
+	for _, i := range r.Issues {
+		kinds[i.Kind]++
+	}
+
+	return kinds
+}
+
+func TestCheckRepair(t *testing.T) {
+	db, err := NewDatabase(t.TempDir(), false)
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = closeStorage()
+	}()
+
+	blocks := linkedChain(5)
+
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		for _, b := range blocks {
+			if err := t.StoreBlock(b, b.Header.Height == 3); err != nil {
+				return err
+			}
+		}
+
+		return t.StoreCandidateMessage(*helper.RandomBlock(6, 1))
+	}))
+
+	report, err := db.(DB).Check(false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), report.TipHeight)
+	assert.Equal(t, uint64(3), report.PersistedHeight)
+	assert.Equal(t, map[string]int{IssueCandidate: 1}, issueKinds(report))
+
+	// Corrupt secondary indexes
+	txID, err := blocks[2].Txs[0].CalculateHash()
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		tx := t.(*transaction)
+		tx.batch.Delete(heightKey(4))
+		tx.batch.Delete(append(TxIDPrefix, txID...))
+		tx.batch.Put(append(TxIDPrefix, make([]byte, 32)...), blocks[1].Header.Hash)
+		return nil
+	}))
+
+	report, err = db.(DB).Check(true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{IssueCandidate: 1, IssueHeightIndex: 1, IssueTxIndex: 2}, issueKinds(report))
+	assert.Equal(t, uint64(4), report.Repaired)
+
+	report, err = db.(DB).Check(false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	var hash []byte
+
+	assert.NoError(t, db.View(func(t database.Transaction) error {
+		_, _, hash, err = t.FetchBlockTxByHash(txID)
+		return err
+	}))
+	assert.Equal(t, blocks[2].Header.Hash, hash)
+}
+
+func TestCheckSecondaryIndex(t *testing.T) {
+	db, err := NewDatabase(t.TempDir(), false)
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = closeStorage()
+	}()
+
+	enabled := db.(DB)
+	enabled.txIndex = true
+
+	blocks := linkedChain(3)
+
+	assert.NoError(t, enabled.Update(func(t database.Transaction) error {
+		for _, b := range blocks {
+			if err := t.StoreBlock(b, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+
+	report, err := db.(DB).Check(false)
+	assert.NoError(t, err)
+	assert.True(t, report.TxIndexBuilt)
+	assert.Empty(t, report.Issues)
+
+	// Drop an entry and add a dangling one
+	txID, err := blocks[2].Txs[0].CalculateHash()
+	assert.NoError(t, err)
+
+	keys, _ := indexEntries(blocks[2].Txs[0], blocks[2].Header.Hash, 2, 0, txID)
+	dangling, value := indexEntries(blocks[1].Txs[0], blocks[1].Header.Hash, 1, 7, make([]byte, 32))
+
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		tx := t.(*transaction)
+		tx.batch.Delete(keys[len(keys)-1])
+		tx.batch.Put(dangling[len(dangling)-1], value)
+		return nil
+	}))
+
+	report, err = db.(DB).Check(true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{IssueSecondaryIndex: 2}, issueKinds(report))
+	assert.Equal(t, uint64(2), report.Repaired)
+
+	report, err = db.(DB).Check(false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Issues)
+}
+
+func TestCheckPruned(t *testing.T) {
+	db, err := NewDatabase(t.TempDir(), false)
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = closeStorage()
+	}()
+
+	blocks := linkedChain(5)
+
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		for _, b := range blocks {
+			if err := t.StoreBlock(b, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+
+	pruned, err := db.(DB).Prune(2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), pruned)
+
+	// The txID entries of the pruned blocks are not dangling
+	report, err := db.(DB).Check(false)
+	assert.NoError(t, err)
+	assert.Empty(t, report.Issues)
+
+	fetchTx := func(b *block.Block) error {
+		txID := make([]byte, 32)
+		if b != nil {
+			txID, err = b.Txs[0].CalculateHash()
+			assert.NoError(t, err)
+		}
+
+		return db.View(func(t database.Transaction) error {
+			_, _, _, err := t.FetchBlockTxByHash(txID)
+			return err
+		})
+	}
+
+	// Only the txs of the pruned blocks are reported pruned
+	assert.Equal(t, database.ErrBlockPruned, fetchTx(blocks[2]))
+	assert.NoError(t, fetchTx(blocks[3]))
+	assert.Equal(t, database.ErrTxNotFound, fetchTx(nil))
+}
@@ -10,6 +10,7 @@ import (
 	"os"
 	"sync"
 
+	cfg "github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
@@ -28,6 +29,9 @@ type DB struct {
 
 	// Read-only mode provided at heavy.DB level. If true, accepts read-only Transaction.
 	readOnly bool
+
+	// If true, txs are indexed by contract ID and tx type on StoreBlock.
+	txIndex bool
 }
 
 // openStorage is a wrapper around leveldb.OpenFile to provide singleton
@@ -87,7 +91,11 @@ func NewDatabase(path string, readonly bool) (database.DB, error) {
 		return nil, err
 	}
 
-	return DB{storage, readonly}, nil
+	return DB{
+		storage:  storage,
+		readOnly: readonly,
+		txIndex:  cfg.Get().Database.TxIndex,
+	}, nil
 }
 
 // Begin builds read-only or read-write Transaction.
@@ -0,0 +1,318 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package heavy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/utils"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Size of the position suffix of an index key: height (8 bytes) and tx index
+// (4 bytes), both big-endian to keep the entries sorted by chain position.
+const positionSize = 8 + 4
+
+// contractIDSize is the size of a transactions.Call contract ID.
+const contractIDSize = 32
+
+// indexBatchSize is the number of blocks indexed within a single atomic batch.
+const indexBatchSize = 1000
+
+// txIndexBuilt is the TxIndexedPrefix value of a tx index covering the whole
+// chain. Otherwise, the value is the height of the next block to index.
+const txIndexBuilt = math.MaxUint64
+
+// indexTx puts or deletes the secondary index entries of a block tx. Schema:
+//
+// Key = ContractIndexPrefix + contractID + height + txIndex
+// Key = TypeIndexPrefix + txType + height + txIndex
+// Value = block.header.hash + txID
+//
+// A tx which payload cannot be decoded is indexed by its type only.
+func (t transaction) indexTx(optype int, tx transactions.ContractCall, header *block.Header, txIndex uint32, txID []byte) {
+	keys, value := indexEntries(tx, header.Hash, header.Height, txIndex, txID)
+	for _, key := range keys {
+		t.op(optype, key, value)
+	}
+}
+
+// indexEntries returns the keys and the value of the index entries of a tx.
+func indexEntries(tx transactions.ContractCall, hash []byte, height uint64, txIndex uint32, txID []byte) ([][]byte, []byte) {
+	position := make([]byte, positionSize)
+	binary.BigEndian.PutUint64(position, height)
+	binary.BigEndian.PutUint32(position[8:], txIndex)
+
+	value := append(append([]byte{}, hash...), txID...)
+	keys := make([][]byte, 0, 2)
+
+	if decoded, err := tx.Decode(); err == nil && decoded.Call != nil {
+		keys = append(keys, contractIndexKey(decoded.Call.ContractID, position))
+	}
+
+	return append(keys, typeIndexKey(tx.Type(), position)), value
+}
+
+func contractIndexKey(contractID, position []byte) []byte {
+	key := append(append([]byte{}, ContractIndexPrefix...), contractID...)
+	return append(key, position...)
+}
+
+func typeIndexKey(txType transactions.TxType, position []byte) []byte {
+	key := make([]byte, len(TypeIndexPrefix)+4, len(TypeIndexPrefix)+4+len(position))
+	copy(key, TypeIndexPrefix)
+	binary.BigEndian.PutUint32(key[len(TypeIndexPrefix):], uint32(txType))
+
+	return append(key, position...)
+}
+
+// FetchTxsByContract implements database.Transaction.
+func (t transaction) FetchTxsByContract(contractID []byte, offset, limit uint64) ([]database.IndexedTx, error) {
+	if len(contractID) != contractIDSize {
+		return nil, fmt.Errorf("contract ID size is %d but it must be %d", len(contractID), contractIDSize)
+	}
+
+	return t.fetchIndexedTxs(append(append([]byte{}, ContractIndexPrefix...), contractID...), offset, limit)
+}
+
+// FetchTxsByType implements database.Transaction.
+func (t transaction) FetchTxsByType(txType transactions.TxType, offset, limit uint64) ([]database.IndexedTx, error) {
+	return t.fetchIndexedTxs(typeIndexKey(txType, nil), offset, limit)
+}
+
+// fetchIndexedTxs iterates backward the index entries with the specified
+// prefix. Skipping offset entries is linear, as the index keeps no counters.
+func (t transaction) fetchIndexedTxs(prefix []byte, offset, limit uint64) ([]database.IndexedTx, error) {
+	if !t.db.txIndex {
+		return nil, database.ErrTxIndexDisabled
+	}
+
+	next, ok, err := t.fetchTxIndexHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok || next != txIndexBuilt {
+		return nil, database.ErrTxIndexNotReady
+	}
+
+	result := make([]database.IndexedTx, 0)
+
+	iterator := t.snapshot.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iterator.Release()
+
+	for ok := iterator.Last(); ok && uint64(len(result)) < limit; ok = iterator.Prev() {
+		if offset > 0 {
+			offset--
+			continue
+		}
+
+		key, value := iterator.Key(), iterator.Value()
+		if len(key) != len(prefix)+positionSize || len(value) <= block.HeaderHashSize {
+			return nil, errors.New("malformed tx index entry")
+		}
+
+		hash := value[:block.HeaderHashSize]
+
+		data, err := t.snapshot.Get(append(append(append([]byte{}, TxPrefix...), hash...), value[block.HeaderHashSize:]...), nil)
+		if err == leveldb.ErrNotFound {
+			// Pruned blocks are unindexed in the same batch
+			return nil, errors.New("tx index points at a missing tx")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		tx, txIndex, err := utils.DecodeBlockTx(data, database.AnyTxType)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, database.IndexedTx{
+			Tx:          tx,
+			TxIndex:     txIndex,
+			BlockHash:   append([]byte{}, hash...),
+			BlockHeight: binary.BigEndian.Uint64(key[len(prefix):]),
+		})
+	}
+
+	return result, iterator.Error()
+}
+
+// IndexTxs builds the tx index of the blocks stored while it was disabled, from
+// genesis, or the lowest unpruned block, up to the tip. Lookups fail with
+// database.ErrTxIndexNotReady until it is done.
+//
+// Blocks are indexed in batches, each of them updating TxIndexedPrefix, so
+// that an interrupted run is resumed on the next call. Blocks stored meanwhile
+// are indexed by StoreBlock.
+func (db DB) IndexTxs() (uint64, error) {
+	if !db.txIndex {
+		return 0, database.ErrTxIndexDisabled
+	}
+
+	if db.readOnly {
+		return 0, errors.New("database is read-only")
+	}
+
+	var (
+		from, to uint64
+		built    bool
+	)
+
+	err := db.View(func(t database.Transaction) error {
+		tx := t.(*transaction)
+
+		next, ok, err := tx.fetchTxIndexHeight()
+		if err != nil {
+			return err
+		}
+
+		if ok && next == txIndexBuilt {
+			built = true
+			return nil
+		}
+
+		if ok {
+			from = next
+		}
+
+		height, pruned, err := tx.fetchPrunedHeight()
+		if err != nil {
+			return err
+		}
+
+		if pruned && height >= from {
+			from = height + 1
+		}
+
+		tip, err := tx.FetchCurrentHeight()
+		if err == database.ErrStateNotFound {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// Exclusive upper bound
+		to = tip + 1
+		return nil
+	})
+	if err != nil || built {
+		return 0, err
+	}
+
+	if from >= to {
+		return 0, db.Update(func(t database.Transaction) error {
+			return t.(*transaction).putTxIndexHeight(txIndexBuilt)
+		})
+	}
+
+	for start := from; start < to; start += indexBatchSize {
+		end := start + indexBatchSize
+		if end > to {
+			end = to
+		}
+
+		err = db.Update(func(t database.Transaction) error {
+			tx := t.(*transaction)
+
+			for height := start; height < end; height++ {
+				if err := tx.indexBlock(height); err != nil {
+					return err
+				}
+			}
+
+			if end == to {
+				return tx.putTxIndexHeight(txIndexBuilt)
+			}
+
+			return tx.putTxIndexHeight(end)
+		})
+		if err != nil {
+			return start - from, err
+		}
+	}
+
+	return to - from, nil
+}
+
+// indexBlock puts the index entries of the txs of a block at the specified
+// height.
+func (t transaction) indexBlock(height uint64) error {
+	hash, err := t.FetchBlockHashByHeight(height)
+	if err == database.ErrBlockNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	header, err := t.FetchBlockHeader(hash)
+	if err != nil {
+		return err
+	}
+
+	scanFilter := append(append([]byte{}, TxPrefix...), hash...)
+
+	iterator := t.snapshot.NewIterator(util.BytesPrefix(scanFilter), nil)
+	defer iterator.Release()
+
+	for iterator.Next() {
+		// Key = TxPrefix + block.header.hash + txID
+		txID := append([]byte{}, iterator.Key()[len(scanFilter):]...)
+
+		tx, txIndex, err := utils.DecodeBlockTx(iterator.Value(), database.AnyTxType)
+		if err != nil {
+			return err
+		}
+
+		t.indexTx(optypePut, tx, header, txIndex, txID)
+	}
+
+	return iterator.Error()
+}
+
+// fetchTxIndexHeight returns the TxIndexedPrefix value, if any.
+func (t transaction) fetchTxIndexHeight() (uint64, bool, error) {
+	value, err := t.snapshot.Get(TxIndexedPrefix, nil)
+	if err == leveldb.ErrNotFound {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	var height uint64
+	if err := utils.ReadUint64(bytes.NewReader(value), &height); err != nil {
+		return 0, false, err
+	}
+
+	return height, true, nil
+}
+
+func (t transaction) putTxIndexHeight(height uint64) error {
+	heightBuf := new(bytes.Buffer)
+	if err := utils.WriteUint64(heightBuf, height); err != nil {
+		return err
+	}
+
+	t.put(TxIndexedPrefix, heightBuf.Bytes())
+	return nil
+}
@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package heavy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	assert "github.com/stretchr/testify/require"
+)
+
+func indexedHeights(txs []database.IndexedTx) []uint64 {
+	heights := make([]uint64, len(txs))
+	for i, tx := range txs {
+		heights[i] = tx.BlockHeight
+	}
+
+	return heights
+}
+
+func TestTxIndex(t *testing.T) {
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := config.Get()
+	c.Database.TxIndex = true
+	config.Mock(&c)
+
+	db, err := NewDatabase(t.TempDir(), false)
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = closeStorage()
+	}()
+
+	contractA := bytes.Repeat([]byte{0xaa}, 32)
+	contractB := bytes.Repeat([]byte{0xbb}, 32)
+
+	// Odd heights call contractA, even ones contractB
+	blocks := linkedChain(5)
+	for _, b := range blocks[1:] {
+		contract := contractB
+		if b.Header.Height%2 == 1 {
+			contract = contractA
+		}
+
+		b.Txs = append(b.Txs, transactions.MockTxWithCall(contract, []byte{1}))
+	}
+
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		for _, b := range blocks {
+			if err := t.StoreBlock(b, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+
+	byContract := func(contractID []byte, offset, limit uint64) []uint64 {
+		var txs []database.IndexedTx
+
+		assert.NoError(t, db.View(func(t database.Transaction) error {
+			txs, err = t.FetchTxsByContract(contractID, offset, limit)
+			return err
+		}))
+
+		return indexedHeights(txs)
+	}
+
+	assert.Equal(t, []uint64{5, 3, 1}, byContract(contractA, 0, 10))
+	assert.Equal(t, []uint64{4, 2}, byContract(contractB, 0, 10))
+
+	// Pagination
+	assert.Equal(t, []uint64{3}, byContract(contractA, 1, 1))
+	assert.Empty(t, byContract(contractA, 3, 10))
+
+	var txs []database.IndexedTx
+
+	assert.NoError(t, db.View(func(t database.Transaction) error {
+		txs, err = t.FetchTxsByType(transactions.Transfer, 0, 100)
+		return err
+	}))
+
+	// A RandTx and a contract call per block, but genesis
+	assert.Equal(t, 11, len(txs))
+	assert.Equal(t, uint64(5), txs[0].BlockHeight)
+	assert.Equal(t, uint32(1), txs[0].TxIndex)
+
+	txID, err := blocks[5].Txs[1].CalculateHash()
+	assert.NoError(t, err)
+
+	fetchedID, err := txs[0].Tx.CalculateHash()
+	assert.NoError(t, err)
+	assert.Equal(t, txID, fetchedID)
+
+	// Pruned blocks are unindexed
+	pruned, err := db.(DB).Prune(3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), pruned)
+	assert.Equal(t, []uint64{5, 3}, byContract(contractA, 0, 10))
+
+	// Reverted blocks are unindexed
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		return t.DeleteBlock(blocks[5])
+	}))
+	assert.Equal(t, []uint64{3}, byContract(contractA, 0, 10))
+
+	// Lookups fail if the index is not maintained
+	disabled := db.(DB)
+	disabled.txIndex = false
+
+	assert.Equal(t, database.ErrTxIndexDisabled, disabled.View(func(t database.Transaction) error {
+		_, err := t.FetchTxsByType(transactions.Transfer, 0, 10)
+		return err
+	}))
+}
+
+func TestTxIndexBackfill(t *testing.T) {
+	db, err := NewDatabase(t.TempDir(), false)
+	assert.NoError(t, err)
+
+	defer func() {
+		_ = closeStorage()
+	}()
+
+	// The chain is stored while the index is disabled
+	blocks := linkedChain(5)
+
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		for _, b := range blocks[:5] {
+			if err := t.StoreBlock(b, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+
+	enabled := db.(DB)
+	enabled.txIndex = true
+
+	byType := func() ([]database.IndexedTx, error) {
+		var txs []database.IndexedTx
+
+		err := enabled.View(func(t database.Transaction) error {
+			var err error
+			txs, err = t.FetchTxsByType(transactions.Transfer, 0, 100)
+			return err
+		})
+
+		return txs, err
+	}
+
+	// Lookups fail until the index is built
+	assert.NoError(t, enabled.Update(func(t database.Transaction) error {
+		return t.StoreBlock(blocks[5], true)
+	}))
+
+	_, err = byType()
+	assert.Equal(t, database.ErrTxIndexNotReady, err)
+
+	indexed, err := enabled.IndexTxs()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), indexed)
+
+	txs, err := byType()
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{5, 4, 3, 2, 1, 0}, indexedHeights(txs))
+
+	indexed, err = enabled.IndexTxs()
+	assert.NoError(t, err)
+	assert.Zero(t, indexed)
+
+	// A block stored while the index is disabled makes it built again
+	assert.NoError(t, db.Update(func(t database.Transaction) error {
+		return t.StoreBlock(linkedChain(6)[6], true)
+	}))
+
+	_, err = byType()
+	assert.Equal(t, database.ErrTxIndexNotReady, err)
+}
@@ -0,0 +1,200 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package heavy
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/utils"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// pruneBatchSize is the number of blocks pruned within a single atomic batch.
+const pruneBatchSize = 1000
+
+// Prune deletes TxPrefix and tx index records of all blocks stored more than
+// depth blocks below the persisted block. Headers, height index and TxIDPrefix
+// records are kept for the whole chain, so that a lookup of a pruned tx is
+// told apart from a lookup of an unknown one.
+//
+// Blocks are pruned in batches, each of them updating PrunedPrefix. That said,
+// an interrupted pruning is resumed on the next call. Once done, the pruned
+// key ranges are compacted to reclaim disk space.
+func (db DB) Prune(depth uint64) (uint64, error) {
+	if db.readOnly {
+		return 0, errors.New("database is read-only")
+	}
+
+	var from, to uint64
+
+	err := db.View(func(t database.Transaction) error {
+		tx := t.(*transaction)
+
+		s, err := tx.FetchRegistry()
+		if err != nil {
+			return err
+		}
+
+		persisted, err := tx.FetchBlockHeader(s.PersistedHash)
+		if err != nil {
+			return err
+		}
+
+		if persisted.Height <= depth {
+			return nil
+		}
+
+		height, pruned, err := tx.fetchPrunedHeight()
+		if err != nil {
+			return err
+		}
+
+		if pruned {
+			from = height + 1
+		}
+
+		// Exclusive upper bound
+		to = persisted.Height - depth
+		return nil
+	})
+	if err != nil || from >= to {
+		return 0, err
+	}
+
+	for start := from; start < to; start += pruneBatchSize {
+		end := start + pruneBatchSize
+		if end > to {
+			end = to
+		}
+
+		err = db.Update(func(t database.Transaction) error {
+			tx := t.(*transaction)
+
+			for height := start; height < end; height++ {
+				if err := tx.pruneBlock(height); err != nil {
+					return err
+				}
+			}
+
+			heightBuf := new(bytes.Buffer)
+			if err := utils.WriteUint64(heightBuf, end-1); err != nil {
+				return err
+			}
+
+			tx.put(PrunedPrefix, heightBuf.Bytes())
+			return nil
+		})
+		if err != nil {
+			return start - from, err
+		}
+	}
+
+	for _, prefix := range [][]byte{TxPrefix, ContractIndexPrefix, TypeIndexPrefix} {
+		if err := db.storage.CompactRange(*util.BytesPrefix(prefix)); err != nil {
+			return to - from, err
+		}
+	}
+
+	return to - from, nil
+}
+
+// pruneBlock deletes all transactions data of a block at the specified height,
+// but the TxIDPrefix records.
+func (t transaction) pruneBlock(height uint64) error {
+	hash, err := t.FetchBlockHashByHeight(height)
+	if err == database.ErrBlockNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	header, err := t.FetchBlockHeader(hash)
+	if err != nil {
+		return err
+	}
+
+	scanFilter := append(TxPrefix, hash...)
+
+	iterator := t.snapshot.NewIterator(util.BytesPrefix(scanFilter), nil)
+	defer iterator.Release()
+
+	for iterator.Next() {
+		// Key = TxPrefix + block.header.hash + txID
+		txID := append([]byte{}, iterator.Key()[len(scanFilter):]...)
+
+		tx, txIndex, err := utils.DecodeBlockTx(iterator.Value(), database.AnyTxType)
+		if err != nil {
+			return err
+		}
+
+		t.indexTx(optypeDelete, tx, header, txIndex, txID)
+		t.op(optypeDelete, append([]byte{}, iterator.Key()...), nil)
+	}
+
+	return iterator.Error()
+}
+
+// PrunedHeight returns the height of the highest pruned block, if any.
+func (db DB) PrunedHeight() (uint64, bool, error) {
+	var (
+		height uint64
+		pruned bool
+	)
+
+	err := db.View(func(t database.Transaction) error {
+		var err error
+		height, pruned, err = t.(*transaction).fetchPrunedHeight()
+		return err
+	})
+
+	return height, pruned, err
+}
+
+// fetchPrunedHeight returns the height of the highest pruned block, if any.
+func (t transaction) fetchPrunedHeight() (uint64, bool, error) {
+	value, err := t.snapshot.Get(PrunedPrefix, nil)
+	if err == leveldb.ErrNotFound {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	var height uint64
+	if err := utils.ReadUint64(bytes.NewReader(value), &height); err != nil {
+		return 0, false, err
+	}
+
+	return height, true, nil
+}
+
+// checkPruned returns database.ErrBlockPruned if the block transactions data
+// has been pruned.
+func (t transaction) checkPruned(hash []byte) error {
+	height, pruned, err := t.fetchPrunedHeight()
+	if err != nil || !pruned {
+		return err
+	}
+
+	header, err := t.FetchBlockHeader(hash)
+	if err != nil {
+		// Unknown block
+		return nil
+	}
+
+	if header.Height <= height {
+		return database.ErrBlockPruned
+	}
+
+	return nil
+}
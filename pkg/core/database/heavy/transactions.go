@@ -58,6 +58,14 @@ var (
 	PersistedPrefix = []byte{0x06}
 	// CandidatePrefix is the prefix to identify Candidate messages.
 	CandidatePrefix = []byte{0x07}
+	// PrunedPrefix is the prefix to identify the height of the highest pruned block.
+	PrunedPrefix = []byte{0x08}
+	// ContractIndexPrefix is the prefix to identify the txs index by contract ID.
+	ContractIndexPrefix = []byte{0x09}
+	// TypeIndexPrefix is the prefix to identify the txs index by tx type.
+	TypeIndexPrefix = []byte{0x0A}
+	// TxIndexedPrefix is the prefix to identify the progress of the txs index.
+	TxIndexedPrefix = []byte{0x0B}
 )
 
 type transaction struct {
@@ -178,6 +186,12 @@ func (t *transaction) modify(optype int, b *block.Block) error {
 		//
 		// For the retrival of a single transaction by TxId
 		t.op(optype, append(TxIDPrefix, txID...), b.Header.Hash)
+
+		// Index entries are deleted even if the index is disabled, as the
+		// block might have been stored while it was enabled
+		if t.db.txIndex || optype == optypeDelete {
+			t.indexTx(optype, tx, b.Header, uint32(i), txID)
+		}
 	}
 
 	// Key = HeightPrefix + block.header.height
@@ -195,6 +209,26 @@ func (t *transaction) modify(optype int, b *block.Block) error {
 
 	t.op(optype, key, b.Header.Hash)
 
+	if optype == optypePut {
+		return t.markTxIndex(b.Header.Height)
+	}
+
+	return nil
+}
+
+// markTxIndex updates the progress of the tx index on storing a block. A chain
+// indexed from genesis is built already, while a block stored with the index
+// disabled leaves it to be built again.
+func (t *transaction) markTxIndex(height uint64) error {
+	if !t.db.txIndex {
+		t.op(optypeDelete, TxIndexedPrefix, nil)
+		return nil
+	}
+
+	if height == 0 {
+		return t.putTxIndexHeight(txIndexBuilt)
+	}
+
 	return nil
 }
 
@@ -292,6 +326,13 @@ func (t transaction) FetchBlockTxs(hashHeader []byte) ([]transactions.ContractCa
 		tempTxs[txIndex] = tx
 	}
 
+	// A block without transactions data might have been pruned
+	if len(tempTxs) == 0 {
+		if err := t.checkPruned(hashHeader); err != nil {
+			return nil, err
+		}
+	}
+
 	// Reorder Tx slice as per retrieved indexes
 	resultTxs := make([]transactions.ContractCall, len(tempTxs))
 	for k, v := range tempTxs {
@@ -370,6 +411,11 @@ func (t transaction) FetchBlockTxByHash(txID []byte) (transactions.ContractCall,
 		return nil, txIndex, nil, err
 	}
 
+	// The TxID index of the pruned blocks is kept
+	if err := t.checkPruned(hashHeader); err != nil {
+		return nil, txIndex, nil, err
+	}
+
 	// Fetch all the txs that belong to a single block
 	// Return only the transaction that is associated to txID
 	scanFilter := append(TxPrefix, hashHeader...)
@@ -28,6 +28,15 @@ var (
 	ErrOutputNotFound = errors.New("database: output not found")
 	// ErrStateHashNotFound returned on state hash not linked to any block.
 	ErrStateHashNotFound = errors.New("database: state hash was not found")
+	// ErrBlockPruned returned on a tx lookup when the requested data might
+	// have been discarded by the pruning mode.
+	ErrBlockPruned = errors.New("database: block data pruned")
+	// ErrTxIndexDisabled returned on a tx lookup by contract or type when
+	// the secondary tx index is not maintained.
+	ErrTxIndexDisabled = errors.New("database: tx index disabled")
+	// ErrTxIndexNotReady returned on a tx lookup by contract or type while
+	// the index of the blocks stored before it was enabled is being built.
+	ErrTxIndexNotReady = errors.New("database: tx index not built yet")
 
 	// AnyTxType is used as a filter value on FetchBlockTxByHash.
 	AnyTxType = transactions.TxType(math.MaxUint8)
@@ -67,6 +76,14 @@ type Transaction interface {
 	FetchBlockExists(hash []byte) (bool, error)
 	FetchBlockByStateRoot(fromHeight uint64, stateRoot []byte) (*block.Block, error)
 
+	// FetchTxsByContract returns the txs calling a contract, most recent
+	// first. The first offset txs are skipped and at most limit txs are
+	// returned.
+	FetchTxsByContract(contractID []byte, offset, limit uint64) ([]IndexedTx, error)
+	// FetchTxsByType returns the txs of a type, most recent first. See also
+	// FetchTxsByContract.
+	FetchTxsByType(txType transactions.TxType, offset, limit uint64) ([]IndexedTx, error)
+
 	// Fetch chain registry (chain tip hash, persisted block etc).
 	FetchRegistry() (*Registry, error)
 
@@ -123,8 +140,34 @@ type DB interface {
 	Close() error
 }
 
+// Pruner is implemented by the DB drivers capable of discarding transactions
+// data of old blocks. Block headers, certificates included, are never pruned.
+type Pruner interface {
+	// Prune deletes the transactions data of all blocks stored more than depth
+	// blocks below the persisted block. It returns the number of blocks pruned.
+	Prune(depth uint64) (uint64, error)
+	// PrunedHeight returns the height of the highest pruned block, if any.
+	PrunedHeight() (uint64, bool, error)
+}
+
+// TxIndexer is implemented by the DB drivers maintaining a tx index.
+type TxIndexer interface {
+	// IndexTxs indexes the blocks stored before the tx index was enabled. It
+	// returns the number of blocks indexed.
+	IndexTxs() (uint64, error)
+}
+
 // Registry represents a set database records that provide chain metadata.
 type Registry struct {
 	TipHash       []byte
 	PersistedHash []byte
 }
+
+// IndexedTx is a tx returned by a lookup on the secondary tx index.
+type IndexedTx struct {
+	Tx      transactions.ContractCall
+	TxIndex uint32
+
+	BlockHash   []byte
+	BlockHeight uint64
+}
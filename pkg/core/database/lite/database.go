@@ -9,6 +9,7 @@ package lite
 import (
 	"sync"
 
+	cfg "github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 )
 
@@ -38,6 +39,9 @@ type DB struct {
 	mu       sync.RWMutex
 	readOnly bool
 	path     string
+
+	// If true, txs can be looked up by contract ID and tx type.
+	txIndex bool
 }
 
 // NewDatabase returns a DB instance.
@@ -52,7 +56,7 @@ func NewDatabase(path string, readonly bool) (database.DB, error) {
 		tables[i] = make(table)
 	}
 
-	db = &DB{path: path, readOnly: readonly, storage: tables}
+	db = &DB{path: path, readOnly: readonly, storage: tables, txIndex: cfg.Get().Database.TxIndex}
 
 	return db, nil
 }
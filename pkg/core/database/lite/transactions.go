@@ -16,6 +16,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database/utils"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 )
 
@@ -123,12 +124,12 @@ func (t transaction) FetchBlockHeader(hash []byte) (*block.Header, error) {
 		return nil, database.ErrBlockNotFound
 	}
 
-	b := block.NewBlock()
-	if err := message.UnmarshalBlock(bytes.NewBuffer(data), b); err != nil {
+	h := block.NewHeader()
+	if err := message.UnmarshalHeader(bytes.NewBuffer(data), h); err != nil {
 		return nil, err
 	}
 
-	return b.Header, nil
+	return h, nil
 }
 
 func (t transaction) FetchBlockTxs(hash []byte) ([]transactions.ContractCall, error) {
@@ -139,12 +140,12 @@ func (t transaction) FetchBlockTxs(hash []byte) ([]transactions.ContractCall, er
 		return nil, database.ErrBlockNotFound
 	}
 
-	b := block.NewBlock()
-	if err := message.UnmarshalBlock(bytes.NewBuffer(data), b); err != nil {
+	r := bytes.NewBuffer(data)
+	if err := message.UnmarshalHeader(r, block.NewHeader()); err != nil {
 		return nil, err
 	}
 
-	return b.Txs, nil
+	return unmarshalBlockTxs(r)
 }
 
 func (t transaction) FetchBlockHashByHeight(height uint64) ([]byte, error) {
@@ -162,12 +163,53 @@ func (t transaction) FetchBlockHashByHeight(height uint64) ([]byte, error) {
 		return nil, database.ErrBlockNotFound
 	}
 
-	b := block.NewBlock()
-	if err := message.UnmarshalBlock(bytes.NewBuffer(data), b); err != nil {
+	h := block.NewHeader()
+	if err := message.UnmarshalHeader(bytes.NewBuffer(data), h); err != nil {
 		return nil, err
 	}
 
-	return b.Header.Hash, nil
+	return h.Hash, nil
+}
+
+// unmarshalBlockTxs reads the transactions list of a stored block. In the same
+// way as heavy driver does, a transaction which payload cannot be decoded
+// (e.g the genesis one) is returned without its hash instead of failing the
+// whole fetch.
+func unmarshalBlockTxs(r *bytes.Buffer) ([]transactions.ContractCall, error) {
+	lTxs, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]transactions.ContractCall, lTxs)
+	for i := range txs {
+		tx := transactions.NewTransaction()
+
+		if err := encoding.ReadUint32LE(r, &tx.Version); err != nil {
+			return nil, err
+		}
+
+		var txType uint32
+		if err := encoding.ReadUint32LE(r, &txType); err != nil {
+			return nil, err
+		}
+
+		tx.TxType = transactions.TxType(txType)
+
+		if err := transactions.UnmarshalTransactionPayload(r, tx.Payload); err != nil {
+			return nil, err
+		}
+
+		if d, err := tx.Decode(); err == nil {
+			if hash, err := d.Hash(tx.TxType); err == nil {
+				copy(tx.Hash[:], hash)
+			}
+		}
+
+		txs[i] = tx
+	}
+
+	return txs, nil
 }
 
 func (t transaction) FetchBlockTxByHash(txID []byte) (transactions.ContractCall, uint32, []byte, error) {
@@ -376,3 +418,81 @@ func (t transaction) ClearDatabase() error {
 
 	return nil
 }
+
+// FetchTxsByContract scans the chain from the tip down, as no secondary index
+// is maintained in memory.
+func (t transaction) FetchTxsByContract(contractID []byte, offset, limit uint64) ([]database.IndexedTx, error) {
+	return t.scanTxs(func(tx transactions.ContractCall) bool {
+		decoded, err := tx.Decode()
+		return err == nil && decoded.Call != nil && bytes.Equal(decoded.Call.ContractID, contractID)
+	}, offset, limit)
+}
+
+// FetchTxsByType scans the chain from the tip down. See FetchTxsByContract.
+func (t transaction) FetchTxsByType(txType transactions.TxType, offset, limit uint64) ([]database.IndexedTx, error) {
+	return t.scanTxs(func(tx transactions.ContractCall) bool {
+		return tx.Type() == txType
+	}, offset, limit)
+}
+
+// scanTxs returns the txs matching the filter in the same order as heavy
+// driver does, most recent first. As with heavy driver, lookups fail if the
+// tx index is disabled.
+func (t transaction) scanTxs(filter func(transactions.ContractCall) bool, offset, limit uint64) ([]database.IndexedTx, error) {
+	if !t.db.txIndex {
+		return nil, database.ErrTxIndexDisabled
+	}
+
+	result := make([]database.IndexedTx, 0)
+
+	if limit == 0 {
+		return result, nil
+	}
+
+	tip, err := t.FetchCurrentHeight()
+	if err == database.ErrStateNotFound {
+		return result, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for height := tip; ; height-- {
+		hash, err := t.FetchBlockHashByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+
+		txs, err := t.FetchBlockTxs(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := len(txs) - 1; i >= 0; i-- {
+			if !filter(txs[i]) {
+				continue
+			}
+
+			if offset > 0 {
+				offset--
+				continue
+			}
+
+			result = append(result, database.IndexedTx{
+				Tx:          txs[i],
+				TxIndex:     uint32(i),
+				BlockHash:   hash,
+				BlockHeight: height,
+			})
+
+			if uint64(len(result)) >= limit {
+				return result, nil
+			}
+		}
+
+		if height == 0 {
+			return result, nil
+		}
+	}
+}
@@ -139,6 +139,7 @@ func _TestDriver(m *testing.M, driverName string) int {
 	code := m.Run()
 
 	if drvrName != lite.DriverName {
+		code += _TestPruning()
 		code += _TestPersistence()
 	}
 
@@ -678,6 +679,88 @@ func TestClearDatabase(test *testing.T) {
 	}
 }
 
+// _TestPruning ensures transactions data of the blocks below the pruning depth
+// is discarded while their headers are kept. As it alters the sample chain
+// data, it can be called only if all tests have completed.
+// It returns result code 0 if all checks pass.
+func _TestPruning() int {
+	pruner, ok := db.(database.Pruner)
+	if !ok {
+		fmt.Printf("TestPruning failed: %s does not support pruning\n", drvrName)
+		return 1
+	}
+
+	const depth = 3
+
+	tip := blocks[len(blocks)-1]
+
+	// Mark the chain tip as persisted
+	err := db.Update(func(t database.Transaction) error {
+		return t.StoreBlock(tip, true)
+	})
+	if err != nil {
+		fmt.Printf("TestPruning failed: %v\n", err)
+		return 1
+	}
+
+	pruned, err := pruner.Prune(depth)
+	if err != nil {
+		fmt.Printf("TestPruning failed: %v\n", err)
+		return 1
+	}
+
+	if pruned != tip.Header.Height-depth {
+		fmt.Printf("TestPruning failed: unexpected pruned blocks count %d\n", pruned)
+		return 1
+	}
+
+	err = db.View(func(t database.Transaction) error {
+		for _, blk := range blocks {
+			if _, err := t.FetchBlockHeader(blk.Header.Hash); err != nil {
+				return err
+			}
+
+			txID, _ := blk.Txs[0].CalculateHash()
+			_, _, _, txErr := t.FetchBlockTxByHash(txID)
+			_, blockErr := t.FetchBlockTxs(blk.Header.Hash)
+
+			if blk.Header.Height < tip.Header.Height-depth {
+				if blockErr != database.ErrBlockPruned || txErr != database.ErrBlockPruned {
+					return fmt.Errorf("block on height %d was not pruned", blk.Header.Height)
+				}
+
+				continue
+			}
+
+			if blockErr != nil || txErr != nil {
+				return fmt.Errorf("block on height %d was pruned", blk.Header.Height)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("TestPruning failed: %v\n", err)
+		return 1
+	}
+
+	height, ok, err := pruner.PrunedHeight()
+	if err != nil || !ok || height != tip.Header.Height-depth-1 {
+		fmt.Printf("TestPruning failed: unexpected pruned height %d, %v\n", height, err)
+		return 1
+	}
+
+	// Nothing left to prune at the same depth
+	if pruned, err = pruner.Prune(depth); err != nil || pruned != 0 {
+		fmt.Printf("TestPruning failed: repeated pruning %d, %v\n", pruned, err)
+		return 1
+	}
+
+	fmt.Printf("--- PASS: TestPruning\n")
+
+	return 0
+}
+
 // _TestPersistence tries to ensure if driver provides persistence storage.
 // The procedure is simply based on:
 // 1. Close the driver
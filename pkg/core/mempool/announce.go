@@ -0,0 +1,150 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package mempool
+
+import (
+	"bytes"
+	"expvar"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/inventory"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+)
+
+const (
+	defaultAnnounceWindow = 100 * time.Millisecond
+	defaultMaxInvItems    = 500
+	defaultFanout         = 3
+)
+
+var relayMetrics = expvar.NewMap("txrelay")
+
+// announcer batches the hashes of the txs to propagate, and announces them in
+// Inv messages to each relay peer not knowing them yet. The peers fetch the
+// txs they miss with GetData.
+type announcer struct {
+	publisher eventbus.Publisher
+	inventory *inventory.Inventory
+
+	window   time.Duration
+	maxItems int
+	fanout   uint8
+
+	batch [][]byte
+}
+
+func newAnnouncer(publisher eventbus.Publisher, inv *inventory.Inventory) *announcer {
+	cfg := config.Get().Network.TxRelay
+
+	window := defaultAnnounceWindow
+
+	if len(cfg.Window) > 0 {
+		var err error
+
+		window, err = time.ParseDuration(cfg.Window)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse tx relay window")
+		}
+	}
+
+	maxItems := int(cfg.MaxInvItems)
+	if maxItems == 0 {
+		maxItems = defaultMaxInvItems
+	}
+
+	fanout := cfg.Fanout
+	if fanout == 0 {
+		fanout = defaultFanout
+	}
+
+	return &announcer{
+		publisher: publisher,
+		inventory: inv,
+		window:    window,
+		maxItems:  maxItems,
+		fanout:    fanout,
+	}
+}
+
+// add queues the hash of a tx received from source, which is empty for the
+// txs submitted locally. It returns true once the batch is full.
+func (a *announcer) add(txid []byte, source string) bool {
+	a.inventory.Add(source, txid)
+	a.batch = append(a.batch, txid)
+
+	return len(a.batch) >= a.maxItems
+}
+
+// flush announces the batch to the relay peers, skipping the hashes they
+// know. While fewer relay peers than the fanout are known, the whole batch is
+// also announced to random nodes, through which the relay peers are met.
+func (a *announcer) flush() {
+	if len(a.batch) == 0 {
+		return
+	}
+
+	batch := a.batch
+	a.batch = nil
+
+	peers := a.inventory.Peers()
+
+	for _, peer := range peers {
+		txids := a.inventory.Unknown(peer, batch)
+		relayMetrics.Add("skipped", int64(len(batch)-len(txids)))
+
+		if len(txids) == 0 {
+			continue
+		}
+
+		buf, err := marshalInv(txids)
+		if err != nil {
+			log.WithError(err).Error("failed to marshal inv")
+			return
+		}
+
+		metadata := message.Metadata{Source: peer}
+		a.publisher.Publish(topics.KadcastSendToOne, message.NewWithMetadata(topics.KadcastSendToOne, *buf, &metadata))
+
+		relayMetrics.Add("announced", int64(len(txids)))
+	}
+
+	if len(peers) >= int(a.fanout) {
+		return
+	}
+
+	buf, err := marshalInv(batch)
+	if err != nil {
+		log.WithError(err).Error("failed to marshal inv")
+		return
+	}
+
+	metadata := message.Metadata{NumNodes: a.fanout}
+	a.publisher.Publish(topics.KadcastSendToMany, message.NewWithMetadata(topics.KadcastSendToMany, *buf, &metadata))
+
+	relayMetrics.Add("announced", int64(len(batch))*int64(a.fanout))
+}
+
+func marshalInv(txids [][]byte) (*bytes.Buffer, error) {
+	inv := &message.Inv{}
+	for _, txid := range txids {
+		inv.AddItem(message.InvTypeMempoolTx, txid)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := inv.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	if err := topics.Prepend(buf, topics.Inv); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
@@ -0,0 +1,156 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package mempool
+
+import (
+	"errors"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+)
+
+var (
+	// ErrMempoolFull no room could be made for the transaction in the mempool.
+	ErrMempoolFull = errors.New("mempool is full, dropping transaction")
+	// ErrFeeTooLow transaction fee is below the current mempool fee floor.
+	ErrFeeTooLow = errors.New("fee is below the mempool minimum fee")
+)
+
+// evictionCandidate is a pool entry that could be dropped to make room for a
+// better-paying transaction.
+type evictionCandidate struct {
+	k    txHash
+	tx   transactions.ContractCall
+	fee  uint64
+	size uint
+}
+
+// maxPoolSize returns the configured mempool capacity in bytes.
+func maxPoolSize() uint32 {
+	return config.Get().Mempool.MaxSizeMB * 1000 * 1000
+}
+
+// minFee returns the minimum gas price a transaction should pay to enter the
+// mempool at its current fill level.
+//
+// The floor equals MinFee until the pool is filled up to FeeFloorThreshold.
+// Above that, it rises linearly up to MinFee*FeeFloorMultiplier when the pool
+// is full.
+func (m *Mempool) minFee() uint64 {
+	cfg := config.Get().Mempool
+
+	maxSize := uint64(maxPoolSize())
+	if maxSize == 0 || cfg.FeeFloorMultiplier <= 1 || cfg.FeeFloorThreshold >= 100 {
+		return cfg.MinFee
+	}
+
+	fill := uint64(m.verified.Size()) * 100 / maxSize
+	threshold := uint64(cfg.FeeFloorThreshold)
+
+	if fill <= threshold {
+		return cfg.MinFee
+	}
+
+	if fill > 100 {
+		fill = 100
+	}
+
+	rise := cfg.MinFee * (cfg.FeeFloorMultiplier - 1) * (fill - threshold) / (100 - threshold)
+	return cfg.MinFee + rise
+}
+
+// evictable returns the transactions to evict to make room for t, lowest fee
+// first, and the number of bytes to free. Only transactions paying strictly
+// less than fee are evictable. ErrMempoolFull is returned if they are not
+// enough. The pool is left untouched.
+func (m *Mempool) evictable(t TxDesc, fee uint64) ([]evictionCandidate, uint32, error) {
+	maxSize := maxPoolSize()
+
+	size := m.verified.Size() + uint32(t.size)
+	if size <= maxSize {
+		return nil, 0, nil
+	}
+
+	needed := size - maxSize
+
+	// RangeSort iterates from the highest to the lowest fee. Candidates are
+	// collected first, as the pool cannot be modified while iterating.
+	candidates := make([]evictionCandidate, 0, m.verified.Len())
+
+	err := m.verified.RangeSort(func(k txHash, d TxDesc) (bool, error) {
+		f, err := d.tx.Fee()
+		if err != nil {
+			log.WithError(err).Warn("fee could not be read")
+		}
+
+		candidates = append(candidates, evictionCandidate{k: k, tx: d.tx, fee: f, size: d.size})
+		return false, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	evictable := make([]evictionCandidate, 0)
+
+	var total uint32
+
+	for i := len(candidates) - 1; i >= 0 && candidates[i].fee < fee; i-- {
+		evictable = append(evictable, candidates[i])
+		total += uint32(candidates[i].size)
+	}
+
+	if total < needed {
+		log.WithField("max_size_mb", config.Get().Mempool.MaxSizeMB).
+			WithField("alloc_size", m.verified.Size()/1000).
+			WithField("fee", fee).
+			Warn("mempool is full, dropping transaction")
+		return nil, 0, ErrMempoolFull
+	}
+
+	return evictable, needed, nil
+}
+
+// makeRoom ensures the pool can accommodate t by evicting the lowest-fee
+// transactions. Only transactions paying strictly less than fee are evicted.
+// If not enough room can be made, the pool is left untouched and
+// ErrMempoolFull is returned. The same error is returned if evictions fail
+// on the way.
+func (m *Mempool) makeRoom(t TxDesc, fee uint64) error {
+	candidates, needed, err := m.evictable(t, fee)
+	if err != nil {
+		return err
+	}
+
+	var freed uint32
+
+	for _, c := range candidates {
+		if freed >= needed {
+			break
+		}
+
+		if err := m.verified.Delete(c.k[:]); err != nil {
+			continue
+		}
+
+		freed += uint32(c.size)
+
+		log.WithField("txid", toHex(c.k[:])).
+			WithField("fee", c.fee).
+			WithField("new_fee", fee).
+			Info("evicted transaction")
+
+		m.eventBus.Publish(topics.EvictedTx, message.New(topics.EvictedTx, c.tx))
+	}
+
+	if freed < needed {
+		return ErrMempoolFull
+	}
+
+	return nil
+}
@@ -29,6 +29,8 @@ type TxDesc struct {
 
 	// Kadcast transport-specific field.
 	kadHeight byte
+	// the peer the tx was received from, empty if submitted locally.
+	source string
 }
 
 // Pool represents a transaction pool of the verified txs only.
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -23,6 +24,8 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/inventory"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
@@ -34,9 +37,10 @@ import (
 var log = logger.WithFields(logger.Fields{"process": "mempool"})
 
 const (
-	idleTime        = 20 * time.Second
-	backendHashmap  = "hashmap"
-	backendDiskpool = "diskpool"
+	idleTime            = 20 * time.Second
+	defaultReapInterval = time.Minute
+	backendHashmap      = "hashmap"
+	backendDiskpool     = "diskpool"
 )
 
 var (
@@ -53,13 +57,22 @@ var (
 type Mempool struct {
 	getMempoolTxsChan       <-chan rpcbus.Request
 	getMempoolTxsBySizeChan <-chan rpcbus.Request
+	getTxsByShortIDsChan    <-chan rpcbus.Request
 	sendTxChan              <-chan rpcbus.Request
 
 	// verified txs to be included in next block.
 	verified Pool
 
+	// admitLock serializes eviction and insertion into the verified pool.
+	admitLock *sync.Mutex
+
 	pendingPropagation chan TxDesc
 
+	// announcer relays the tx hashes instead of the full txs, if set.
+	announcer *announcer
+	// inventory records the txs known by each peer.
+	inventory *inventory.Inventory
+
 	// the collector to listen for new accepted blocks.
 	acceptedBlockChan <-chan block.Block
 
@@ -70,6 +83,29 @@ type Mempool struct {
 
 	limiter *rate.Limiter
 
+	// time-to-live of a transaction in the verified pool. Zero value
+	// disables expiry.
+	txTTL        time.Duration
+	reapInterval time.Duration
+
+	// file the verified pool is persisted into. Empty value disables it.
+	snapshotFile string
+	// period of persisting the verified pool. Zero value persists it on
+	// closing only.
+	snapshotInterval time.Duration
+	// number of transactions expired since start-up.
+	expiredTxs uint64
+
+	// unrestored holds the persisted txs not yet restored.
+	unrestored  []TxDesc
+	restoreLock *sync.Mutex
+
+	// revalidateChan triggers a revalidation of the pool.
+	revalidateChan chan struct{}
+	// revalidationQueue holds the txs left to revalidate, highest fee first.
+	// It is accessed by the revalidation loop only.
+	revalidationQueue []txHash
+
 	db database.DB
 }
 
@@ -90,6 +126,11 @@ func NewMempool(db database.DB, eventBus *eventbus.EventBus, rpcBus *rpcbus.RPCB
 		log.WithError(err).Error("failed to register topics.GetMempoolTxsBySize")
 	}
 
+	getTxsByShortIDsChan := make(chan rpcbus.Request, 1)
+	if err := rpcBus.Register(topics.GetMempoolTxsByShortIDs, getTxsByShortIDsChan); err != nil {
+		log.WithError(err).Error("failed to register topics.GetMempoolTxsByShortIDs")
+	}
+
 	sendTxChan := make(chan rpcbus.Request, 1)
 	if err := rpcBus.Register(topics.SendMempoolTx, sendTxChan); err != nil {
 		log.WithError(err).Error("failed to register topics.SendMempoolTx")
@@ -119,15 +160,60 @@ func NewMempool(db database.DB, eventBus *eventbus.EventBus, rpcBus *rpcbus.RPCB
 			WithField("propagate_burst", burst)
 	}
 
+	var txTTL time.Duration
+
+	reapInterval := defaultReapInterval
+
+	if len(cfg.TxTTL) > 0 {
+		var err error
+
+		txTTL, err = time.ParseDuration(cfg.TxTTL)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse mempool tx ttl")
+		}
+
+		if len(cfg.ReapInterval) > 0 {
+			reapInterval, err = time.ParseDuration(cfg.ReapInterval)
+			if err != nil {
+				log.WithError(err).Fatal("could not parse mempool reap interval")
+			}
+		}
+
+		l = l.WithField("tx_ttl", cfg.TxTTL).
+			WithField("reap_interval", reapInterval.String())
+	}
+
+	var snapshotInterval time.Duration
+
+	if len(cfg.SnapshotFile) > 0 && len(cfg.SnapshotInterval) > 0 {
+		var err error
+
+		snapshotInterval, err = time.ParseDuration(cfg.SnapshotInterval)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse mempool snapshot interval")
+		}
+
+		l = l.WithField("snapshot_file", cfg.SnapshotFile).
+			WithField("snapshot_interval", cfg.SnapshotInterval)
+	}
+
 	m := &Mempool{
 		eventBus:                eventBus,
 		acceptedBlockChan:       acceptedBlockChan,
 		getMempoolTxsChan:       getMempoolTxsChan,
 		getMempoolTxsBySizeChan: getMempoolTxsBySizeChan,
+		getTxsByShortIDsChan:    getTxsByShortIDsChan,
 		sendTxChan:              sendTxChan,
 		verifier:                verifier,
 		limiter:                 limiter,
 		pendingPropagation:      make(chan TxDesc, 1000),
+		revalidateChan:          make(chan struct{}, 1),
+		admitLock:               &sync.Mutex{},
+		restoreLock:             &sync.Mutex{},
+		txTTL:                   txTTL,
+		reapInterval:            reapInterval,
+		snapshotFile:            cfg.SnapshotFile,
+		snapshotInterval:        snapshotInterval,
 		db:                      db,
 	}
 
@@ -135,18 +221,36 @@ func NewMempool(db database.DB, eventBus *eventbus.EventBus, rpcBus *rpcbus.RPCB
 	// The pool is normally a Hashmap
 	m.verified = m.newPool()
 
+	// Load transactions persisted by the previous run, if any. They are
+	// verified again once running.
+	m.unrestored = m.loadPersisted()
+
 	l.Info("running")
 
 	return m
 }
 
+// AnnounceTxs makes the mempool relay the txs by announcing their hashes to
+// the relay peers of the inventory, instead of broadcasting them in full. It
+// must be called before Run.
+func (m *Mempool) AnnounceTxs(inv *inventory.Inventory) {
+	m.inventory = inv
+	m.announcer = newAnnouncer(m.eventBus, inv)
+}
+
 // Run spawns the mempool lifecycle routines.
 func (m *Mempool) Run(ctx context.Context) {
+	// Restore the persisted transactions through the intake path
+	go m.restore(ctx)
+
 	// Main Loop
 	go m.Loop(ctx)
 
 	// Loop to drain pendingPropagation and try to propagate transaction
 	go m.propagateLoop(ctx)
+
+	// Loop to revalidate the pool on block acceptance
+	go m.revalidateLoop(ctx)
 }
 
 // Loop listens for GetMempoolTxs request and topics.AcceptedBlock events.
@@ -154,16 +258,44 @@ func (m *Mempool) Loop(ctx context.Context) {
 	ticker := time.NewTicker(idleTime)
 	defer ticker.Stop()
 
+	// Reaping expired transactions is enabled only if a TTL is set
+	var reapChan <-chan time.Time
+
+	if m.txTTL > 0 {
+		reaper := time.NewTicker(m.reapInterval)
+		defer reaper.Stop()
+
+		reapChan = reaper.C
+	}
+
+	// Periodic snapshots are enabled only if an interval is set
+	var snapshotChan <-chan time.Time
+
+	if m.snapshotInterval > 0 {
+		snapshotTicker := time.NewTicker(m.snapshotInterval)
+		defer snapshotTicker.Stop()
+
+		snapshotChan = snapshotTicker.C
+	}
+
 	for {
 		select {
 		case r := <-m.getMempoolTxsChan:
 			handleRequest(r, m.processGetMempoolTxsRequest, "GetMempoolTxs")
 		case r := <-m.getMempoolTxsBySizeChan:
 			handleRequest(r, m.processGetMempoolTxsBySizeRequest, "GetMempoolTxsBySize")
+		case r := <-m.getTxsByShortIDsChan:
+			handleRequest(r, m.processGetTxsByShortIDsRequest, "GetMempoolTxsByShortIDs")
 		case b := <-m.acceptedBlockChan:
 			m.onBlock(b)
 		case <-ticker.C:
 			m.onIdle()
+		case <-reapChan:
+			m.reapExpiredTxs(time.Now())
+		case <-snapshotChan:
+			if err := m.snapshot(); err != nil {
+				log.WithError(err).Warn("failed to persist mempool snapshot")
+			}
 		case <-ctx.Done():
 			m.OnClose()
 			log.Info("main_loop terminated")
@@ -175,6 +307,16 @@ func (m *Mempool) Loop(ctx context.Context) {
 }
 
 func (m *Mempool) propagateLoop(ctx context.Context) {
+	// Announced hashes are flushed at the end of each batching window
+	var flushChan <-chan time.Time
+
+	if m.announcer != nil {
+		ticker := time.NewTicker(m.announcer.window)
+		defer ticker.Stop()
+
+		flushChan = ticker.C
+	}
+
 	for {
 		select {
 		case t := <-m.pendingPropagation:
@@ -191,11 +333,22 @@ func (m *Mempool) propagateLoop(ctx context.Context) {
 				continue
 			}
 
+			if m.announcer != nil {
+				if m.announcer.add(txid, t.source) {
+					m.announcer.flush()
+				}
+
+				continue
+			}
+
 			err = m.kadcastTx(t)
 			if err != nil {
 				log.WithField("txid", hex.EncodeToString(txid)).WithError(err).Error("failed to propagate")
 			}
 
+		case <-flushChan:
+			m.announcer.flush()
+
 		// Mempool terminating
 		case <-ctx.Done():
 			log.Info("propagate_loop terminated")
@@ -206,14 +359,6 @@ func (m *Mempool) propagateLoop(ctx context.Context) {
 
 // ProcessTx processes a Transaction wire message.
 func (m *Mempool) ProcessTx(srcPeerID string, msg message.Message) ([]bytes.Buffer, error) {
-	maxSizeBytes := config.Get().Mempool.MaxSizeMB * 1000 * 1000
-	if m.verified.Size() > maxSizeBytes {
-		log.WithField("max_size_mb", maxSizeBytes).
-			WithField("alloc_size", m.verified.Size()/1000).
-			Warn("mempool is full, dropping transaction")
-		return nil, errors.New("mempool is full, dropping transaction")
-	}
-
 	// Initializing `h=0` or `h=KadcastInitialHeight` will not work.
 	// This because `h` will be decremented by the kadcast writer as per
 	// it's interpreted as "the kadcast height at which it's been received"
@@ -229,12 +374,18 @@ func (m *Mempool) ProcessTx(srcPeerID string, msg message.Message) ([]bytes.Buff
 		received:  time.Now(),
 		size:      uint(len(msg.Id())),
 		kadHeight: h,
+		source:    srcPeerID,
 	}
 
 	start := time.Now()
 	txid, err := m.processTx(t)
 	elapsed := time.Since(start)
 
+	// The sender knows the tx, even if it is a duplicate
+	if len(txid) > 0 {
+		m.inventory.Add(srcPeerID, txid)
+	}
+
 	if err != nil {
 		log.WithError(err).
 			WithField("txid", toHex(txid)).
@@ -262,11 +413,32 @@ func (m *Mempool) processTx(t TxDesc) ([]byte, error) {
 		err  error
 	)
 
+	// ensure transaction pays at least the current fee floor
+	fee, err := t.tx.Fee()
+	if err != nil {
+		return nil, err
+	}
+
+	if fee < m.minFee() {
+		return nil, ErrFeeTooLow
+	}
+
+	// ensure room can be made for the transaction before verifying it. The
+	// pool is checked again on admission, as it might fill up meanwhile.
+	if _, _, err = m.evictable(t, fee); err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(),
 		time.Duration(config.Get().RPC.Rusk.ContractTimeout)*time.Millisecond)
 	defer cancel()
 
 	if hash, _, err = m.verifier.Preverify(ctx, t.tx); err != nil {
+		// A timed out verification is not held against the sender
+		if ctx.Err() == nil {
+			err = score.Wrap(score.RejectedTx, err)
+		}
+
 		return nil, err
 	}
 
@@ -302,8 +474,8 @@ func (m *Mempool) processTx(t TxDesc) ([]byte, error) {
 		t.verified = time.Now()
 
 		// store transaction in mempool
-		if err = m.verified.Put(t); err != nil {
-			return txid, fmt.Errorf("store err - %v", err)
+		if err = m.admit(t, fee); err != nil {
+			return txid, err
 		}
 
 		// queue transaction for (re)propagation
@@ -312,13 +484,31 @@ func (m *Mempool) processTx(t TxDesc) ([]byte, error) {
 		}()
 
 		return txid, nil
-	case nil:
+	// The tx ID index is kept on pruning, a pruned tx is still known
+	case nil, database.ErrBlockPruned:
 		return txid, ErrAlreadyExistsInBlockchain
 	default:
 		return txid, err
 	}
 }
 
+// admit stores a verified transaction in the pool, evicting lower-fee
+// transactions if the pool is full.
+func (m *Mempool) admit(t TxDesc, fee uint64) error {
+	m.admitLock.Lock()
+	defer m.admitLock.Unlock()
+
+	if err := m.makeRoom(t, fee); err != nil {
+		return err
+	}
+
+	if err := m.verified.Put(t); err != nil {
+		return fmt.Errorf("store err - %v", err)
+	}
+
+	return nil
+}
+
 // onBlock performs post-block-acceptance procedure to update mempool state
 // accordingly.
 func (m *Mempool) onBlock(b block.Block) {
@@ -326,6 +516,9 @@ func (m *Mempool) onBlock(b block.Block) {
 	// This is the case when the accepted block has been proposed by another provisioner.
 	m.discardAcceptedTxs(b.Txs)
 
+	// Drop transactions invalidated by the new state, if enabled.
+	m.requestRevalidation()
+
 	log.WithField("height", b.Header.Height).
 		WithField("txs_count", len(b.Txs)).
 		WithField("mem_alloc_size", int64(m.verified.Size())/1000).
@@ -372,11 +565,55 @@ func (m Mempool) containNullifier(tx transactions.ContractCall) error {
 	return nil
 }
 
-// TODO: Get rid of stuck/expired transactions.
 func (m *Mempool) onIdle() {
 	log.
 		WithField("alloc_size", int64(m.verified.Size())/1000).
-		WithField("txs_count", m.verified.Len()).Info("process_on_idle")
+		WithField("txs_count", m.verified.Len()).
+		WithField("expired_txs", atomic.LoadUint64(&m.expiredTxs)).
+		Info("process_on_idle")
+}
+
+// reapExpiredTxs removes all transactions that have been received more than
+// txTTL ago. As nullifiers are looked up within the pool entries, deleting a
+// transaction releases its nullifiers too.
+func (m *Mempool) reapExpiredTxs(now time.Time) {
+	if m.txTTL == 0 || m.verified.Len() == 0 {
+		return
+	}
+
+	expired := make([]txHash, 0)
+
+	err := m.verified.Range(func(k txHash, t TxDesc) error {
+		if now.Sub(t.received) > m.txTTL {
+			expired = append(expired, k)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Warn("could not iterate mempool")
+	}
+
+	var count uint64
+
+	for _, k := range expired {
+		if err := m.verified.Delete(k[:]); err != nil {
+			continue
+		}
+
+		log.WithField("txid", toHex(k[:])).Trace("expired transaction")
+
+		count++
+	}
+
+	total := atomic.AddUint64(&m.expiredTxs, count)
+
+	if count > 0 {
+		log.WithField("expired_count", count).
+			WithField("expired_total", total).
+			WithField("mem_txs_len", m.verified.Len()).
+			Info("reaped expired transactions")
+	}
 }
 
 func (m *Mempool) newPool() Pool {
@@ -439,6 +676,50 @@ func (m Mempool) processGetMempoolTxsRequest(r rpcbus.Request) (interface{}, err
 	return outputTxs, err
 }
 
+// processGetTxsByShortIDsRequest looks up the verified txs matching the
+// requested short IDs, salted with the key of the compact block (see
+// message.ShortIDKey). The result is aligned with the
+// request, with nil for the IDs not found or matching more than one tx.
+// Called by P2P on rebuilding a compact block.
+func (m Mempool) processGetTxsByShortIDsRequest(r rpcbus.Request) (interface{}, error) {
+	lookup := r.Params.(message.ShortIDsLookup)
+	ids := lookup.IDs
+
+	// Count the pool keys matching each short ID
+	matches := make(map[uint64]int, len(ids))
+	for _, id := range ids {
+		matches[id] = 0
+	}
+
+	keys := make(map[uint64]txHash, len(ids))
+
+	err := m.verified.Range(func(k txHash, t TxDesc) error {
+		id := lookup.Key.ShortID(k[:])
+		if n, ok := matches[id]; ok {
+			matches[id] = n + 1
+			keys[id] = k
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]transactions.ContractCall, len(ids))
+
+	for i, id := range ids {
+		if matches[id] != 1 {
+			continue
+		}
+
+		k := keys[id]
+		txs[i] = m.verified.Get(k[:])
+	}
+
+	return txs, nil
+}
+
 // processGetMempoolTxsBySizeRequest returns a subset of verified mempool txs which
 // 1. contains only highest fee txs
 // 2. has total txs size not bigger than maxTxsSize (request param)
@@ -547,6 +828,11 @@ func (m *Mempool) RequestUpdates() {
 // OnClose performs mempool cleanup procedure. It's called on canceling mempool
 // context.
 func (m *Mempool) OnClose() {
+	// Persist the pool content to be reloaded on next start-up.
+	if err := m.snapshot(); err != nil {
+		log.WithError(err).Warn("failed to persist mempool snapshot")
+	}
+
 	// Closing diskpool backend commits changes to file and close it.
 	m.verified.Close()
 }
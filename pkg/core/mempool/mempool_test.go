@@ -9,8 +9,10 @@ package mempool
 import (
 	"bytes"
 	"context"
+	"errors"
 	"math"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -19,8 +21,10 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/inventory"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
@@ -217,6 +221,369 @@ func TestRemoveAccepted(t *testing.T) {
 	}
 }
 
+func TestEvictLowestFee(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, bus, _, _ := startMempoolTest(ctx)
+
+	evictedChan := make(chan message.Message, 10)
+	bus.Subscribe(topics.EvictedTx, eventbus.NewChanListener(evictedChan))
+
+	// Fill up the pool (MaxSizeMB = 1)
+	low := transactions.MockTxWithFee(10)
+	mid := transactions.MockTxWithFee(20)
+	high := transactions.MockTxWithFee(30)
+
+	assert.NoError(m.verified.Put(TxDesc{tx: low, size: 400_000}))
+	assert.NoError(m.verified.Put(TxDesc{tx: mid, size: 400_000}))
+	assert.NoError(m.verified.Put(TxDesc{tx: high, size: 200_000}))
+
+	// A better-paying tx evicts the lowest-fee one only
+	assert.NoError(m.makeRoom(TxDesc{size: 300_000}, 25))
+
+	lowHash, _ := low.CalculateHash()
+	midHash, _ := mid.CalculateHash()
+	highHash, _ := high.CalculateHash()
+
+	assert.False(m.verified.Contain(lowHash))
+	assert.True(m.verified.Contain(midHash))
+	assert.True(m.verified.Contain(highHash))
+
+	select {
+	case msg := <-evictedChan:
+		evictedHash, err := msg.Payload().(transactions.ContractCall).CalculateHash()
+		assert.NoError(err)
+		assert.Equal(lowHash, evictedHash)
+	case <-time.After(1 * time.Second):
+		t.Fatal("evicted tx not published")
+	}
+
+	// A tx paying less than all pool entries cannot make room
+	assert.Equal(ErrMempoolFull, m.makeRoom(TxDesc{size: 700_000}, 15))
+	assert.True(m.verified.Contain(midHash))
+	assert.True(m.verified.Contain(highHash))
+}
+
+// Test that a tx not paying enough to make room is dropped before being
+// verified.
+func TestFullPoolBeforeVerification(t *testing.T) {
+	assert := assert.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := r
+	c.Mempool.MinFee = 1
+	c.Mempool.FeeFloorMultiplier = 1
+	config.Mock(&c)
+
+	bus := eventbus.New()
+	_, db := lite.CreateDBConnection()
+	prober := &rejectingProber{rejected: make(map[txHash]bool)}
+
+	m := NewMempool(db, bus, rpcbus.New(), prober)
+	assert.NoError(m.verified.Put(TxDesc{tx: transactions.MockTxWithFee(20), size: 1_000_000}))
+
+	tx := transactions.MockTxWithFee(15)
+
+	hash, err := tx.CalculateHash()
+	assert.NoError(err)
+
+	var k txHash
+	copy(k[:], hash)
+
+	// The verification would fail
+	prober.rejected[k] = true
+
+	_, err = m.ProcessTx("", message.New(topics.Tx, tx))
+	assert.Equal(ErrMempoolFull, err)
+}
+
+// prunedDB is a database whose txs are all in pruned blocks.
+type prunedDB struct {
+	database.DB
+}
+
+func (d prunedDB) View(fn func(t database.Transaction) error) error {
+	return d.DB.View(func(t database.Transaction) error {
+		return fn(prunedTxs{t})
+	})
+}
+
+type prunedTxs struct {
+	database.Transaction
+}
+
+func (prunedTxs) FetchBlockTxByHash(txID []byte) (transactions.ContractCall, uint32, []byte, error) {
+	return nil, 0, nil, database.ErrBlockPruned
+}
+
+// Test that a tx of a pruned block is known to the blockchain.
+func TestPrunedTxAlreadyExists(t *testing.T) {
+	_, db := lite.CreateDBConnection()
+
+	m := NewMempool(prunedDB{db}, eventbus.New(), rpcbus.New(), (&transactions.MockProxy{}).Prober())
+
+	_, err := m.ProcessTx("", message.New(topics.Tx, transactions.MockTxWithFee(10)))
+	assert.Equal(t, ErrAlreadyExistsInBlockchain, err)
+}
+
+func TestMinFeeFloor(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, _, _, _ := startMempoolTest(ctx)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := r
+	c.Mempool.MinFee = 10
+	c.Mempool.FeeFloorThreshold = 50
+	c.Mempool.FeeFloorMultiplier = 3
+	config.Mock(&c)
+
+	// Empty pool applies the base floor
+	assert.Equal(uint64(10), m.minFee())
+
+	_, err := m.ProcessTx("", message.New(topics.Tx, transactions.MockTxWithFee(5)))
+	assert.Equal(ErrFeeTooLow, err)
+
+	_, err = m.ProcessTx("", message.New(topics.Tx, transactions.MockTxWithFee(10)))
+	assert.NoError(err)
+
+	// The floor rises linearly above the threshold
+	assert.NoError(m.verified.Put(TxDesc{tx: transactions.MockTxWithFee(50), size: 750_000}))
+	assert.Equal(uint64(20), m.minFee())
+}
+
+func TestReapExpiredTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	bus := eventbus.New()
+	_, db := lite.CreateDBConnection()
+
+	m := NewMempool(db, bus, rpcbus.New(), (&transactions.MockProxy{}).Prober())
+	m.txTTL = time.Hour
+
+	now := time.Now()
+
+	expired := transactions.MockTxWithFee(10)
+	fresh := transactions.MockTxWithFee(10)
+
+	assert.NoError(m.verified.Put(TxDesc{tx: expired, received: now.Add(-2 * time.Hour)}))
+	assert.NoError(m.verified.Put(TxDesc{tx: fresh, received: now.Add(-time.Minute)}))
+
+	m.reapExpiredTxs(now)
+
+	expiredHash, _ := expired.CalculateHash()
+	freshHash, _ := fresh.CalculateHash()
+
+	assert.False(m.verified.Contain(expiredHash))
+	assert.True(m.verified.Contain(freshHash))
+	assert.Equal(uint64(1), m.expiredTxs)
+
+	// Nullifiers of the expired tx are released
+	d, err := expired.(*transactions.Transaction).Decode()
+	assert.NoError(err)
+
+	found, _ := m.verified.ContainAnyNullifiers(d.Nullifiers)
+	assert.False(found)
+}
+
+// rejectingProber fails verification of the transactions in its set.
+type rejectingProber struct {
+	lock     sync.RWMutex
+	rejected map[txHash]bool
+	// latency of each verification.
+	latency time.Duration
+}
+
+func (p *rejectingProber) Preverify(ctx context.Context, tx transactions.ContractCall) ([]byte, transactions.Fee, error) {
+	time.Sleep(p.latency)
+
+	hash, _ := tx.CalculateHash()
+
+	var k txHash
+	copy(k[:], hash)
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.rejected[k] {
+		return nil, transactions.Fee{}, errors.New("invalid transaction")
+	}
+
+	return hash, transactions.Fee{}, nil
+}
+
+func TestRevalidateOnBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := r
+	c.Mempool.Revalidation.Enabled = true
+	c.Mempool.Revalidation.BatchSize = 3
+	c.Mempool.Revalidation.Workers = 2
+	config.Mock(&c)
+
+	bus := eventbus.New()
+	_, db := lite.CreateDBConnection()
+	prober := &rejectingProber{rejected: make(map[txHash]bool)}
+
+	m := NewMempool(db, bus, rpcbus.New(), prober)
+	m.Run(ctx)
+
+	txs := transactions.RandContractCalls(10, 0, false)
+	for _, tx := range txs {
+		_, err := m.ProcessTx("", message.New(topics.Tx, tx))
+		assert.NoError(err)
+	}
+
+	// Invalidate each 2nd tx as if the new state conflicts with it
+	prober.lock.Lock()
+
+	for i, tx := range txs {
+		if i%2 == 0 {
+			hash, _ := tx.CalculateHash()
+
+			var k txHash
+			copy(k[:], hash)
+			prober.rejected[k] = true
+		}
+	}
+
+	prober.lock.Unlock()
+
+	m.onBlock(*helper.RandomBlock(200, 0))
+
+	// Revalidation runs in the background
+	assert.Eventually(func() bool {
+		return m.verified.Len() == len(txs)/2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for i, tx := range txs {
+		hash, _ := tx.CalculateHash()
+		assert.Equal(i%2 != 0, m.verified.Contain(hash))
+	}
+}
+
+// Test that a revalidation cut short by its time budget is resumed by the
+// next one, so that the lowest fee txs are checked too.
+func TestRevalidationResumed(t *testing.T) {
+	assert := assert.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := r
+	c.Mempool.Revalidation.Enabled = true
+	c.Mempool.Revalidation.BatchSize = 1
+	c.Mempool.Revalidation.Workers = 1
+	c.Mempool.Revalidation.MaxDuration = "100ms"
+	config.Mock(&c)
+
+	_, db := lite.CreateDBConnection()
+	prober := &rejectingProber{rejected: make(map[txHash]bool)}
+
+	m := NewMempool(db, eventbus.New(), rpcbus.New(), prober)
+
+	for fee := uint64(1); fee <= 20; fee++ {
+		assert.NoError(m.verified.Put(TxDesc{tx: transactions.MockTxWithFee(fee), size: 100}))
+	}
+
+	// Only the lowest fee tx, the last one in the fee order, is invalidated
+	var k txHash
+
+	err := m.verified.RangeSort(func(h txHash, _ TxDesc) (bool, error) {
+		k = h
+		return false, nil
+	})
+	assert.NoError(err)
+
+	prober.rejected[k] = true
+	prober.latency = 10 * time.Millisecond
+
+	// A single revalidation does not reach the end of the pool
+	m.revalidate(context.Background())
+	assert.True(m.verified.Contain(k[:]))
+
+	for i := 0; i < 20 && m.verified.Contain(k[:]); i++ {
+		m.revalidate(context.Background())
+	}
+
+	assert.False(m.verified.Contain(k[:]))
+	assert.Equal(19, m.verified.Len())
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	assert := assert.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := r
+	c.Mempool.SnapshotFile = filepath.Join(t.TempDir(), "mempool.snapshot")
+	config.Mock(&c)
+
+	_, db := lite.CreateDBConnection()
+	prober := (&transactions.MockProxy{}).Prober()
+
+	m := NewMempool(db, eventbus.New(), rpcbus.New(), prober)
+
+	txs := transactions.RandContractCalls(3, 0, false)
+	for _, tx := range txs {
+		_, err := m.ProcessTx("", message.New(topics.Tx, tx))
+		assert.NoError(err)
+	}
+
+	// Persist the pool on closing
+	m.OnClose()
+
+	// Meanwhile, the first tx gets included in the chain
+	b := helper.RandomBlock(200, 0)
+	b.Txs = []transactions.ContractCall{txs[0]}
+
+	assert.NoError(db.Update(func(t database.Transaction) error {
+		return t.StoreBlock(b, false)
+	}))
+
+	// Nothing is verified before running
+	m = NewMempool(db, eventbus.New(), rpcbus.New(), prober)
+	assert.Equal(0, m.verified.Len())
+
+	// A snapshot keeps the txs not restored yet
+	assert.NoError(m.snapshot())
+
+	persisted, err := loadSnapshot(c.Mempool.SnapshotFile)
+	assert.NoError(err)
+	assert.Len(persisted, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m.Run(ctx)
+
+	assert.Eventually(func() bool {
+		return m.verified.Len() == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	for i, tx := range txs {
+		hash, _ := tx.CalculateHash()
+		assert.Equal(i != 0, m.verified.Contain(hash))
+	}
+}
+
 func BenchmarkProcessTx_0(b *testing.B) {
 	// Recent result
 	// BenchmarkProcessTx_0-8             50475             33671 ns/op
@@ -278,3 +645,146 @@ func benchmarkProcessTx(b *testing.B, batchCount int, verifyTransactionLatency t
 		b.Fatalf("not all txs accepted %d - %d", len(txs), m.verified.Len())
 	}
 }
+
+func TestGetTxsByShortIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, _, rpcBus, _ := startMempoolTest(ctx)
+
+	cc := transactions.RandContractCalls(3, 0, false)
+	ids := make([]uint64, 0, len(cc)+1)
+
+	for _, tx := range cc[:2] {
+		_, err := m.ProcessTx("", message.New(topics.Tx, tx))
+		assert.NoError(err)
+	}
+
+	key := message.NewShortIDKey(make([]byte, 32), 1)
+
+	for _, tx := range cc {
+		hash, _ := tx.CalculateHash()
+		ids = append(ids, key.ShortID(hash))
+	}
+
+	lookup := message.ShortIDsLookup{Key: key, IDs: ids}
+
+	resp, err := rpcBus.Call(topics.GetMempoolTxsByShortIDs, rpcbus.NewRequest(lookup), 5*time.Second)
+	assert.NoError(err)
+
+	txs := resp.([]transactions.ContractCall)
+	assert.Len(txs, 3)
+
+	// The last tx is not in the mempool
+	for i, tx := range txs[:2] {
+		hash, _ := tx.CalculateHash()
+		expected, _ := cc[i].CalculateHash()
+		assert.Equal(expected, hash)
+	}
+
+	assert.Nil(txs[2])
+}
+
+func receive(t *testing.T, msgChan <-chan message.Message) message.Message {
+	select {
+	case msg := <-msgChan:
+		return msg
+	case <-time.After(1 * time.Second):
+		t.Fatal("inv not published")
+	}
+
+	return nil
+}
+
+// readInv returns the tx hashes of an Inv message.
+func readInv(t *testing.T, msg message.Message) [][]byte {
+	buf := msg.Payload().(message.SafeBuffer).Buffer
+
+	topic, err := topics.Extract(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, topics.Inv, topic)
+
+	inv := &message.Inv{}
+	assert.NoError(t, inv.Decode(&buf))
+
+	hashes := make([][]byte, len(inv.InvList))
+	for i, obj := range inv.InvList {
+		assert.Equal(t, message.InvTypeMempoolTx, obj.Type)
+		hashes[i] = obj.Hash
+	}
+
+	return hashes
+}
+
+// Test that the tx hashes are announced to the relay peers not knowing them,
+// and to random nodes while few relay peers are known.
+func TestAnnounceTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	cfg := r
+	cfg.Network.TxRelay.Window = "1h"
+	cfg.Network.TxRelay.MaxInvItems = 2
+	config.Mock(&cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bus := eventbus.New()
+	_, db := lite.CreateDBConnection()
+
+	sendToOneChan := make(chan message.Message, 10)
+	bus.Subscribe(topics.KadcastSendToOne, eventbus.NewChanListener(sendToOneChan))
+
+	sendToManyChan := make(chan message.Message, 10)
+	bus.Subscribe(topics.KadcastSendToMany, eventbus.NewChanListener(sendToManyChan))
+
+	fullTxChan := make(chan message.Message, 10)
+	bus.Subscribe(topics.Kadcast, eventbus.NewChanListener(fullTxChan))
+
+	v := &transactions.MockProxy{}
+	m := NewMempool(db, bus, rpcbus.New(), v.Prober())
+
+	inv := inventory.New(100)
+	inv.Add("a", []byte{1})
+	m.AnnounceTxs(inv)
+	m.Run(ctx)
+
+	txs := transactions.RandContractCalls(2, 0, false)
+	hash0, _ := txs[0].CalculateHash()
+	hash1, _ := txs[1].CalculateHash()
+
+	// The first tx is received from peer b, the second is submitted locally
+	_, err := m.ProcessTx("b", message.New(topics.Tx, txs[0]))
+	assert.NoError(err)
+	_, err = m.ProcessTx("", message.New(topics.Tx, txs[1]))
+	assert.NoError(err)
+
+	announced := make(map[string][][]byte)
+
+	for i := 0; i < 2; i++ {
+		msg := receive(t, sendToOneChan)
+		announced[msg.Metadata().Source] = readInv(t, msg)
+	}
+
+	assert.ElementsMatch([][]byte{hash0, hash1}, announced["a"])
+	// Peer b is not announced the tx it sent
+	assert.Equal([][]byte{hash1}, announced["b"])
+
+	// Fewer relay peers than the fanout are known
+	assert.ElementsMatch([][]byte{hash0, hash1}, readInv(t, receive(t, sendToManyChan)))
+
+	// The txs are not broadcast in full
+	select {
+	case <-fullTxChan:
+		t.Fatal("tx broadcast in full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Announced hashes are not announced twice
+	assert.Empty(inv.Unknown("a", [][]byte{hash0, hash1}))
+}
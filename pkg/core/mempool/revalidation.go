@@ -0,0 +1,174 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package mempool
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+)
+
+const (
+	defaultRevalidationBatchSize = 100
+	defaultRevalidationWorkers   = 4
+	defaultRevalidationDuration  = 2 * time.Second
+)
+
+// revalidateLoop runs the revalidations requested on block acceptance. It
+// runs aside of the main loop, so that the rpcbus requests are served
+// meanwhile.
+func (m *Mempool) revalidateLoop(ctx context.Context) {
+	for {
+		select {
+		case <-m.revalidateChan:
+			m.revalidate(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// requestRevalidation schedules a revalidation. A request made while a
+// revalidation is pending is merged into it.
+func (m *Mempool) requestRevalidation() {
+	if !config.Get().Mempool.Revalidation.Enabled {
+		return
+	}
+
+	select {
+	case m.revalidateChan <- struct{}{}:
+	default:
+	}
+}
+
+// revalidate re-runs Preverify over the pool content against the state
+// resulting from the last accepted block. Transactions that are no longer
+// valid are dropped.
+//
+// Transactions are verified in batches with a bounded number of concurrent
+// calls. Revalidation stops once MaxDuration elapses, and the next one
+// resumes from there, so that the whole pool is eventually checked. Only a
+// pass over the whole pool starts again with the highest fee.
+func (m *Mempool) revalidate(ctx context.Context) {
+	cfg := config.Get().Mempool.Revalidation
+	if !cfg.Enabled {
+		return
+	}
+
+	batchSize := int(cfg.BatchSize)
+	if batchSize == 0 {
+		batchSize = defaultRevalidationBatchSize
+	}
+
+	workers := int(cfg.Workers)
+	if workers == 0 {
+		workers = defaultRevalidationWorkers
+	}
+
+	maxDuration := defaultRevalidationDuration
+
+	if len(cfg.MaxDuration) > 0 {
+		d, err := time.ParseDuration(cfg.MaxDuration)
+		if err != nil {
+			log.WithError(err).Warn("could not parse revalidation max duration")
+		} else {
+			maxDuration = d
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, maxDuration)
+	defer cancel()
+
+	// Snapshot the pool content, highest fee first, so that the most
+	// valuable txs are checked first. The txs admitted meanwhile have been
+	// verified against a recent state already.
+	if len(m.revalidationQueue) == 0 {
+		err := m.verified.RangeSort(func(k txHash, t TxDesc) (bool, error) {
+			m.revalidationQueue = append(m.revalidationQueue, k)
+			return false, nil
+		})
+		if err != nil {
+			log.WithError(err).Warn("could not iterate mempool")
+			return
+		}
+	}
+
+	start := time.Now()
+
+	var checked, dropped uint32
+
+	for len(m.revalidationQueue) > 0 && ctx.Err() == nil {
+		to := batchSize
+		if to > len(m.revalidationQueue) {
+			to = len(m.revalidationQueue)
+		}
+
+		var wg sync.WaitGroup
+
+		sem := make(chan struct{}, workers)
+
+		for _, k := range m.revalidationQueue[:to] {
+			// Skip the txs removed since the snapshot
+			tx := m.verified.Get(k[:])
+			if tx == nil {
+				continue
+			}
+
+			sem <- struct{}{}
+
+			wg.Add(1)
+
+			go func(tx transactions.ContractCall) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				_, _, err := m.verifier.Preverify(ctx, tx)
+
+				// Do not hold a timeout against the transaction
+				if ctx.Err() != nil {
+					return
+				}
+
+				atomic.AddUint32(&checked, 1)
+
+				if err == nil {
+					return
+				}
+
+				txid, _ := tx.CalculateHash()
+				if m.verified.Delete(txid) == nil {
+					atomic.AddUint32(&dropped, 1)
+
+					log.WithError(err).
+						WithField("txid", toHex(txid)).
+						Debug("dropped invalidated transaction")
+				}
+			}(tx)
+		}
+
+		wg.Wait()
+
+		// A batch cut short is checked again by the next revalidation
+		if ctx.Err() != nil {
+			break
+		}
+
+		m.revalidationQueue = m.revalidationQueue[to:]
+	}
+
+	log.WithField("checked", checked).
+		WithField("dropped", dropped).
+		WithField("remaining", len(m.revalidationQueue)).
+		WithField("duration", time.Since(start).Milliseconds()).
+		Info("mempool revalidation completed")
+}
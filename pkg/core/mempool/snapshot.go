@@ -0,0 +1,222 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package mempool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+)
+
+// snapshotVersion is the version of the mempool snapshot file format.
+const snapshotVersion uint8 = 1
+
+// errSnapshotVersion snapshot file is written in an unsupported format.
+var errSnapshotVersion = errors.New("unsupported snapshot version")
+
+// snapshot persists the content of the verified pool into the configured
+// snapshot file. The file is first written aside and then renamed to avoid
+// leaving a truncated snapshot behind.
+func (m *Mempool) snapshot() error {
+	path := m.snapshotFile
+	if len(path) == 0 {
+		return nil
+	}
+
+	entries := make([]TxDesc, 0, m.verified.Len())
+
+	err := m.verified.Range(func(k txHash, t TxDesc) error {
+		entries = append(entries, t)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// The transactions not restored yet are kept for the next run
+	m.restoreLock.Lock()
+	entries = append(entries, m.unrestored...)
+	m.restoreLock.Unlock()
+
+	buf := new(bytes.Buffer)
+	if err := marshalSnapshot(buf, entries); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	log.WithField("txs_count", len(entries)).
+		WithField("file", path).
+		Debug("mempool snapshot persisted")
+
+	return nil
+}
+
+// loadPersisted returns the transactions persisted by a previous node run,
+// to be restored.
+//
+// Entries found in a persistent pool (diskpool) are taken out of it, to be
+// re-processed as well instead of being trusted blindly.
+func (m *Mempool) loadPersisted() []TxDesc {
+	entries := make([]TxDesc, 0)
+
+	err := m.verified.Range(func(k txHash, t TxDesc) error {
+		entries = append(entries, t)
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Warn("could not iterate mempool")
+	}
+
+	for _, t := range entries {
+		txid, err := t.tx.CalculateHash()
+		if err == nil {
+			_ = m.verified.Delete(txid)
+		}
+	}
+
+	if path := m.snapshotFile; len(path) > 0 {
+		persisted, err := loadSnapshot(path)
+
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			log.WithError(err).WithField("file", path).Warn("could not load mempool snapshot")
+		default:
+			entries = append(entries, persisted...)
+		}
+	}
+
+	return entries
+}
+
+// restore runs the persisted transactions through processTx, so that stale or
+// already included ones are dropped. It runs aside of the main loop, and a
+// transaction is removed from the unrestored ones once processed only, so
+// that a snapshot taken meanwhile keeps it.
+func (m *Mempool) restore(ctx context.Context) {
+	var total, restored int
+
+	for ctx.Err() == nil {
+		m.restoreLock.Lock()
+		if len(m.unrestored) == 0 {
+			m.restoreLock.Unlock()
+			break
+		}
+
+		t := m.unrestored[0]
+		m.restoreLock.Unlock()
+
+		// The transport height is not persisted. Restored transactions are
+		// repropagated in the same way as the locally submitted ones.
+		t.kadHeight = math.MaxUint8
+
+		txid, err := m.processTx(t)
+		if err != nil {
+			log.WithError(err).
+				WithField("txid", toHex(txid)).
+				Debug("dropped restored transaction")
+		} else {
+			restored++
+		}
+
+		total++
+
+		m.restoreLock.Lock()
+		m.unrestored = m.unrestored[1:]
+		m.restoreLock.Unlock()
+	}
+
+	if total == 0 {
+		return
+	}
+
+	log.WithField("txs_count", total).
+		WithField("restored", restored).
+		WithField("dropped", total-restored).
+		Info("mempool restored")
+}
+
+// loadSnapshot reads all transactions from a snapshot file.
+func loadSnapshot(path string) ([]TxDesc, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalSnapshot(bytes.NewBuffer(data))
+}
+
+func marshalSnapshot(r *bytes.Buffer, entries []TxDesc) error {
+	if err := encoding.WriteUint8(r, snapshotVersion); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint32LE(r, uint32(len(entries))); err != nil {
+		return err
+	}
+
+	for i := range entries {
+		var entry bytes.Buffer
+		if err := marshalTxDesc(&entry, &entries[i]); err != nil {
+			return err
+		}
+
+		if err := encoding.WriteVarBytes(r, entry.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unmarshalSnapshot(r *bytes.Buffer) ([]TxDesc, error) {
+	var version uint8
+	if err := encoding.ReadUint8(r, &version); err != nil {
+		return nil, err
+	}
+
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", errSnapshotVersion, version)
+	}
+
+	var count uint32
+	if err := encoding.ReadUint32LE(r, &count); err != nil {
+		return nil, err
+	}
+
+	entries := make([]TxDesc, 0)
+
+	for i := uint32(0); i < count; i++ {
+		var entry []byte
+		if err := encoding.ReadVarBytes(r, &entry); err != nil {
+			return nil, err
+		}
+
+		t, err := unmarshalTxDesc(bytes.NewBuffer(entry), needFullTx)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, t)
+	}
+
+	return entries, nil
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/didip/tollbooth"
 	"github.com/didip/tollbooth/limiter"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
-	"github.com/dusk-network/dusk-blockchain/pkg/core/database/heavy"
 	"github.com/dusk-network/dusk-blockchain/pkg/gql/notifications"
 	"github.com/dusk-network/dusk-blockchain/pkg/gql/query"
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
@@ -58,12 +57,14 @@ type Server struct {
 }
 
 // NewHTTPServer instantiates a new NewHTTPServer to handle GraphQL queries.
-func NewHTTPServer(eventBus *eventbus.EventBus, rpcBus *rpcbus.RPCBus) (*Server, error) {
+// Queries are served from db, which must be the node blockchain database.
+func NewHTTPServer(eventBus *eventbus.EventBus, rpcBus *rpcbus.RPCBus, db database.DB) (*Server, error) {
 	max := float64(cfg.Get().Gql.MaxRequestLimit)
 
 	srv := Server{
 		eventBus: eventBus,
 		rpcBus:   rpcBus,
+		db:       db,
 		lmt:      tollbooth.NewLimiter(max, nil),
 	}
 
@@ -159,7 +160,6 @@ func (s *Server) EnableGraphQL(serverMux *http.ServeMux) error {
 	}
 
 	s.schema = &sc
-	_, s.db = heavy.CreateDBConnection()
 
 	return nil
 }
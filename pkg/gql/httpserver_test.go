@@ -9,14 +9,18 @@ package gql
 import (
 	"context"
 	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
 	"net/url"
 	"syscall"
 	"testing"
 	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/gql/notifications"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
@@ -100,6 +104,52 @@ func TestMultiClient(t *testing.T) {
 	}
 }
 
+// TestEvictedTxNotification ensures the txs evicted from the mempool are
+// notified to the ws clients, if enabled.
+func TestEvictedTxNotification(t *testing.T) {
+	assert := assert.New(t)
+
+	addr := "127.0.0.1:22223"
+
+	r := config.Registry{}
+	r.Gql.Network = "tcp"
+	r.Gql.Address = addr
+	r.Gql.Enabled = true
+	r.Gql.Notification.BrokersNum = 1
+	r.Gql.Notification.ClientsPerBroker = 10
+	r.Gql.Notification.EvictedTxs = true
+	r.Database.Driver = lite.DriverName
+	r.General.Network = "testnet"
+
+	s, eb, err := startServer(r)
+	assert.NoError(err)
+
+	defer s.Close()
+
+	resp := make(chan string, 10)
+	assert.NoError(createClient(addr, resp, false))
+
+	// Give the broker the time to register the client
+	time.Sleep(100 * time.Millisecond)
+
+	// Simulate the mempool evicting a tx
+	tx := transactions.MockTxWithFee(10)
+	eb.Publish(topics.EvictedTx, message.New(topics.EvictedTx, tx))
+
+	txid, err := tx.CalculateHash()
+	assert.NoError(err)
+
+	expected, err := json.Marshal(notifications.EvictedTxMsg{Type: "evictedtx", TxID: hex.EncodeToString(txid), Fee: 10})
+	assert.NoError(err)
+
+	select {
+	case msg := <-resp:
+		assert.JSONEq(string(expected), msg)
+	case <-time.After(5 * time.Second):
+		assert.Fail("evicted tx not notified")
+	}
+}
+
 func createClient(addr string, resp chan string, enableTLS bool) error {
 	dialCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -149,12 +199,19 @@ func createServer(addr string, brokerNum, clientsPerBroker uint, enableTLS bool)
 	r.Gql.Notification.ClientsPerBroker = clientsPerBroker
 	r.Database.Driver = lite.DriverName
 	r.General.Network = "testnet"
+
+	return startServer(r)
+}
+
+func startServer(r config.Registry) (*Server, *eventbus.EventBus, error) {
 	config.Mock(&r)
 
 	eb := eventbus.New()
 	rpcBus := rpcbus.New()
 
-	s, err := NewHTTPServer(eb, rpcBus)
+	_, db := lite.CreateDBConnection()
+
+	s, err := NewHTTPServer(eb, rpcBus, db)
 	if err != nil {
 		return nil, nil, err
 	}
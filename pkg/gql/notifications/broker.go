@@ -10,8 +10,11 @@ import (
 	"container/list"
 	"time"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
 	"github.com/sirupsen/logrus"
@@ -51,6 +54,9 @@ type Broker struct {
 	eventBus          eventbus.Broker
 	acceptedBlockChan chan block.Block
 	acceptedBlockID   uint32
+	// evictedTxChan is nil unless the evicted txs are notified.
+	evictedTxChan chan message.Message
+	evictedTxID   uint32
 }
 
 // NewBroker creates a new Broker instance.
@@ -59,6 +65,12 @@ func NewBroker(id uint, eventBus eventbus.Broker, maxClientsCount uint, connChan
 	b.eventBus = eventBus
 	b.ConnectionChan = connChan
 	b.acceptedBlockChan, b.acceptedBlockID = consensus.InitAcceptedBlockUpdate(eventBus)
+
+	if config.Get().Gql.Notification.EvictedTxs {
+		b.evictedTxChan = make(chan message.Message, 100)
+		b.evictedTxID = eventBus.Subscribe(topics.EvictedTx, eventbus.NewChanListener(b.evictedTxChan))
+	}
+
 	b.clients = list.New()
 	b.maxClientsCount = maxClientsCount
 	b.id = id
@@ -78,6 +90,10 @@ func (b *Broker) Run() {
 		// Unsubscribe from all eventBus events.
 		b.eventBus.Unsubscribe(topics.AcceptedBlock, b.acceptedBlockID)
 
+		if b.evictedTxChan != nil {
+			b.eventBus.Unsubscribe(topics.EvictedTx, b.evictedTxID)
+		}
+
 		// Terminate all clients goroutines.
 		for e := b.clients.Front(); e != nil; e = e.Next() {
 			c := e.Value.(*wsClient)
@@ -102,6 +118,9 @@ func (b *Broker) Run() {
 		// new accepted block from node
 		case blk := <-b.acceptedBlockChan:
 			b.handleBlock(blk)
+		// tx evicted from the mempool
+		case m := <-b.evictedTxChan:
+			b.handleEvictedTx(m.Payload().(transactions.ContractCall))
 		case <-time.After(30 * time.Second):
 			b.handleIdle()
 		}
@@ -127,6 +146,26 @@ func (b *Broker) handleBlock(blk block.Block) {
 	b.broadcastMessage(msg)
 }
 
+// handleEvictedTx handles the topics.EvictedTx event emitted by the mempool.
+// It packs a json from the tx and broadcast it to all active clients.
+func (b *Broker) handleEvictedTx(tx transactions.ContractCall) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("handleEvictedTx recovered from err: %v", r)
+		}
+	}()
+
+	b.reap()
+
+	msg, err := MarshalEvictedTxMsg(tx)
+	if err != nil {
+		log.Errorf("encoding err: %v", err)
+		return
+	}
+
+	b.broadcastMessage(msg)
+}
+
 // handleConn handles a new websocket conn pushed from webserver layer It stores
 // the conn to list of active clients.
 func (b *Broker) handleConn(conn wsConn) {
@@ -11,8 +11,12 @@ import (
 	"encoding/json"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 )
 
+// evictedTxType is the Type of the EvictedTxMsg.
+const evictedTxType = "evictedtx"
+
 // BlockMsg represents the data need by Explorer UI on each new block accepted.
 type BlockMsg struct {
 	Height    uint64
@@ -58,3 +62,33 @@ func MarshalBlockMsg(blk block.Block) (string, error) {
 
 	return string(msg), nil
 }
+
+// EvictedTxMsg represents a tx evicted from the mempool to make room for a
+// better paying one.
+type EvictedTxMsg struct {
+	Type string
+	TxID string
+	Fee  uint64
+}
+
+// MarshalEvictedTxMsg builds the JSON of an evicted tx.
+func MarshalEvictedTxMsg(tx transactions.ContractCall) (string, error) {
+	txid, err := tx.CalculateHash()
+	if err != nil {
+		return "", err
+	}
+
+	fee, err := tx.Fee()
+	if err != nil {
+		return "", err
+	}
+
+	p := EvictedTxMsg{Type: evictedTxType, TxID: hex.EncodeToString(txid), Fee: fee}
+
+	msg, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+
+	return string(msg), nil
+}
@@ -71,7 +71,10 @@ func newQueryBlock(b *block.Block) queryBlock {
 
 // File purpose is to define all arguments and resolvers relevant to "blocks" query only.
 
-type blocks struct{}
+type blocks struct {
+	// counter caches the totalCount of the connections.
+	counter *counter
+}
 
 func (b blocks) getQuery() *graphql.Field {
 	return &graphql.Field{
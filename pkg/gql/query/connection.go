@@ -0,0 +1,576 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package query
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	core "github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/graphql-go/graphql"
+)
+
+// File purpose is to define the Relay-style connections of blocks and
+// transactions. Both walk the chain from the tip down to genesis, resuming
+// after the position encoded in the `after` cursor.
+
+const (
+	connFirstArg       = "first"
+	connAfterArg       = "after"
+	connGeneratorArg   = "generator"
+	connTxTypeArg      = "txtype"
+	connMinGasSpentArg = "mingasspent"
+	connMaxGasSpentArg = "maxgasspent"
+
+	// Default and max number of edges of a single page.
+	connDefaultFirst = 20
+	connMaxFirst     = 1000
+
+	// connScanLimit caps the number of blocks walked to fill a single page.
+	// If reached, the page is returned with the end cursor pointing at the
+	// last walked block.
+	connScanLimit = txsBlocksFetchLimit
+
+	blockCursorPrefix = "block:"
+	txCursorPrefix    = "tx:"
+)
+
+type (
+	// position of a node in the chain. txIndex is unused by block cursors.
+	position struct {
+		height  uint64
+		txIndex uint32
+	}
+
+	pageInfo struct {
+		HasNextPage     bool
+		HasPreviousPage bool
+		StartCursor     string
+		EndCursor       string
+	}
+
+	edge struct {
+		Node   interface{}
+		Cursor string
+	}
+
+	// connection is the source of both BlockConnection and
+	// TransactionConnection objects.
+	connection struct {
+		Edges    []edge
+		PageInfo pageInfo
+
+		// count returns the number of nodes matching the filters, walking
+		// the chain if needed, and whether the count is complete. It is
+		// called only if totalCount is selected, once per connection.
+		count func() (int, bool, error)
+
+		counted  bool
+		total    int
+		complete bool
+		countErr error
+	}
+
+	// txFilter holds the filters applicable to a transactions connection.
+	txFilter struct {
+		txType      *core.TxType
+		minGasSpent uint64
+		maxGasSpent uint64
+		generator   []byte
+	}
+)
+
+func blockCursor(height uint64) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s%d", blockCursorPrefix, height)))
+}
+
+func txCursor(pos position) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s%d:%d", txCursorPrefix, pos.height, pos.txIndex)))
+}
+
+func decodeCursor(cursor, prefix string) (*position, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !bytes.HasPrefix(raw, []byte(prefix)) {
+		return nil, errors.New("invalid cursor")
+	}
+
+	pos := new(position)
+
+	switch prefix {
+	case blockCursorPrefix:
+		_, err = fmt.Sscanf(string(raw[len(prefix):]), "%d", &pos.height)
+	default:
+		_, err = fmt.Sscanf(string(raw[len(prefix):]), "%d:%d", &pos.height, &pos.txIndex)
+	}
+
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return pos, nil
+}
+
+// connectionArgs reads the pagination arguments.
+func connectionArgs(args map[string]interface{}, cursorPrefix string) (int, *position, error) {
+	first, ok := args[connFirstArg].(int)
+	if !ok {
+		first = connDefaultFirst
+	}
+
+	if first <= 0 || first > connMaxFirst {
+		return 0, nil, fmt.Errorf("``%s`` argument must be in range [1, %d]", connFirstArg, connMaxFirst)
+	}
+
+	cursor, ok := args[connAfterArg].(string)
+	if !ok {
+		return first, nil, nil
+	}
+
+	after, err := decodeCursor(cursor, cursorPrefix)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return first, after, nil
+}
+
+func generatorArg(args map[string]interface{}) ([]byte, error) {
+	generator, ok := args[connGeneratorArg].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	return hex.DecodeString(generator)
+}
+
+func newTxFilter(args map[string]interface{}) (txFilter, error) {
+	f := txFilter{maxGasSpent: math.MaxUint64}
+
+	var err error
+	if f.generator, err = generatorArg(args); err != nil {
+		return f, err
+	}
+
+	if txType, ok := args[connTxTypeArg].(int); ok {
+		if txType < 0 {
+			return f, errors.New("invalid ``" + connTxTypeArg + "`` argument")
+		}
+
+		t := core.TxType(txType)
+		f.txType = &t
+	}
+
+	if min, ok := args[connMinGasSpentArg].(float64); ok {
+		if min < 0 {
+			return f, errors.New("invalid ``" + connMinGasSpentArg + "`` argument")
+		}
+
+		f.minGasSpent = uint64(min)
+	}
+
+	if max, ok := args[connMaxGasSpentArg].(float64); ok {
+		if max < 0 {
+			return f, errors.New("invalid ``" + connMaxGasSpentArg + "`` argument")
+		}
+
+		f.maxGasSpent = uint64(max)
+	}
+
+	return f, nil
+}
+
+// key identifies the filter in the count cache.
+func (f txFilter) key() string {
+	txType := "any"
+	if f.txType != nil {
+		txType = fmt.Sprintf("%d", *f.txType)
+	}
+
+	return fmt.Sprintf("%s%x:%s:%d:%d", txCursorPrefix, f.generator, txType, f.minGasSpent, f.maxGasSpent)
+}
+
+func (f txFilter) matchBlock(header *block.Header) bool {
+	return f.generator == nil || bytes.Equal(header.GeneratorBlsPubkey, f.generator)
+}
+
+func (f txFilter) matchTx(tx core.ContractCall) bool {
+	if f.txType != nil && tx.Type() != *f.txType {
+		return false
+	}
+
+	gasSpent := tx.GasSpent()
+	return gasSpent >= f.minGasSpent && gasSpent <= f.maxGasSpent
+}
+
+// walkChain calls visit on each block from the specified height down to
+// genesis, until visit returns false or connScanLimit blocks are walked. It
+// returns the height of the last walked block and true if the walk is
+// interrupted by the scan limit.
+func walkChain(t database.Transaction, from uint64, visit func(height uint64, header *block.Header) (bool, error)) (uint64, bool, error) {
+	height := from
+
+	for scanned := 1; ; scanned++ {
+		hash, err := t.FetchBlockHashByHeight(height)
+		if err != nil {
+			return height, false, err
+		}
+
+		header, err := t.FetchBlockHeader(hash)
+		if err != nil {
+			return height, false, err
+		}
+
+		more, err := visit(height, header)
+		if err != nil || !more || height == 0 {
+			return height, false, err
+		}
+
+		if scanned == connScanLimit {
+			return height, true, nil
+		}
+
+		height--
+	}
+}
+
+// startHeight returns the height to walk the chain from. False is returned if
+// the cursor points at genesis, thus no more nodes are available.
+func startHeight(t database.Transaction, after *position, includeAfter bool) (uint64, bool, error) {
+	tip, err := t.FetchCurrentHeight()
+	if err != nil {
+		return 0, false, err
+	}
+
+	if after == nil || after.height > tip {
+		return tip, true, nil
+	}
+
+	if includeAfter {
+		return after.height, true, nil
+	}
+
+	if after.height == 0 {
+		return 0, false, nil
+	}
+
+	return after.height - 1, true, nil
+}
+
+func (c *connection) finalize(after *position) {
+	c.PageInfo.HasPreviousPage = after != nil
+
+	if len(c.Edges) > 0 {
+		c.PageInfo.StartCursor = c.Edges[0].Cursor
+
+		if c.PageInfo.EndCursor == "" {
+			c.PageInfo.EndCursor = c.Edges[len(c.Edges)-1].Cursor
+		}
+	}
+}
+
+// totalCount returns the count of the nodes, or a lower bound of it if the
+// chain is not walked completely yet, and whether the count is complete.
+func (c *connection) totalCount() (int, bool, error) {
+	if !c.counted {
+		c.total, c.complete, c.countErr = c.count()
+		c.counted = true
+	}
+
+	return c.total, c.complete, c.countErr
+}
+
+func resolveTotalCount(p graphql.ResolveParams) (interface{}, error) {
+	c, ok := p.Source.(*connection)
+	if !ok {
+		return nil, errors.New("invalid source connection")
+	}
+
+	total, _, err := c.totalCount()
+	return total, err
+}
+
+func resolveTotalCountComplete(p graphql.ResolveParams) (interface{}, error) {
+	c, ok := p.Source.(*connection)
+	if !ok {
+		return nil, errors.New("invalid source connection")
+	}
+
+	_, complete, err := c.totalCount()
+	return complete, err
+}
+
+func (b blocks) getConnectionQuery() *graphql.Field {
+	return &graphql.Field{
+		Type: BlockConnection,
+		Args: graphql.FieldConfigArgument{
+			connFirstArg: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			connAfterArg: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			connGeneratorArg: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+		},
+		Resolve: b.resolveConnection,
+	}
+}
+
+func (t transactions) getConnectionQuery() *graphql.Field {
+	return &graphql.Field{
+		Type: TransactionConnection,
+		Args: graphql.FieldConfigArgument{
+			connFirstArg: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			connAfterArg: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			connGeneratorArg: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			connTxTypeArg: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			connMinGasSpentArg: &graphql.ArgumentConfig{
+				Type: graphql.Float,
+			},
+			connMaxGasSpentArg: &graphql.ArgumentConfig{
+				Type: graphql.Float,
+			},
+		},
+		Resolve: t.resolveConnection,
+	}
+}
+
+// resolveConnection resolves the `blocksConnection` query, most recent block
+// first.
+func (b blocks) resolveConnection(p graphql.ResolveParams) (interface{}, error) {
+	db, ok := p.Context.Value("database").(database.DB)
+	if !ok {
+		return nil, errors.New("context does not store database conn")
+	}
+
+	first, after, err := connectionArgs(p.Args, blockCursorPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	generator, err := generatorArg(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	match := func(header *block.Header) bool {
+		return generator == nil || bytes.Equal(header.GeneratorBlsPubkey, generator)
+	}
+
+	c := &connection{
+		Edges: make([]edge, 0),
+		count: func() (int, bool, error) {
+			return b.countBlocks(db, generator, match)
+		},
+	}
+
+	err = db.View(func(t database.Transaction) error {
+		from, ok, err := startHeight(t, after, false)
+		if err != nil || !ok {
+			return err
+		}
+
+		last, limited, err := walkChain(t, from, func(height uint64, header *block.Header) (bool, error) {
+			if !match(header) {
+				return true, nil
+			}
+
+			if len(c.Edges) == first {
+				c.PageInfo.HasNextPage = true
+				return false, nil
+			}
+
+			c.Edges = append(c.Edges, edge{
+				Node:   newQueryBlock(&block.Block{Header: header}),
+				Cursor: blockCursor(height),
+			})
+
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if limited {
+			c.PageInfo.HasNextPage = true
+			c.PageInfo.EndCursor = blockCursor(last)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.finalize(after)
+	return c, nil
+}
+
+// countBlocks returns the number of blocks of the generator, or the chain
+// height if none is specified.
+func (b blocks) countBlocks(db database.DB, generator []byte, match func(*block.Header) bool) (int, bool, error) {
+	if generator == nil {
+		var count int
+
+		err := db.View(func(t database.Transaction) error {
+			tip, err := t.FetchCurrentHeight()
+			count = int(tip) + 1
+			return err
+		})
+
+		return count, err == nil, err
+	}
+
+	key := blockCursorPrefix + hex.EncodeToString(generator)
+
+	return b.counter.count(db, key, func(t database.Transaction, header *block.Header) (int, bool, error) {
+		if match(header) {
+			return 1, true, nil
+		}
+
+		return 0, true, nil
+	})
+}
+
+// resolveConnection resolves the `transactionsConnection` query, most recent
+// transaction first.
+func (t transactions) resolveConnection(p graphql.ResolveParams) (interface{}, error) {
+	db, ok := p.Context.Value("database").(database.DB)
+	if !ok {
+		return nil, errors.New("context does not store database conn")
+	}
+
+	first, after, err := connectionArgs(p.Args, txCursorPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newTxFilter(p.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &connection{
+		Edges: make([]edge, 0),
+		count: func() (int, bool, error) {
+			return t.countTxs(db, filter)
+		},
+	}
+
+	err = db.View(func(tr database.Transaction) error {
+		from, ok, err := startHeight(tr, after, true)
+		if err != nil || !ok {
+			return err
+		}
+
+		last, limited, err := walkChain(tr, from, func(height uint64, header *block.Header) (bool, error) {
+			if !filter.matchBlock(header) {
+				return true, nil
+			}
+
+			txs, err := tr.FetchBlockTxs(header.Hash)
+			if err == database.ErrBlockPruned {
+				// All older blocks are pruned as well
+				return false, nil
+			}
+
+			if err != nil {
+				return false, err
+			}
+
+			i := len(txs) - 1
+			if after != nil && height == after.height {
+				// Resume right after the cursor tx
+				i = int(after.txIndex) - 1
+			}
+
+			for ; i >= 0; i-- {
+				if !filter.matchTx(txs[i]) {
+					continue
+				}
+
+				d, err := newQueryTx(txs[i], header.Hash, header.Timestamp)
+				if err != nil {
+					continue
+				}
+
+				if len(c.Edges) == first {
+					c.PageInfo.HasNextPage = true
+					return false, nil
+				}
+
+				c.Edges = append(c.Edges, edge{
+					Node:   d,
+					Cursor: txCursor(position{height: height, txIndex: uint32(i)}),
+				})
+			}
+
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if limited {
+			c.PageInfo.HasNextPage = true
+			c.PageInfo.EndCursor = txCursor(position{height: last, txIndex: 0})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.finalize(after)
+	return c, nil
+}
+
+// countTxs returns the number of txs matching the filter, down to the highest
+// pruned block.
+func (t transactions) countTxs(db database.DB, filter txFilter) (int, bool, error) {
+	return t.counter.count(db, filter.key(), func(tr database.Transaction, header *block.Header) (int, bool, error) {
+		if !filter.matchBlock(header) {
+			return 0, true, nil
+		}
+
+		txs, err := tr.FetchBlockTxs(header.Hash)
+		if err == database.ErrBlockPruned {
+			return 0, false, nil
+		}
+
+		if err != nil {
+			return 0, false, err
+		}
+
+		count := 0
+
+		for _, tx := range txs {
+			// Txs not decodable are never returned as nodes
+			if _, err := tx.Decode(); err == nil && filter.matchTx(tx) {
+				count++
+			}
+		}
+
+		return count, true, nil
+	})
+}
@@ -0,0 +1,167 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package query
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	assert "github.com/stretchr/testify/require"
+)
+
+type testConnection struct {
+	Edges []struct {
+		Node struct {
+			TxID   string `json:"txid"`
+			Header struct {
+				Height float64 `json:"height"`
+			} `json:"header"`
+		} `json:"node"`
+		Cursor string `json:"cursor"`
+	} `json:"edges"`
+	PageInfo struct {
+		HasNextPage bool   `json:"hasNextPage"`
+		EndCursor   string `json:"endCursor"`
+	} `json:"pageInfo"`
+	TotalCount         int  `json:"totalCount"`
+	TotalCountComplete bool `json:"totalCountComplete"`
+}
+
+func queryConnection(t *testing.T, name, args, node string) testConnection {
+	query := fmt.Sprintf(`
+		{
+			%s(%s) {
+				edges { node { %s } cursor }
+				pageInfo { hasNextPage endCursor }
+				totalCount
+				totalCountComplete
+			}
+		}
+		`, name, args, node)
+
+	result := execute(query, sc, db)
+	assert.Empty(t, result.Errors)
+
+	data, err := json.Marshal(result.Data.(map[string]interface{})[name])
+	assert.NoError(t, err)
+
+	var c testConnection
+	assert.NoError(t, json.Unmarshal(data, &c))
+
+	return c
+}
+
+func TestBlocksConnection(t *testing.T) {
+	c := queryConnection(t, "blocksConnection", "first: 2", "header { height }")
+	assert.Equal(t, 2, len(c.Edges))
+	assert.Equal(t, float64(2), c.Edges[0].Node.Header.Height)
+	assert.Equal(t, float64(1), c.Edges[1].Node.Header.Height)
+	assert.True(t, c.PageInfo.HasNextPage)
+	assert.Equal(t, c.Edges[1].Cursor, c.PageInfo.EndCursor)
+	assert.Equal(t, 3, c.TotalCount)
+	assert.True(t, c.TotalCountComplete)
+
+	c = queryConnection(t, "blocksConnection", fmt.Sprintf(`first: 2, after: "%s"`, c.PageInfo.EndCursor), "header { height }")
+	assert.Equal(t, 1, len(c.Edges))
+	assert.Equal(t, float64(0), c.Edges[0].Node.Header.Height)
+	assert.False(t, c.PageInfo.HasNextPage)
+}
+
+func TestBlocksConnectionGenerator(t *testing.T) {
+	var generator []byte
+
+	assert.NoError(t, db.View(func(t database.Transaction) error {
+		hash, err := hex.DecodeString(block2)
+		if err != nil {
+			return err
+		}
+
+		header, err := t.FetchBlockHeader(hash)
+		if err != nil {
+			return err
+		}
+
+		generator = header.GeneratorBlsPubkey
+		return nil
+	}))
+
+	c := queryConnection(t, "blocksConnection", fmt.Sprintf(`generator: "%s"`, hex.EncodeToString(generator)), "header { height }")
+	assert.Equal(t, 1, len(c.Edges))
+	assert.Equal(t, float64(1), c.Edges[0].Node.Header.Height)
+	assert.False(t, c.PageInfo.HasNextPage)
+	assert.Equal(t, 1, c.TotalCount)
+	assert.True(t, c.TotalCountComplete)
+}
+
+func TestTransactionsConnection(t *testing.T) {
+	c := queryConnection(t, "transactionsConnection", "first: 2", "txid")
+	assert.Equal(t, 2, len(c.Edges))
+	assert.Equal(t, bid3Hash, c.Edges[0].Node.TxID)
+	assert.Equal(t, bid2Hash, c.Edges[1].Node.TxID)
+	assert.True(t, c.PageInfo.HasNextPage)
+	assert.Equal(t, 3, c.TotalCount)
+	assert.True(t, c.TotalCountComplete)
+
+	c = queryConnection(t, "transactionsConnection", fmt.Sprintf(`first: 2, after: "%s"`, c.PageInfo.EndCursor), "txid")
+	assert.Equal(t, 1, len(c.Edges))
+	assert.Equal(t, bid1Hash, c.Edges[0].Node.TxID)
+	assert.False(t, c.PageInfo.HasNextPage)
+
+	// Filters
+	c = queryConnection(t, "transactionsConnection", "txtype: 5", "txid")
+	assert.Empty(t, c.Edges)
+	assert.Equal(t, 0, c.TotalCount)
+
+	c = queryConnection(t, "transactionsConnection", "mingasspent: 1", "txid")
+	assert.Empty(t, c.Edges)
+
+	c = queryConnection(t, "transactionsConnection", "txtype: 1, maxgasspent: 0", "txid")
+	assert.Equal(t, 3, len(c.Edges))
+}
+
+func TestConnectionInvalidCursor(t *testing.T) {
+	query := `{ transactionsConnection(after: "bm90IGEgY3Vyc29y") { totalCount } }`
+
+	result := execute(query, sc, db)
+	assert.NotEmpty(t, result.Errors)
+}
+
+func TestCounterResume(t *testing.T) {
+	c := newCounter(1)
+
+	visits := 0
+	visit := func(t database.Transaction, header *block.Header) (int, bool, error) {
+		visits++
+		return 1, true, nil
+	}
+
+	// A single block is walked per count, the count walked so far being
+	// returned as a lower bound
+	for i := 1; i <= 2; i++ {
+		count, complete, err := c.count(db, "all", visit)
+		assert.NoError(t, err)
+		assert.False(t, complete)
+		assert.Equal(t, i, count)
+	}
+
+	count, complete, err := c.count(db, "all", visit)
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 3, visits)
+
+	// The count is cached
+	count, complete, err = c.count(db, "all", visit)
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 3, visits)
+}
@@ -0,0 +1,163 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package query
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+)
+
+const (
+	// connCountLimit caps the number of blocks walked to count the nodes of a
+	// connection within a single query. A longer walk is resumed by the next
+	// queries.
+	connCountLimit = 10 * connScanLimit
+
+	// countCacheSize is the number of filters which count is cached.
+	countCacheSize = 64
+)
+
+// nodeCount is the count of the nodes matching a filter, from the block top
+// down to the block lowest.
+type nodeCount struct {
+	top     uint64
+	topHash []byte
+	lowest  uint64
+	// done is set once the walk reaches genesis or a pruned block.
+	done  bool
+	count int
+}
+
+// counter counts the nodes of the connections, caching the count of each
+// filter. A cached count is updated with the blocks accepted since, so that
+// the chain is walked once per filter.
+type counter struct {
+	lock   sync.Mutex
+	counts map[string]*nodeCount
+	limit  uint64
+}
+
+func newCounter(limit uint64) *counter {
+	return &counter{
+		counts: make(map[string]*nodeCount),
+		limit:  limit,
+	}
+}
+
+// count returns the number of nodes matching the filter identified by key.
+// The blocks are visited from the tip down, visit returning the number of
+// matching nodes of a block, and false if the older blocks are not counted.
+// Until the walk is complete, the count of the blocks walked so far is
+// returned as a lower bound, along with false.
+func (c *counter) count(db database.DB, key string, visit func(t database.Transaction, header *block.Header) (int, bool, error)) (int, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n := c.counts[key]
+
+	err := db.View(func(t database.Transaction) error {
+		tip, err := t.FetchCurrentHeight()
+		if err != nil {
+			return err
+		}
+
+		// A count out of the chain, as on a fallback, or too far behind is
+		// started over
+		if n != nil && (n.top > tip || tip-n.top > c.limit || !onChain(t, n.top, n.topHash)) {
+			n = nil
+		}
+
+		if n == nil {
+			n = &nodeCount{top: tip, lowest: tip + 1}
+
+			if n.topHash, err = t.FetchBlockHashByHeight(tip); err != nil {
+				return err
+			}
+		}
+
+		walked := uint64(0)
+
+		// Blocks accepted since the last count
+		for height := tip; height > n.top; height-- {
+			count, _, err := visitHeight(t, height, visit)
+			if err != nil {
+				return err
+			}
+
+			n.count += count
+			walked++
+		}
+
+		if n.top != tip {
+			n.top = tip
+
+			if n.topHash, err = t.FetchBlockHashByHeight(tip); err != nil {
+				return err
+			}
+		}
+
+		// Older blocks not counted yet
+		for ; !n.done && walked < c.limit; walked++ {
+			if n.lowest == 0 {
+				n.done = true
+				break
+			}
+
+			count, more, err := visitHeight(t, n.lowest-1, visit)
+			if err != nil {
+				return err
+			}
+
+			n.lowest--
+			n.count += count
+			n.done = !more || n.lowest == 0
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	c.store(key, n)
+
+	return n.count, n.done, nil
+}
+
+// store caches a count, evicting a random one if the cache is full.
+func (c *counter) store(key string, n *nodeCount) {
+	if _, ok := c.counts[key]; !ok && len(c.counts) >= countCacheSize {
+		for k := range c.counts {
+			delete(c.counts, k)
+			break
+		}
+	}
+
+	c.counts[key] = n
+}
+
+func onChain(t database.Transaction, height uint64, hash []byte) bool {
+	h, err := t.FetchBlockHashByHeight(height)
+	return err == nil && bytes.Equal(h, hash)
+}
+
+func visitHeight(t database.Transaction, height uint64, visit func(t database.Transaction, header *block.Header) (int, bool, error)) (int, bool, error) {
+	hash, err := t.FetchBlockHashByHeight(height)
+	if err != nil {
+		return 0, false, err
+	}
+
+	header, err := t.FetchBlockHeader(hash)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return visit(t, header)
+}
@@ -15,6 +15,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
 	core "github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
@@ -30,6 +31,11 @@ var (
 )
 
 func TestMain(m *testing.M) {
+	// The lookups by contract and type need the tx index
+	r := config.Get()
+	r.Database.TxIndex = true
+	config.Mock(&r)
+
 	// Setup lite DB
 	_, db = lite.CreateDBConnection()
 
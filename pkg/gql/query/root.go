@@ -19,15 +19,20 @@ type Root struct {
 // NewRoot returns a Root with blocks, transactions and mempool setup.
 func NewRoot(rpcBus *rpcbus.RPCBus) *Root {
 	m := mempool{rpcBus: rpcBus}
+	c := newCounter(connCountLimit)
+	b := blocks{counter: c}
+	t := transactions{counter: c}
 
 	root := Root{
 		Query: graphql.NewObject(
 			graphql.ObjectConfig{
 				Name: "Query",
 				Fields: graphql.Fields{
-					"blocks":       blocks{}.getQuery(),
-					"transactions": transactions{}.getQuery(),
-					"mempool":      m.getQuery(),
+					"blocks":                 b.getQuery(),
+					"blocksConnection":       b.getConnectionQuery(),
+					"transactions":           t.getQuery(),
+					"transactionsConnection": t.getConnectionQuery(),
+					"mempool":                m.getQuery(),
 				},
 			},
 		),
@@ -24,10 +24,18 @@ const (
 	txsFetchLimit       = 10000
 	txsBlocksFetchLimit = 10000
 
+	// Default page size of the lookups by contract and type.
+	txsIndexPageSize = 100
+
 	txidArg     = "txid"
 	txidsArg    = "txids"
 	txlastArg   = "last"
 	txblocksArg = "blocks"
+
+	txcontractArg = "contract"
+	txtypeArg     = "txtype"
+	txoffsetArg   = "offset"
+	txlimitArg    = "limit"
 )
 
 type (
@@ -55,7 +63,10 @@ type (
 	}
 )
 
-type transactions struct{}
+type transactions struct {
+	// counter caches the totalCount of the connections.
+	counter *counter
+}
 
 // newQueryTx constructs query tx data from core tx and block hash.
 //nolint
@@ -123,6 +134,18 @@ func (t transactions) getQuery() *graphql.Field {
 			txblocksArg: &graphql.ArgumentConfig{
 				Type: graphql.Int,
 			},
+			txcontractArg: &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			txtypeArg: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			txoffsetArg: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+			txlimitArg: &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
 		},
 		Resolve: t.resolve,
 	}
@@ -147,6 +170,27 @@ func (t transactions) resolve(p graphql.ResolveParams) (interface{}, error) {
 		return t.fetchTxsByHash(db, ids)
 	}
 
+	if contract, ok := p.Args[txcontractArg].(string); ok {
+		contractID, err := hex.DecodeString(contract)
+		if err != nil {
+			return nil, err
+		}
+
+		return t.fetchIndexedTxs(db, p.Args, func(t database.Transaction, offset, limit uint64) ([]database.IndexedTx, error) {
+			return t.FetchTxsByContract(contractID, offset, limit)
+		})
+	}
+
+	if txType, ok := p.Args[txtypeArg].(int); ok {
+		if txType < 0 {
+			return nil, errors.New("invalid ``" + txtypeArg + "`` argument")
+		}
+
+		return t.fetchIndexedTxs(db, p.Args, func(t database.Transaction, offset, limit uint64) ([]database.IndexedTx, error) {
+			return t.FetchTxsByType(core.TxType(txType), offset, limit)
+		})
+	}
+
 	count, ok := p.Args[txlastArg].(int)
 	if ok {
 		if count <= 0 {
@@ -198,6 +242,56 @@ func (t transactions) fetchTxsByHash(db database.DB, txids []interface{}) ([]que
 	return txs, err
 }
 
+// fetchIndexedTxs returns a page of txs looked up on the secondary tx index,
+// most recent first.
+func (t transactions) fetchIndexedTxs(db database.DB, args map[string]interface{}, fetch func(database.Transaction, uint64, uint64) ([]database.IndexedTx, error)) ([]queryTx, error) {
+	offset, ok := args[txoffsetArg].(int)
+	if !ok {
+		offset = 0
+	}
+
+	limit, ok := args[txlimitArg].(int)
+	if !ok {
+		limit = txsIndexPageSize
+	}
+
+	if offset < 0 || limit <= 0 {
+		return nil, errors.New("invalid ``" + txoffsetArg + "`` or ``" + txlimitArg + "`` argument")
+	}
+
+	if limit > txsFetchLimit {
+		msg := "requested txs count exceeds the limit"
+		log.WithField("txsFetchLimit", txsFetchLimit).
+			Warn(msg)
+		return nil, errors.New(msg)
+	}
+
+	txs := make([]queryTx, 0)
+
+	err := db.View(func(t database.Transaction) error {
+		indexed, err := fetch(t, uint64(offset), uint64(limit))
+		if err != nil {
+			return err
+		}
+
+		for _, i := range indexed {
+			header, err := t.FetchBlockHeader(i.BlockHash)
+			if err != nil {
+				return err
+			}
+
+			d, err := newQueryTx(i.Tx, header.Hash, header.Timestamp)
+			if err == nil {
+				txs = append(txs, d)
+			}
+		}
+
+		return nil
+	})
+
+	return txs, err
+}
+
 // Fetch `count` number of txs from lastly `maxBlocks` accepted blocks.
 func (t transactions) fetchLastTxs(db database.DB, count int, maxBlocks int) ([]queryTx, error) {
 	txs := make([]queryTx, 0)
@@ -236,6 +330,11 @@ func (t transactions) fetchLastTxs(db database.DB, count int, maxBlocks int) ([]
 			}
 
 			blockTxs, err := t.FetchBlockTxs(hash)
+			if err == database.ErrBlockPruned {
+				// All older blocks are pruned as well
+				return nil
+			}
+
 			if err != nil {
 				return err
 			}
@@ -8,6 +8,7 @@ package query
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -195,3 +196,48 @@ func TestTxSize(t *testing.T) {
 	`
 	assertQuery(t, query, response)
 }
+
+func TestTxsByType(t *testing.T) {
+	query := `
+		{
+			transactions(txtype: 1, offset: 1, limit: 2)
+			{
+				txid
+			}
+		}
+		`
+	response := fmt.Sprintf(`
+		{
+			"data": {
+				"transactions": [
+					{
+						"txid": "%s"
+					},
+					{
+						"txid": "%s"
+					}
+				]
+			}
+		}
+	`, bid2Hash, bid1Hash)
+	assertQuery(t, query, response)
+}
+
+func TestTxsByContract(t *testing.T) {
+	query := fmt.Sprintf(`
+		{
+			transactions(contract: "%s")
+			{
+				txid
+			}
+		}
+		`, strings.Repeat("aa", 32))
+	response := `
+		{
+			"data": {
+				"transactions": []
+			}
+		}
+	`
+	assertQuery(t, query, response)
+}
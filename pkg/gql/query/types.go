@@ -138,6 +138,72 @@ var ContractInfo = graphql.NewObject(
 	},
 )
 
+// PageInfo is the graphql object representing the page of a connection.
+var PageInfo = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"hasPreviousPage": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"startCursor": &graphql.Field{
+				Type: graphql.String,
+			},
+			"endCursor": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	},
+)
+
+// BlockConnection is the graphql object representing a page of blocks.
+var BlockConnection = newConnection("Block", Block)
+
+// TransactionConnection is the graphql object representing a page of
+// transactions.
+var TransactionConnection = newConnection("Transaction", Transaction)
+
+func newConnection(name string, node *graphql.Object) *graphql.Object {
+	edge := graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: name + "Edge",
+			Fields: graphql.Fields{
+				"node": &graphql.Field{
+					Type: node,
+				},
+				"cursor": &graphql.Field{
+					Type: graphql.String,
+				},
+			},
+		},
+	)
+
+	return graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: name + "Connection",
+			Fields: graphql.Fields{
+				"edges": &graphql.Field{
+					Type: graphql.NewList(edge),
+				},
+				"pageInfo": &graphql.Field{
+					Type: PageInfo,
+				},
+				"totalCount": &graphql.Field{
+					Type:    graphql.Int,
+					Resolve: resolveTotalCount,
+				},
+				"totalCountComplete": &graphql.Field{
+					Type:    graphql.Boolean,
+					Resolve: resolveTotalCountComplete,
+				},
+			},
+		},
+	)
+}
+
 // Hex is the graphql object representing a hex scalar.
 var Hex = graphql.NewScalar(graphql.ScalarConfig{
 	Name:        "Hex",
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/kadcast/routing"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/kadcast/writer"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
@@ -24,6 +25,14 @@ import (
 
 var log = logger.WithFields(logger.Fields{"process": "kadcast"})
 
+// Kadcast implementations selectable with the kadcast.routing setting.
+const (
+	// RoutingRusk relies on the Rusk network service over gRPC.
+	RoutingRusk = "rusk"
+	// RoutingNative runs the Kadcast routing in-process.
+	RoutingNative = "native"
+)
+
 // Peer is a wrapper for both kadcast grpc sides.
 type Peer struct {
 	// dusk node components
@@ -36,6 +45,7 @@ type Peer struct {
 	reader  *Reader
 
 	connections []*grpc.ClientConn
+	node        *routing.Node
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -55,50 +65,59 @@ func NewKadcastPeer(pCtx context.Context, eventBus *eventbus.EventBus, processor
 }
 
 // Launch starts kadcast peer reader and writers, binds them to the event buss,
-// and establishes connection to rusk network server.
+// and establishes connection to rusk network server, or starts the native
+// Kadcast node.
 func (p *Peer) Launch() {
-	// gRPC rusk client
 	cfg := config.Get().Kadcast
 
-	log.WithField("grpc_addr", cfg.Grpc.Address).
-		WithField("grpc_network", cfg.Grpc.Network).
-		Info("launch peer connections")
-
 	// set rusk version
 	md := metadata.New(map[string]string{"x-rusk-version": config.RuskVersion})
 	ctx := metadata.NewOutgoingContext(p.ctx, md)
 
+	var newClient func() rusk.NetworkClient
+
+	switch cfg.Routing {
+	case "", RoutingRusk:
+		// gRPC rusk client
+		log.WithField("grpc_addr", cfg.Grpc.Address).
+			WithField("grpc_network", cfg.Grpc.Network).
+			Info("launch peer connections")
+
+		newClient = func() rusk.NetworkClient {
+			client, conn := CreateNetworkClient(ctx, cfg.Grpc.Network, cfg.Grpc.Address, cfg.Grpc.DialTimeout)
+			p.connections = append(p.connections, conn)
+			return client
+		}
+	case RoutingNative:
+		p.node = CreateNativeNode()
+
+		newClient = func() rusk.NetworkClient {
+			return p.node
+		}
+	default:
+		log.WithField("routing", cfg.Routing).Panic("unsupported kadcast routing")
+	}
+
 	// initiate all writers for Kadcast messages.
-	p.createWriters(ctx)
+	p.createWriters(ctx, newClient)
 
 	// a reader for Kadcast messages
-	client, conn := CreateNetworkClient(ctx, cfg.Grpc.Network, cfg.Grpc.Address, cfg.Grpc.DialTimeout)
-	p.reader = NewReader(ctx, p.eventBus, p.gossip, p.processor, client)
-
-	p.connections = append(p.connections, conn)
+	p.reader = NewReader(ctx, p.eventBus, p.gossip, p.processor, newClient())
 
 	go p.reader.Listen()
 }
 
-func (p *Peer) createWriters(ctx context.Context) {
-	cfg := config.Get().Kadcast
-
+func (p *Peer) createWriters(ctx context.Context, newClient func() rusk.NetworkClient) {
 	// Broadcast
-	client, conn := CreateNetworkClient(ctx, cfg.Grpc.Network, cfg.Grpc.Address, cfg.Grpc.DialTimeout)
-	w := writer.NewBroadcast(ctx, p.eventBus, p.gossip, client)
-	p.connections = append(p.connections, conn)
+	w := writer.NewBroadcast(ctx, p.eventBus, p.gossip, newClient())
 	p.writers = append(p.writers, w)
 
 	// Send to One
-	client, conn = CreateNetworkClient(ctx, cfg.Grpc.Network, cfg.Grpc.Address, cfg.Grpc.DialTimeout)
-	w = writer.NewSendToOne(ctx, p.eventBus, p.gossip, client)
-	p.connections = append(p.connections, conn)
+	w = writer.NewSendToOne(ctx, p.eventBus, p.gossip, newClient())
 	p.writers = append(p.writers, w)
 
 	// Send to Many
-	client, conn = CreateNetworkClient(ctx, cfg.Grpc.Network, cfg.Grpc.Address, cfg.Grpc.DialTimeout)
-	w = writer.NewSendToMany(ctx, p.eventBus, p.gossip, client)
-	p.connections = append(p.connections, conn)
+	w = writer.NewSendToMany(ctx, p.eventBus, p.gossip, newClient())
 	p.writers = append(p.writers, w)
 }
 
@@ -119,6 +138,10 @@ func (p *Peer) Close() {
 		}
 	}
 
+	if p.node != nil {
+		_ = p.node.Close()
+	}
+
 	log.Info("peer closed")
 }
 
@@ -148,6 +171,51 @@ func CreateNetworkClient(ctx context.Context, network, address string, dialTimeo
 	return rusk.NewNetworkClient(conn), conn
 }
 
+// CreateNativeNode starts the in-process Kadcast node configured by the
+// kadcast.native settings and bootstraps it.
+func CreateNativeNode() *routing.Node {
+	cfg := config.Get().Kadcast
+
+	ncfg := routing.Config{
+		Address:    cfg.Address,
+		Bootstrap:  cfg.BootstrapAddr,
+		BucketSize: int(cfg.Native.BucketSize),
+		Redundancy: int(cfg.Native.Redundancy),
+	}
+
+	if cfg.Native.RefreshInterval != "" {
+		d, err := time.ParseDuration(cfg.Native.RefreshInterval)
+		if err != nil {
+			log.WithError(err).Fatal("invalid kadcast.native.refreshInterval")
+		}
+
+		ncfg.RefreshInterval = d
+	}
+
+	ncfg.Raptor = routing.RaptorConfig{
+		Enabled: cfg.Native.Raptor.Enabled,
+		MinSize: int(cfg.Native.Raptor.MinSize),
+	}
+
+	if cfg.Native.Raptor.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Native.Raptor.Timeout)
+		if err != nil {
+			log.WithError(err).Fatal("invalid kadcast.native.raptor.timeout")
+		}
+
+		ncfg.Raptor.Timeout = d
+	}
+
+	node, err := routing.NewNode(ncfg)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	node.Bootstrap()
+
+	return node
+}
+
 // InjectRuskVersion injects the rusk version into the grpc headers.
 func InjectRuskVersion(ctx context.Context) context.Context {
 	md := metadata.New(map[string]string{"x-rusk-version": config.RuskVersion})
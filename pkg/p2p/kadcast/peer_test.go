@@ -69,7 +69,10 @@ func TestListenStreamReader(t *testing.T) {
 	ruskc := rusk.NewNetworkClient(grpcConn)
 
 	// create our kadcli (gRPC) Reader
-	r := NewReader(context.Background(), eb, g, p, ruskc)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := NewReader(ctx, eb, g, p, ruskc)
 
 	// subscribe to gRPC stream
 	go r.Listen()
@@ -0,0 +1,217 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package kadcast
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/container/ring"
+)
+
+const (
+	// DropOldest discards the oldest queued message to make room for the
+	// incoming one.
+	DropOldest = "oldest"
+	// DropNewest discards the incoming message.
+	DropNewest = "newest"
+)
+
+// Priorities of the inbound messages, the highest are processed first.
+const (
+	priorityTx = byte(iota)
+	priorityBlock
+	priorityConsensus
+
+	priorityLevels = int(priorityConsensus) + 1
+)
+
+var priorityNames = [priorityLevels]string{"tx", "block", "consensus"}
+
+// queueMetrics exposes the queues of the latest started pool on /debug/vars.
+var queueMetrics = expvar.NewMap("kadcast_queues")
+
+// priority maps a topic to the priority of its queue.
+func priority(t topics.Topic) byte {
+	switch t {
+	case topics.NewBlock, topics.Candidate, topics.GetCandidate,
+		topics.Reduction, topics.AggrAgreement, topics.Agreement:
+		return priorityConsensus
+	case topics.Block, topics.GetBlocks, topics.Inv, topics.GetData,
+		topics.GetHeaders, topics.Headers, topics.CompactBlock,
+		topics.GetBlockTxs, topics.BlockTxs:
+		return priorityBlock
+	default:
+		return priorityTx
+	}
+}
+
+// QueueStats is a snapshot of a priority queue of the worker pool.
+type QueueStats struct {
+	Name      string
+	Depth     int64
+	Dropped   int64
+	Processed int64
+}
+
+type queueCounters struct {
+	depth     expvar.Int
+	dropped   expvar.Int
+	processed expvar.Int
+}
+
+// workerPool processes ring elements with a fixed number of goroutines. The
+// elements are queued by ring.Elem.Priority into bounded FIFO queues and a
+// worker always picks from the highest priority non-empty queue.
+type workerPool struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+
+	queues     [priorityLevels][]ring.Elem
+	queueSize  int
+	dropOldest bool
+	closed     bool
+
+	counters [priorityLevels]queueCounters
+
+	process func(ring.Elem)
+}
+
+// newWorkerPool starts workers goroutines calling process on each queued
+// element.
+func newWorkerPool(workers, queueSize int, dropPolicy string, process func(ring.Elem)) *workerPool {
+	p := &workerPool{
+		queueSize:  queueSize,
+		dropOldest: dropPolicy != DropNewest,
+		process:    process,
+	}
+
+	p.notEmpty = sync.NewCond(&p.mu)
+
+	for i := range p.counters {
+		name := priorityNames[i]
+		queueMetrics.Set(name+".depth", &p.counters[i].depth)
+		queueMetrics.Set(name+".dropped", &p.counters[i].dropped)
+		queueMetrics.Set(name+".processed", &p.counters[i].processed)
+	}
+
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+
+	return p
+}
+
+// Put queues an element. It returns false if an element had to be dropped,
+// either the incoming one or the oldest one depending on the drop policy.
+func (p *workerPool) Put(e ring.Elem) bool {
+	level := int(e.Priority)
+	if level >= priorityLevels {
+		level = priorityLevels - 1
+	}
+
+	c := &p.counters[level]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+
+	q := p.queues[level]
+	full := len(q) >= p.queueSize
+
+	if full {
+		c.dropped.Add(1)
+
+		if !p.dropOldest || len(q) == 0 {
+			return false
+		}
+
+		q[0] = ring.Elem{}
+		q = q[1:]
+
+		c.depth.Add(-1)
+	}
+
+	p.queues[level] = append(q, e)
+	c.depth.Add(1)
+
+	p.notEmpty.Signal()
+
+	return !full
+}
+
+// next blocks until an element is queued and pops the one with the highest
+// priority. It returns false once the pool is closed.
+func (p *workerPool) next() (ring.Elem, int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.closed {
+			return ring.Elem{}, 0, false
+		}
+
+		for level := priorityLevels - 1; level >= 0; level-- {
+			q := p.queues[level]
+			if len(q) == 0 {
+				continue
+			}
+
+			e := q[0]
+			q[0] = ring.Elem{}
+			p.queues[level] = q[1:]
+
+			p.counters[level].depth.Add(-1)
+			return e, level, true
+		}
+
+		p.notEmpty.Wait()
+	}
+}
+
+func (p *workerPool) run() {
+	for {
+		e, level, ok := p.next()
+		if !ok {
+			return
+		}
+
+		p.process(e)
+		p.counters[level].processed.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the queues, highest priority first.
+func (p *workerPool) Stats() []QueueStats {
+	stats := make([]QueueStats, 0, priorityLevels)
+
+	for level := priorityLevels - 1; level >= 0; level-- {
+		c := &p.counters[level]
+		stats = append(stats, QueueStats{
+			Name:      priorityNames[level],
+			Depth:     c.depth.Value(),
+			Dropped:   c.dropped.Value(),
+			Processed: c.processed.Value(),
+		})
+	}
+
+	return stats
+}
+
+// Close stops the workers once they are done with the element in progress.
+// Queued elements are discarded.
+func (p *workerPool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.notEmpty.Broadcast()
+}
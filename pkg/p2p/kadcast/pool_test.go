@@ -0,0 +1,105 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package kadcast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/container/ring"
+	"github.com/stretchr/testify/assert"
+)
+
+func topicElem(t topics.Topic) ring.Elem {
+	return ring.Elem{Data: []byte{byte(t)}, Priority: priority(t)}
+}
+
+// blockedPool returns a single worker pool which is busy until release is
+// closed. Processed topics are sent to the returned channel.
+func blockedPool(queueSize int, dropPolicy string) (*workerPool, chan topics.Topic, chan struct{}) {
+	processed := make(chan topics.Topic, 100)
+	release := make(chan struct{})
+
+	p := newWorkerPool(1, queueSize, dropPolicy, func(e ring.Elem) {
+		<-release
+		processed <- topics.Topic(e.Data[0])
+	})
+
+	// keep the worker busy
+	p.Put(topicElem(topics.Unknown))
+
+	for p.Stats()[2].Depth > 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	return p, processed, release
+}
+
+func receive(t *testing.T, c chan topics.Topic, n int) []topics.Topic {
+	result := make([]topics.Topic, 0, n)
+
+	for i := 0; i < n; i++ {
+		select {
+		case topic := <-c:
+			result = append(result, topic)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not processed")
+		}
+	}
+
+	return result
+}
+
+// TestPoolPriority tests consensus messages are processed before blocks and
+// blocks before txs.
+func TestPoolPriority(t *testing.T) {
+	p, processed, release := blockedPool(10, DropOldest)
+	defer p.Close()
+
+	for _, topic := range []topics.Topic{topics.Tx, topics.Block, topics.Reduction, topics.Tx, topics.Agreement} {
+		assert.True(t, p.Put(topicElem(topic)))
+	}
+
+	close(release)
+
+	assert.Equal(t, []topics.Topic{
+		topics.Unknown,
+		topics.Reduction, topics.Agreement,
+		topics.Block,
+		topics.Tx, topics.Tx,
+	}, receive(t, processed, 6))
+}
+
+// TestPoolDropPolicy tests the overflowing messages are dropped according
+// to the drop policy, without affecting the other queues.
+func TestPoolDropPolicy(t *testing.T) {
+	for policy, expected := range map[string][]topics.Topic{
+		DropOldest: {topics.NewBlock, topics.GetData, topics.Inv},
+		DropNewest: {topics.NewBlock, topics.Block, topics.GetBlocks},
+	} {
+		p, processed, release := blockedPool(2, policy)
+
+		assert.True(t, p.Put(topicElem(topics.Block)))
+		assert.True(t, p.Put(topicElem(topics.GetBlocks)))
+		assert.False(t, p.Put(topicElem(topics.GetData)))
+		assert.False(t, p.Put(topicElem(topics.Inv)))
+		assert.True(t, p.Put(topicElem(topics.NewBlock)))
+
+		stats := p.Stats()
+		assert.Equal(t, "consensus", stats[0].Name)
+		assert.Equal(t, int64(1), stats[0].Depth)
+		assert.Equal(t, "block", stats[1].Name)
+		assert.Equal(t, int64(2), stats[1].Depth)
+		assert.Equal(t, int64(2), stats[1].Dropped)
+
+		close(release)
+		assert.Equal(t, expected, receive(t, processed, 4)[1:], policy)
+
+		p.Close()
+	}
+}
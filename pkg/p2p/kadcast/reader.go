@@ -9,20 +9,58 @@ package kadcast
 import (
 	"bytes"
 	"context"
-	"encoding/hex"
 	"errors"
+	"sync/atomic"
+	"time"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/checksum"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/container/ring"
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
 	"github.com/dusk-network/dusk-protobuf/autogen/go/rusk"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+
+	defaultWorkers   = 4
+	defaultQueueSize = 1000
+)
+
+// ConnState is the state of the Kadcast listener stream.
+type ConnState int32
+
+const (
+	// StateReconnecting is set until the stream is (re)opened.
+	StateReconnecting ConnState = iota
+	// StateConnected is set while the stream is open.
+	StateConnected
+	// StateFailed is set once the configured number of consecutive
+	// reconnection attempts failed. Attempts go on at the max backoff.
+	StateFailed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateReconnecting:
+		return "reconnecting"
+	case StateConnected:
+		return "connected"
+	case StateFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
 // Reader is a proxy between Kadcast grpc service and Message Processor. It
 // receives a wire message and ,if it's valid, redirects it to Message Processor.
 // In addition, it turns any response message from Processor into
@@ -34,47 +72,215 @@ type Reader struct {
 
 	client rusk.NetworkClient
 
+	// reconnection policy
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	maxAttempts uint
+
+	state int32
+
+	// inbound messages processing
+	pool *workerPool
+
 	ctx context.Context
 }
 
 // NewReader makes a new Kadcast reader.
 func NewReader(ctx context.Context, publisher eventbus.Publisher, g *protocol.Gossip, p *peer.MessageProcessor, rusk rusk.NetworkClient) *Reader {
-	return &Reader{
-		publisher: publisher,
-		processor: p,
-		gossip:    g,
-		client:    rusk,
-		ctx:       ctx,
+	cfg := config.Get().Kadcast.Reconnect
+
+	minBackoff := defaultMinBackoff
+	maxBackoff := defaultMaxBackoff
+
+	if len(cfg.MinBackoff) > 0 {
+		var err error
+
+		minBackoff, err = time.ParseDuration(cfg.MinBackoff)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse kadcast min backoff")
+		}
+	}
+
+	if len(cfg.MaxBackoff) > 0 {
+		var err error
+
+		maxBackoff, err = time.ParseDuration(cfg.MaxBackoff)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse kadcast max backoff")
+		}
+	}
+
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
 	}
+
+	r := &Reader{
+		publisher:   publisher,
+		processor:   p,
+		gossip:      g,
+		client:      rusk,
+		minBackoff:  minBackoff,
+		maxBackoff:  maxBackoff,
+		maxAttempts: cfg.MaxAttempts,
+		state:       int32(StateReconnecting),
+		ctx:         ctx,
+	}
+
+	wcfg := config.Get().Kadcast.Workers
+
+	workers := defaultWorkers
+	if wcfg.Count > 0 {
+		workers = int(wcfg.Count)
+	}
+
+	queueSize := defaultQueueSize
+	if wcfg.QueueSize > 0 {
+		queueSize = int(wcfg.QueueSize)
+	}
+
+	switch wcfg.DropPolicy {
+	case "", DropOldest, DropNewest:
+	default:
+		log.WithField("drop_policy", wcfg.DropPolicy).Fatal("unsupported kadcast drop policy")
+	}
+
+	r.pool = newWorkerPool(workers, queueSize, wcfg.DropPolicy, r.collect)
+	return r
 }
 
-// Listen starts accepting and processing stream data.
+// QueueStats returns a snapshot of the inbound message queues.
+func (r *Reader) QueueStats() []QueueStats {
+	return r.pool.Stats()
+}
+
+// State returns the current state of the listener stream.
+func (r *Reader) State() ConnState {
+	return ConnState(atomic.LoadInt32(&r.state))
+}
+
+// Listen starts accepting and processing stream data. Whenever the stream
+// breaks, it is reopened with an exponential backoff. It blocks until the
+// reader context is canceled, then stops the worker pool.
 func (r *Reader) Listen() {
+	defer r.pool.Close()
+
+	// The initial state is not a change, so notify it explicitly
+	r.publishState(r.State())
+
+	var attempts uint
+
+	for {
+		opened := time.Now()
+
+		received, err := r.listen()
+		if r.ctx.Err() != nil {
+			reportStreamErr(err)
+			return
+		}
+
+		// A stream that was healthy for a while starts a new backoff sequence
+		if received || time.Since(opened) >= r.maxBackoff {
+			attempts = 0
+		}
+
+		attempts++
+
+		if r.maxAttempts > 0 && attempts >= r.maxAttempts {
+			r.setState(StateFailed)
+		} else {
+			r.setState(StateReconnecting)
+		}
+
+		delay := r.backoff(attempts)
+
+		log.WithError(err).
+			WithField("attempt", attempts).
+			WithField("delay", delay.String()).
+			Debug("reconnecting listener stream")
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// listen opens the stream and receives messages until it breaks. It reports
+// whether any message was received.
+func (r *Reader) listen() (bool, error) {
 	// create stream handler
 	stream, err := r.client.Listen(r.ctx, &rusk.Null{})
 	if err != nil {
-		log.WithError(err).Fatal("open stream error")
-		return
+		return false, err
 	}
 
-	// listen for messages
-	go func() {
-		for {
-			// receive a message
-			msg, err := stream.Recv()
-			if err != nil {
-				reportStreamErr(err)
-				return
-			}
+	r.setState(StateConnected)
 
-			// Message received
-			go r.processMessage(msg)
+	received := false
+
+	for {
+		// receive a message
+		msg, err := stream.Recv()
+		if err != nil {
+			return received, err
 		}
-	}()
+
+		received = true
+
+		// Message received
+		r.processMessage(msg)
+	}
 }
 
-// processMessage propagates the received kadcast message into the event bus.
+// backoff returns the delay before the specified reconnection attempt.
+func (r *Reader) backoff(attempt uint) time.Duration {
+	delay := r.minBackoff
+	for i := uint(1); i < attempt && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > r.maxBackoff {
+		return r.maxBackoff
+	}
+
+	return delay
+}
+
+// setState updates the stream state and notifies the change on
+// topics.KadcastStatus.
+func (r *Reader) setState(s ConnState) {
+	prev := ConnState(atomic.SwapInt32(&r.state, int32(s)))
+	if prev == s {
+		return
+	}
+
+	l := log.WithField("state", s.String())
+
+	switch {
+	case s == StateConnected:
+		l.Info("kadcast stream connected")
+	case prev == StateConnected:
+		l.Warn("kadcast connectivity lost")
+	case s == StateFailed:
+		l.Error("kadcast reconnection failed")
+	}
+
+	r.publishState(s)
+}
+
+func (r *Reader) publishState(s ConnState) {
+	r.publisher.Publish(topics.KadcastStatus, message.New(topics.KadcastStatus, s.String()))
+}
+
+// processMessage validates the received kadcast message and queues it by
+// topic priority for the worker pool.
 func (r *Reader) processMessage(msg *rusk.Message) {
+	// drop messages from banned peers
+	if r.processor.Scores().Banned(msg.Metadata.SrcAddress) {
+		return
+	}
+
 	reader := bytes.NewReader(msg.Message)
 
 	// read message (extract length and magic)
@@ -94,33 +300,50 @@ func (r *Reader) processMessage(msg *rusk.Message) {
 	if !checksum.Verify(m, cs) {
 		log.WithError(errors.New("invalid checksum")).
 			Warnln("error verifying message cs")
+		r.processor.Penalize(msg.Metadata.SrcAddress, score.BadChecksum)
 		return
 	}
 
-	metadata := message.Metadata{
-		KadcastHeight: byte(msg.Metadata.KadcastHeight),
-		Source:        msg.Metadata.SrcAddress,
+	if len(m) == 0 {
+		log.WithField("r_addr", msg.Metadata.SrcAddress).Warnln("empty message")
+		return
+	}
+
+	topic := topics.Topic(m[0])
+
+	e := ring.Elem{
+		Data: m,
+		Metadata: &message.Metadata{
+			KadcastHeight: byte(msg.Metadata.KadcastHeight),
+			Source:        msg.Metadata.SrcAddress,
+		},
+		Priority: priority(topic),
+	}
+
+	if !r.pool.Put(e) {
+		log.WithField("topic", topic.String()).Trace("queue overflow, message dropped")
 	}
+}
+
+// collect propagates a queued kadcast message into the event bus.
+func (r *Reader) collect(e ring.Elem) {
+	srcAddr := e.Metadata.Source
 
 	// collect (process) the message
-	respBufs, err := r.processor.Collect(msg.Metadata.SrcAddress, m, nil, protocol.FullNode, &metadata)
+	respBufs, err := r.processor.Collect(srcAddr, e.Data, nil, protocol.FullNode, e.Metadata)
 	if err != nil {
-		var topic string
-		if len(m) > 0 {
-			topic = topics.Topic(m[0]).String()
-		}
 		// log error
-		log.WithField("cs", hex.EncodeToString(cs)).
-			WithField("topic", topic).
+		log.WithField("r_addr", srcAddr).
+			WithField("topic", topics.Topic(e.Data[0]).String()).
 			WithError(err).Error("failed to process message")
 		return
 	}
 	// any response message is translated into Kadcast Point-to-Point wire message
 	// in other words, any bufs item is sent back to the sender node (remotePeer)
 	for i := 0; i < len(respBufs); i++ {
-		log.WithField("r_addr", msg.Metadata.SrcAddress).Trace("send point-to-point message")
+		log.WithField("r_addr", srcAddr).Trace("send point-to-point message")
 		// send Kadcast point-to-point message with source address as destination
-		msg := message.NewWithMetadata(topics.KadcastSendToOne, respBufs[i], &metadata)
+		msg := message.NewWithMetadata(topics.KadcastSendToOne, respBufs[i], e.Metadata)
 		r.publisher.Publish(topics.KadcastSendToOne, msg)
 	}
 }
@@ -0,0 +1,114 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package kadcast
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+	"github.com/dusk-network/dusk-protobuf/autogen/go/rusk"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// TestReaderBackoff tests the reconnection delay is doubled up to the max.
+func TestReaderBackoff(t *testing.T) {
+	assert := assert.New(t)
+
+	r := &Reader{minBackoff: 100 * time.Millisecond, maxBackoff: time.Second}
+
+	assert.Equal(100*time.Millisecond, r.backoff(1))
+	assert.Equal(200*time.Millisecond, r.backoff(2))
+	assert.Equal(800*time.Millisecond, r.backoff(4))
+	assert.Equal(time.Second, r.backoff(5))
+	assert.Equal(time.Second, r.backoff(1000))
+}
+
+// TestReaderReconnect tests the kadcli.Reader reopening the stream after
+// failed attempts and notifying its state changes.
+func TestReaderReconnect(t *testing.T) {
+	assert := assert.New(t)
+
+	eb := eventbus.New()
+	statusChan := make(chan message.Message, 10)
+	eb.Subscribe(topics.KadcastStatus, eventbus.NewChanListener(statusChan))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cli := &flakyNetworkClient{failures: 3}
+
+	r := NewReader(ctx, eb, protocol.NewGossip(), peer.NewMessageProcessor(eb), cli)
+	r.minBackoff = time.Millisecond
+	r.maxBackoff = 4 * time.Millisecond
+	r.maxAttempts = 2
+
+	assert.Equal(StateReconnecting, r.State())
+
+	done := make(chan struct{})
+
+	go func() {
+		r.Listen()
+		close(done)
+	}()
+
+	for _, expected := range []ConnState{StateReconnecting, StateFailed, StateConnected} {
+		select {
+		case m := <-statusChan:
+			state, err := message.ConvStr(m.Payload())
+			assert.NoError(err)
+			assert.Equal(expected.String(), state)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s notification", expected)
+		}
+	}
+
+	assert.Equal(StateConnected, r.State())
+	assert.Equal(int32(4), atomic.LoadInt32(&cli.calls))
+
+	// Listen returns once the reader is canceled
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not stop")
+	}
+}
+
+// flakyNetworkClient fails to open the listener stream a number of times
+// before opening a stream that stays idle until canceled.
+type flakyNetworkClient struct {
+	MockNetworkClient
+	failures int32
+	calls    int32
+}
+
+// Listen fails until failures are exhausted.
+func (c *flakyNetworkClient) Listen(ctx context.Context, in *rusk.Null, opts ...grpc.CallOption) (rusk.Network_ListenClient, error) {
+	if atomic.AddInt32(&c.calls, 1) <= c.failures {
+		return nil, errors.New("unavailable")
+	}
+
+	return &idleStream{ctx: ctx}, nil
+}
+
+type idleStream struct {
+	grpc.ClientStream
+	ctx context.Context
+}
+
+func (s *idleStream) Recv() (*rusk.Message, error) {
+	<-s.ctx.Done()
+	return nil, s.ctx.Err()
+}
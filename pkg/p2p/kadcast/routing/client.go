@@ -0,0 +1,123 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"context"
+	"io"
+
+	"github.com/dusk-network/dusk-protobuf/autogen/go/rusk"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Node satisfies the same client interface as the Rusk network service, so
+// that it can be used in place of it by the Kadcast reader and writers.
+var _ rusk.NetworkClient = (*Node)(nil)
+
+// Listen returns the stream of the messages received by the node.
+func (n *Node) Listen(ctx context.Context, in *rusk.Null, opts ...grpc.CallOption) (rusk.Network_ListenClient, error) {
+	if n.ctx.Err() != nil {
+		return nil, status.Error(codes.Unavailable, ErrClosed.Error())
+	}
+
+	return &stream{ctx: ctx, node: n}, nil
+}
+
+// Broadcast implements rusk.NetworkClient.
+func (n *Node) Broadcast(ctx context.Context, in *rusk.BroadcastMessage, opts ...grpc.CallOption) (*rusk.Null, error) {
+	height := in.KadcastHeight
+	if height >= BucketsNum {
+		height = BucketsNum - 1
+	}
+
+	if err := n.broadcast(in.Message, byte(height)); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &rusk.Null{}, nil
+}
+
+// Propagate broadcasts the message to the whole network.
+func (n *Node) Propagate(ctx context.Context, in *rusk.PropagateMessage, opts ...grpc.CallOption) (*rusk.Null, error) {
+	if err := n.broadcast(in.Message, BucketsNum-1); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &rusk.Null{}, nil
+}
+
+// Send implements rusk.NetworkClient.
+func (n *Node) Send(ctx context.Context, in *rusk.SendMessage, opts ...grpc.CallOption) (*rusk.Null, error) {
+	if err := n.send(in.Message, in.TargetAddress); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &rusk.Null{}, nil
+}
+
+// AliveNodes implements rusk.NetworkClient.
+func (n *Node) AliveNodes(ctx context.Context, in *rusk.AliveNodesRequest, opts ...grpc.CallOption) (*rusk.AliveNodesResponse, error) {
+	return &rusk.AliveNodesResponse{Address: n.aliveNodes(int(in.MaxNodes))}, nil
+}
+
+// stream delivers the messages of the node until either the context of the
+// Listen call is done or the node is closed.
+type stream struct {
+	ctx  context.Context
+	node *Node
+}
+
+func (s *stream) Recv() (*rusk.Message, error) {
+	select {
+	case m := <-s.node.inbound:
+		return m, nil
+	case <-s.ctx.Done():
+		return nil, status.Error(codes.Canceled, s.ctx.Err().Error())
+	case <-s.node.ctx.Done():
+		return nil, status.Error(codes.Unavailable, ErrClosed.Error())
+	}
+}
+
+func (s *stream) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+func (s *stream) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+func (s *stream) CloseSend() error {
+	return nil
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stream) SendMsg(m interface{}) error {
+	return io.EOF
+}
+
+func (s *stream) RecvMsg(m interface{}) error {
+	msg, err := s.Recv()
+	if err != nil {
+		return err
+	}
+
+	out, ok := m.(*rusk.Message)
+	if !ok {
+		return status.Error(codes.Internal, "unexpected message type")
+	}
+
+	out.Message = msg.Message
+	out.Metadata = msg.Metadata
+
+	return nil
+}
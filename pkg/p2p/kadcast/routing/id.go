@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/bits"
+	"net"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// IDSize is the size in bytes of a node ID.
+	IDSize = 16
+	// BucketsNum is the number of k-buckets, one per bit of the ID.
+	BucketsNum = IDSize * 8
+)
+
+// ID identifies a node in the XOR metric space.
+type ID [IDSize]byte
+
+// ComputeID derives the ID of a node from its UDP address.
+func ComputeID(addr string) ID {
+	var id ID
+
+	h := blake2b.Sum256([]byte(addr))
+	copy(id[:], h[:IDSize])
+
+	return id
+}
+
+// BucketHeight returns the index of the k-bucket other belongs to, relative
+// to id. That is the position of the most significant bit of their XOR
+// distance, or -1 if the IDs are equal.
+func (id ID) BucketHeight(other ID) int {
+	for i := 0; i < IDSize; i++ {
+		if x := id[i] ^ other[i]; x != 0 {
+			return (IDSize-i)*8 - 1 - bits.LeadingZeros8(x)
+		}
+	}
+
+	return -1
+}
+
+// Closer reports whether a is closer than b to id.
+func (id ID) Closer(a, b ID) bool {
+	for i := 0; i < IDSize; i++ {
+		da, db := id[i]^a[i], id[i]^b[i]
+		if da != db {
+			return da < db
+		}
+	}
+
+	return false
+}
+
+// RandomAtHeight returns a random ID that falls in the k-bucket of the
+// specified height, relative to id.
+func (id ID) RandomAtHeight(height int) ID {
+	var r ID
+	_, _ = rand.Read(r[:])
+
+	out := id
+	byteIdx := IDSize - 1 - height/8
+	bit := byte(1) << uint(height%8)
+
+	// Flip the bit of the height and randomize the lower ones
+	out[byteIdx] = (id[byteIdx] ^ bit) & ^(bit - 1)
+	out[byteIdx] |= r[byteIdx] & (bit - 1)
+
+	for i := byteIdx + 1; i < IDSize; i++ {
+		out[i] = r[i]
+	}
+
+	return out
+}
+
+func (id ID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// Peer is a node of the Kadcast network.
+type Peer struct {
+	ID   ID
+	Addr *net.UDPAddr
+}
+
+// NewPeer returns the peer listening on the address.
+func NewPeer(addr *net.UDPAddr) Peer {
+	return Peer{
+		ID:   ComputeID(addr.String()),
+		Addr: addr,
+	}
+}
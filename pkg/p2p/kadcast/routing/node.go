@@ -0,0 +1,769 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rcudp"
+	"github.com/dusk-network/dusk-protobuf/autogen/go/rusk"
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.WithFields(logrus.Fields{"process": "kadcast", "routing": "native"})
+
+const (
+	// DefaultBucketSize is the default max number of peers per bucket (k).
+	DefaultBucketSize = 20
+	// DefaultRedundancy is the default number of peers per bucket a message
+	// is broadcast to (beta).
+	DefaultRedundancy = 3
+	// DefaultRefreshInterval is the default max idle time of a bucket.
+	DefaultRefreshInterval = 10 * time.Minute
+	// DefaultRaptorMinSize is the default size from which a message is
+	// raptor-coded.
+	DefaultRaptorMinSize = 16 * 1024
+
+	// alpha is the number of peers a lookup is sent to.
+	alpha = 3
+
+	pingTimeout     = 2 * time.Second
+	lookupTimeout   = 2 * time.Second
+	assemblyTimeout = 10 * time.Second
+	seenTTL         = time.Minute
+	inboundSize     = 1000
+	socketBuffer    = 4 * 1024 * 1024
+
+	// The pending assemblies are bounded per sender host and in total, in
+	// number and in bytes. The bytes of an assembly are reserved for its
+	// announced chunks count.
+	maxAssemblies          = 1024
+	maxAssembliesPerSender = 16
+	maxAssemblyBytes       = 64 * 1024 * 1024
+	maxSenderBytes         = 4 * maxChunks * chunkSize
+
+	// An empty table is bootstrapped again with an exponential backoff.
+	minBootstrapBackoff = pingTimeout
+	maxBootstrapBackoff = time.Minute
+)
+
+// ErrClosed is returned by the operations of a closed Node.
+var ErrClosed = errors.New("kadcast node closed")
+
+// Config of a Node.
+type Config struct {
+	// Address is the UDP address the node listens on and is reached at.
+	// Port 0 picks a free port.
+	Address string
+	// Bootstrap lists the UDP addresses of the bootstrapping nodes.
+	Bootstrap []string
+
+	BucketSize      int
+	Redundancy      int
+	RefreshInterval time.Duration
+
+	Raptor RaptorConfig
+}
+
+// RaptorConfig sets the broadcast of large messages as raptor-coded packets
+// instead of plain chunks. Raptor-coded messages are decoded regardless.
+type RaptorConfig struct {
+	Enabled bool
+	// MinSize is the size in bytes from which a message is raptor-coded
+	MinSize int
+	// Timeout is the time after which an incomplete message is discarded
+	Timeout time.Duration
+}
+
+type assemblyKey struct {
+	kind byte
+	id   uint64
+}
+
+type assembly struct {
+	chunks   [][]byte
+	received int
+	started  time.Time
+	sender   string
+}
+
+// size returns the bytes reserved by the assembly.
+func (a *assembly) size() int {
+	return len(a.chunks) * chunkSize
+}
+
+// usage is the number of pending assemblies and reserved bytes of a sender.
+type usage struct {
+	assemblies int
+	bytes      int
+}
+
+// pendingPing is a liveness check of the least recently seen peer of a full
+// bucket. The candidate replaces it if the check times out.
+type pendingPing struct {
+	peer      Peer
+	candidate Peer
+	deadline  time.Time
+}
+
+// pendingLookup is the number of NODES replies expected from a peer, until
+// the deadline of its latest lookup.
+type pendingLookup struct {
+	replies  int
+	deadline time.Time
+}
+
+// Node is an in-process Kadcast node over UDP. It maintains the k-buckets of
+// the node, derives the peer IDs from their addresses and implements the
+// Kadcast broadcast. Re-broadcasting a received message at its height is left
+// to the caller, as with the Rusk network service.
+type Node struct {
+	cfg  Config
+	self Peer
+	conn *net.UDPConn
+
+	table  *Table
+	raptor *rcudp.UDPReader
+
+	inbound chan *rusk.Message
+
+	lock       sync.Mutex
+	assemblies map[assemblyKey]*assembly
+	usages     map[string]*usage
+	total      usage
+	seen       map[uint64]time.Time
+	pings      map[uint64]pendingPing
+
+	// NODES replies expected, by address of the peers the FIND_NODES were
+	// sent to
+	lookups map[string]*pendingLookup
+
+	// next bootstrap of an empty table, accessed by the maintenance loop
+	bootstrapAt      time.Time
+	bootstrapBackoff time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewNode binds the UDP socket of the node and starts serving it.
+func NewNode(cfg Config) (*Node, error) {
+	if cfg.BucketSize <= 0 {
+		cfg.BucketSize = DefaultBucketSize
+	}
+
+	if cfg.BucketSize > 255 {
+		cfg.BucketSize = 255
+	}
+
+	if cfg.Redundancy <= 0 {
+		cfg.Redundancy = DefaultRedundancy
+	}
+
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultRefreshInterval
+	}
+
+	if cfg.Raptor.MinSize <= 0 {
+		cfg.Raptor.MinSize = DefaultRaptorMinSize
+	}
+
+	if cfg.Raptor.Timeout <= 0 {
+		cfg.Raptor.Timeout = assemblyTimeout
+	}
+
+	laddr, err := net.ResolveUDPAddr("udp", cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = conn.SetReadBuffer(socketBuffer)
+	_ = conn.SetWriteBuffer(socketBuffer)
+
+	// The node is reached at the configured address unless it only sets
+	// the port to be picked
+	addr := conn.LocalAddr().(*net.UDPAddr)
+	if laddr.Port != 0 && laddr.IP != nil && !laddr.IP.IsUnspecified() {
+		addr = laddr
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	n := &Node{
+		cfg:        cfg,
+		self:       NewPeer(addr),
+		conn:       conn,
+		inbound:    make(chan *rusk.Message, inboundSize),
+		assemblies: make(map[assemblyKey]*assembly),
+		usages:     make(map[string]*usage),
+		seen:       make(map[uint64]time.Time),
+		pings:      make(map[uint64]pendingPing),
+		lookups:    make(map[string]*pendingLookup),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+
+	n.table = NewTable(n.self.ID, cfg.BucketSize)
+
+	n.raptor, err = rcudp.NewUDPReader(addr, n.collectRaptor)
+	if err != nil {
+		cancel()
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	n.raptor.SetTimeout(cfg.Raptor.Timeout)
+
+	n.wg.Add(2)
+
+	go n.readLoop()
+	go n.maintenanceLoop()
+
+	log.WithField("addr", addr.String()).
+		WithField("id", n.self.ID.String()).
+		Info("kadcast node started")
+
+	return n, nil
+}
+
+// Self returns the peer of the node.
+func (n *Node) Self() Peer {
+	return n.self
+}
+
+// Table returns the routing table of the node.
+func (n *Node) Table() *Table {
+	return n.table
+}
+
+// Bootstrap pings the bootstrapping nodes and looks up the node own ID
+// through them, to fill the routing table.
+func (n *Node) Bootstrap() {
+	for _, a := range n.cfg.Bootstrap {
+		addr, err := net.ResolveUDPAddr("udp", a)
+		if err != nil {
+			log.WithError(err).WithField("addr", a).Warn("invalid bootstrap address")
+			continue
+		}
+
+		if addr.String() == n.self.Addr.String() {
+			continue
+		}
+
+		n.ping(addr)
+		n.findNodes(n.self.ID, addr)
+	}
+}
+
+// Close stops the node.
+func (n *Node) Close() error {
+	n.cancel()
+	err := n.conn.Close()
+	n.wg.Wait()
+
+	return err
+}
+
+// broadcast sends the message to up to redundancy peers of each bucket
+// up to the specified height. The receivers of a bucket get the message at
+// the height of the bucket.
+func (n *Node) broadcast(payload []byte, height byte) error {
+	n.markSeen(messageID(payload))
+
+	top := int(height)
+	if top >= BucketsNum {
+		top = BucketsNum - 1
+	}
+
+	// Large messages are raptor-coded once for all the receivers
+	var raptor [][]byte
+
+	if n.cfg.Raptor.Enabled && len(payload) >= n.cfg.Raptor.MinSize {
+		var err error
+		if raptor, err = encodeRaptor(payload); err != nil {
+			log.WithError(err).Warn("raptor encoding failed, sending chunks")
+		}
+	}
+
+	for h := 0; h <= top; h++ {
+		peers := n.table.Bucket(h, n.cfg.Redundancy)
+		if len(peers) == 0 {
+			continue
+		}
+
+		packets := raptor
+		if packets != nil {
+			setRaptorHeight(packets, byte(h))
+			rcudp.RecordSent(len(packets) * len(peers))
+		} else {
+			var err error
+			if packets, err = encodeChunks(packetBroadcast, byte(h), payload); err != nil {
+				return err
+			}
+		}
+
+		for _, p := range peers {
+			n.writeAll(packets, p.Addr)
+		}
+	}
+
+	return nil
+}
+
+// send sends the message to a single node.
+func (n *Node) send(payload []byte, addr string) error {
+	to, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return err
+	}
+
+	packets, err := encodeChunks(packetSend, 0, payload)
+	if err != nil {
+		return err
+	}
+
+	n.writeAll(packets, to)
+	return nil
+}
+
+// aliveNodes returns the addresses of up to max random peers.
+func (n *Node) aliveNodes(max int) []string {
+	peers := sample(n.table.Peers(), max)
+
+	addrs := make([]string, len(peers))
+	for i, p := range peers {
+		addrs[i] = p.Addr.String()
+	}
+
+	return addrs
+}
+
+func (n *Node) readLoop() {
+	defer n.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+
+	for {
+		size, from, err := n.conn.ReadFromUDP(buf)
+		if err != nil {
+			if n.ctx.Err() != nil {
+				return
+			}
+
+			log.WithError(err).Warn("read error")
+			continue
+		}
+
+		if size == 0 || from.String() == n.self.Addr.String() {
+			continue
+		}
+
+		packet := make([]byte, size)
+		copy(packet, buf[:size])
+
+		n.handle(packet, from)
+	}
+}
+
+func (n *Node) handle(packet []byte, from *net.UDPAddr) {
+	sender := NewPeer(from)
+	n.seenPeer(sender)
+
+	var err error
+
+	switch packet[0] {
+	case packetPing:
+		var nonce uint64
+		if nonce, err = decodePing(packet); err == nil {
+			n.write(encodePing(packetPong, nonce), from)
+		}
+	case packetPong:
+		var nonce uint64
+		if nonce, err = decodePing(packet); err == nil {
+			n.lock.Lock()
+			delete(n.pings, nonce)
+			n.lock.Unlock()
+		}
+	case packetFindNodes:
+		var target ID
+		if target, err = decodeFindNodes(packet); err == nil {
+			n.write(encodeNodes(n.table.Closest(target, n.cfg.BucketSize)), from)
+		}
+	case packetNodes:
+		// Only replies to the lookups of the node are accepted
+		if !n.replied(from) {
+			err = errUnsolicited
+			break
+		}
+
+		var addrs []*net.UDPAddr
+		if addrs, err = decodeNodes(packet); err == nil {
+			n.discovered(addrs)
+		}
+	case packetBroadcast, packetSend:
+		var c chunk
+		if c, err = decodeChunk(packet); err == nil {
+			n.assemble(c, from)
+		}
+	case packetRaptor:
+		err = n.raptor.Process(*from, packet[1:])
+	default:
+		err = errMalformedPacket
+	}
+
+	if err != nil {
+		log.WithError(err).WithField("r_addr", from.String()).Debug("invalid packet")
+	}
+}
+
+// seenPeer updates the routing table with the sender of a packet. If its
+// bucket is full, the least recently seen peer is pinged and replaced by the
+// sender unless it answers in time.
+func (n *Node) seenPeer(p Peer) {
+	lru, full := n.table.Seen(p)
+	if !full {
+		return
+	}
+
+	n.lock.Lock()
+
+	for _, pending := range n.pings {
+		if pending.peer.ID == lru.ID {
+			n.lock.Unlock()
+			return
+		}
+	}
+
+	nonce := rand.Uint64()
+	n.pings[nonce] = pendingPing{
+		peer:      lru,
+		candidate: p,
+		deadline:  time.Now().Add(pingTimeout),
+	}
+
+	n.lock.Unlock()
+
+	n.write(encodePing(packetPing, nonce), lru.Addr)
+}
+
+// discovered pings the unknown addresses, which are added to the routing
+// table once they answer.
+func (n *Node) discovered(addrs []*net.UDPAddr) {
+	for _, addr := range addrs {
+		if addr.String() == n.self.Addr.String() || n.table.Has(ComputeID(addr.String())) {
+			continue
+		}
+
+		n.ping(addr)
+	}
+}
+
+// findNodes sends a lookup of the target to the peer, expecting its NODES
+// reply within lookupTimeout.
+func (n *Node) findNodes(target ID, addr *net.UDPAddr) {
+	n.lock.Lock()
+
+	l, ok := n.lookups[addr.String()]
+	if !ok {
+		l = &pendingLookup{}
+		n.lookups[addr.String()] = l
+	}
+
+	l.replies++
+	l.deadline = time.Now().Add(lookupTimeout)
+
+	n.lock.Unlock()
+
+	n.write(encodeFindNodes(target), addr)
+}
+
+// replied reports whether a NODES reply from the peer is expected. A lookup
+// is then completed.
+func (n *Node) replied(from *net.UDPAddr) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	key := from.String()
+
+	l, ok := n.lookups[key]
+	if !ok || time.Now().After(l.deadline) {
+		return false
+	}
+
+	l.replies--
+	if l.replies == 0 {
+		delete(n.lookups, key)
+	}
+
+	return true
+}
+
+func (n *Node) ping(addr *net.UDPAddr) {
+	n.write(encodePing(packetPing, rand.Uint64()), addr)
+}
+
+// assemble collects the chunks of a message and delivers it once complete.
+// A broadcast message is delivered once, while a point-to-point message is
+// delivered every time it is sent.
+func (n *Node) assemble(c chunk, from *net.UDPAddr) {
+	broadcast := c.kind == packetBroadcast
+	key := assemblyKey{c.kind, c.id}
+
+	n.lock.Lock()
+
+	if _, ok := n.seen[c.id]; ok && broadcast {
+		n.lock.Unlock()
+		return
+	}
+
+	a, ok := n.assemblies[key]
+	if !ok {
+		a = &assembly{started: time.Now(), sender: from.IP.String()}
+		a.chunks = make([][]byte, c.count)
+
+		if !n.reserve(a) {
+			n.lock.Unlock()
+			log.WithField("r_addr", from.String()).Debug("too many pending messages, chunk dropped")
+			return
+		}
+
+		n.assemblies[key] = a
+	}
+
+	if int(c.count) != len(a.chunks) || a.chunks[c.index] != nil {
+		n.lock.Unlock()
+		return
+	}
+
+	a.chunks[c.index] = c.data
+	a.received++
+
+	if a.received < len(a.chunks) {
+		n.lock.Unlock()
+		return
+	}
+
+	n.release(key, a)
+
+	payload := make([]byte, 0, len(a.chunks)*chunkSize)
+	for _, data := range a.chunks {
+		payload = append(payload, data...)
+	}
+
+	// A forged ID would prevent the delivery of the genuine message
+	if messageID(payload) != c.id {
+		n.lock.Unlock()
+		log.WithField("r_addr", from.String()).Debug("message ID mismatch")
+		return
+	}
+
+	if broadcast {
+		n.seen[c.id] = time.Now()
+	}
+
+	n.lock.Unlock()
+
+	n.deliver(payload, c.height, from.String())
+}
+
+// reserve accounts for a new assembly, unless its sender or the node are over
+// the limits. It must be called with the lock held.
+func (n *Node) reserve(a *assembly) bool {
+	u, ok := n.usages[a.sender]
+	if !ok {
+		u = &usage{}
+	}
+
+	size := a.size()
+
+	if u.assemblies >= maxAssembliesPerSender || u.bytes+size > maxSenderBytes ||
+		n.total.assemblies >= maxAssemblies || n.total.bytes+size > maxAssemblyBytes {
+		return false
+	}
+
+	u.assemblies++
+	u.bytes += size
+	n.usages[a.sender] = u
+
+	n.total.assemblies++
+	n.total.bytes += size
+
+	return true
+}
+
+// release removes a pending assembly. It must be called with the lock held.
+func (n *Node) release(key assemblyKey, a *assembly) {
+	delete(n.assemblies, key)
+
+	size := a.size()
+
+	n.total.assemblies--
+	n.total.bytes -= size
+
+	if u, ok := n.usages[a.sender]; ok {
+		u.assemblies--
+		u.bytes -= size
+
+		if u.assemblies == 0 {
+			delete(n.usages, a.sender)
+		}
+	}
+}
+
+// collectRaptor delivers a broadcast message decoded from raptor packets,
+// unless it has been received already.
+func (n *Node) collectRaptor(height byte, addr string, payload []byte) error {
+	id := messageID(payload)
+
+	n.lock.Lock()
+
+	if _, ok := n.seen[id]; ok {
+		n.lock.Unlock()
+		return nil
+	}
+
+	n.seen[id] = time.Now()
+	n.lock.Unlock()
+
+	n.deliver(payload, height, addr)
+	return nil
+}
+
+func (n *Node) deliver(payload []byte, height byte, from string) {
+	msg := &rusk.Message{
+		Message: payload,
+		Metadata: &rusk.MessageMetadata{
+			KadcastHeight: uint32(height),
+			SrcAddress:    from,
+		},
+	}
+
+	select {
+	case n.inbound <- msg:
+	default:
+		log.WithField("r_addr", from).Warn("inbound queue full, message dropped")
+	}
+}
+
+func (n *Node) markSeen(id uint64) {
+	n.lock.Lock()
+	n.seen[id] = time.Now()
+	n.lock.Unlock()
+}
+
+// maintenanceLoop expires the pending pings, lookups, assemblies and seen
+// messages, and refreshes the idle buckets.
+func (n *Node) maintenanceLoop() {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(pingTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case now := <-ticker.C:
+			n.expire(now)
+			n.refresh()
+		}
+	}
+}
+
+func (n *Node) expire(now time.Time) {
+	n.lock.Lock()
+
+	evicted := make([]pendingPing, 0)
+
+	for nonce, p := range n.pings {
+		if now.After(p.deadline) {
+			evicted = append(evicted, p)
+			delete(n.pings, nonce)
+		}
+	}
+
+	for key, a := range n.assemblies {
+		if now.Sub(a.started) > assemblyTimeout {
+			n.release(key, a)
+		}
+	}
+
+	for id, t := range n.seen {
+		if now.Sub(t) > seenTTL {
+			delete(n.seen, id)
+		}
+	}
+
+	for addr, l := range n.lookups {
+		if now.After(l.deadline) {
+			delete(n.lookups, addr)
+		}
+	}
+
+	n.lock.Unlock()
+
+	n.raptor.Expire()
+
+	for _, p := range evicted {
+		log.WithField("r_addr", p.peer.Addr.String()).Debug("peer evicted")
+		n.table.Replace(p.peer, p.candidate)
+	}
+}
+
+// refresh looks up a random ID in each idle bucket. An empty table is filled
+// again through the bootstrapping nodes, backing off while they do not
+// answer.
+func (n *Node) refresh() {
+	if n.table.Len() == 0 {
+		if now := time.Now(); now.After(n.bootstrapAt) {
+			n.bootstrapBackoff *= 2
+
+			switch {
+			case n.bootstrapBackoff < minBootstrapBackoff:
+				n.bootstrapBackoff = minBootstrapBackoff
+			case n.bootstrapBackoff > maxBootstrapBackoff:
+				n.bootstrapBackoff = maxBootstrapBackoff
+			}
+
+			n.bootstrapAt = now.Add(n.bootstrapBackoff)
+			n.Bootstrap()
+		}
+	} else {
+		n.bootstrapBackoff = 0
+	}
+
+	for _, h := range n.table.StaleBuckets(n.cfg.RefreshInterval) {
+		target := n.self.ID.RandomAtHeight(h)
+		for _, p := range n.table.Closest(target, alpha) {
+			n.findNodes(target, p.Addr)
+		}
+	}
+}
+
+func (n *Node) write(packet []byte, to *net.UDPAddr) {
+	if _, err := n.conn.WriteToUDP(packet, to); err != nil && n.ctx.Err() == nil {
+		log.WithError(err).WithField("r_addr", to.String()).Debug("write error")
+	}
+}
+
+func (n *Node) writeAll(packets [][]byte, to *net.UDPAddr) {
+	for _, p := range packets {
+		n.write(p, to)
+	}
+}
@@ -0,0 +1,353 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"expvar"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-protobuf/autogen/go/rusk"
+	"github.com/stretchr/testify/assert"
+)
+
+const networkSize = 20
+
+type received struct {
+	node int
+	msg  *rusk.Message
+}
+
+// startNetwork starts nodes over loopback, all bootstrapping off the first
+// one, and waits until each of them knows the whole network.
+func startNetwork(t *testing.T, ctx context.Context, size int, base Config) ([]*Node, chan received) {
+	nodes := make([]*Node, size)
+
+	for i := range nodes {
+		cfg := base
+		cfg.Address = "127.0.0.1:0"
+
+		if i > 0 {
+			cfg.Bootstrap = []string{nodes[0].Self().Addr.String()}
+		}
+
+		n, err := NewNode(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { _ = n.Close() })
+
+		nodes[i] = n
+	}
+
+	for _, n := range nodes {
+		n.Bootstrap()
+	}
+
+	// Lookups of the own ID make the nodes learn about each other
+	deadline := time.Now().Add(10 * time.Second)
+
+	for {
+		complete := true
+
+		for _, n := range nodes {
+			if n.Table().Len() < size-1 {
+				complete = false
+
+				for _, p := range n.Table().Peers() {
+					n.findNodes(n.Self().ID, p.Addr)
+				}
+			}
+		}
+
+		if complete {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("routing tables not filled in time")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	out := make(chan received, size*4)
+
+	for i, n := range nodes {
+		go listen(ctx, i, n, out)
+	}
+
+	return nodes, out
+}
+
+// listen delivers the messages of a node, re-broadcasting them at their
+// height as the Kadcast reader does.
+func listen(ctx context.Context, i int, n *Node, out chan<- received) {
+	stream, err := n.Listen(ctx, &rusk.Null{})
+	if err != nil {
+		return
+	}
+
+	for {
+		m, err := stream.Recv()
+		if err != nil {
+			return
+		}
+
+		if h := m.Metadata.KadcastHeight; h > 0 {
+			_, _ = n.Broadcast(ctx, &rusk.BroadcastMessage{Message: m.Message, KadcastHeight: h - 1})
+		}
+
+		out <- received{i, m}
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	testBroadcast(t, Config{}, []int{100, 100 * 1024})
+}
+
+func TestBroadcastRaptor(t *testing.T) {
+	cfg := Config{Raptor: RaptorConfig{Enabled: true, MinSize: 1024}}
+	testBroadcast(t, cfg, []int{100, 256 * 1024})
+
+	metrics := expvar.Get("rcudp").(*expvar.Map)
+	decoded := metrics.Get("decoded").(*expvar.Int).Value()
+	sent := metrics.Get("packets_sent").(*expvar.Int).Value()
+	needed := metrics.Get("packets_needed").(*expvar.Int).Value()
+
+	assert.GreaterOrEqual(t, decoded, int64(networkSize-1))
+	assert.Greater(t, sent, needed)
+}
+
+// testBroadcast checks that every node receives each message exactly once.
+func testBroadcast(t *testing.T, cfg Config, sizes []int) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes, out := startNetwork(t, ctx, networkSize, cfg)
+
+	for _, size := range sizes {
+		payload := make([]byte, size)
+		_, _ = rand.Read(payload)
+
+		_, err := nodes[0].Broadcast(ctx, &rusk.BroadcastMessage{Message: payload, KadcastHeight: BucketsNum - 1})
+		assert.NoError(err)
+
+		got := make(map[int]int)
+		timeout := time.After(10 * time.Second)
+
+	loop:
+		for {
+			select {
+			case r := <-out:
+				assert.True(bytes.Equal(payload, r.msg.Message))
+
+				got[r.node]++
+
+				if len(got) == networkSize-1 {
+					// Leave the time for a duplicate to show up
+					time.Sleep(200 * time.Millisecond)
+					break loop
+				}
+			case <-timeout:
+				break loop
+			}
+		}
+
+		assert.Len(got, networkSize-1)
+		assert.NotContains(got, 0)
+
+		for node, count := range got {
+			assert.Equal(1, count, "node %d", node)
+		}
+
+	drain:
+		for {
+			select {
+			case r := <-out:
+				t.Errorf("unexpected message on node %d", r.node)
+			default:
+				break drain
+			}
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nodes, out := startNetwork(t, ctx, 3, Config{})
+
+	addrs, err := nodes[0].AliveNodes(ctx, &rusk.AliveNodesRequest{MaxNodes: 10})
+	assert.NoError(err)
+	assert.Len(addrs.Address, 2)
+
+	// The same message is delivered every time it is sent
+	for i := 0; i < 2; i++ {
+		_, err = nodes[0].Send(ctx, &rusk.SendMessage{Message: []byte("hello"), TargetAddress: nodes[2].Self().Addr.String()})
+		assert.NoError(err)
+
+		select {
+		case r := <-out:
+			assert.Equal(2, r.node)
+			assert.Equal([]byte("hello"), r.msg.Message)
+			assert.Equal(uint32(0), r.msg.Metadata.KadcastHeight)
+			assert.Equal(nodes[0].Self().Addr.String(), r.msg.Metadata.SrcAddress)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
+
+func TestListenClosed(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewNode(Config{Address: "127.0.0.1:0"})
+	assert.NoError(err)
+
+	stream, err := n.Listen(context.Background(), &rusk.Null{})
+	assert.NoError(err)
+
+	assert.NoError(n.Close())
+
+	_, err = stream.Recv()
+	assert.Error(err)
+
+	_, err = n.Listen(context.Background(), &rusk.Null{})
+	assert.Error(err)
+}
+
+// Test that the pending assemblies are bounded per sender and released on
+// expiry.
+func TestAssemblyLimits(t *testing.T) {
+	n, err := NewNode(Config{Address: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = n.Close() }()
+
+	// A chunk count over the max frame size is refused
+	packets, err := encodeChunks(packetSend, 0, make([]byte, 2*chunkSize))
+	assert.NoError(t, err)
+
+	binary.LittleEndian.PutUint16(packets[0][12:], uint16(maxChunks+1))
+
+	_, err = decodeChunk(packets[0])
+	assert.Equal(t, errTooLarge, err)
+
+	from := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4000}
+
+	for i := 0; i < 2*maxAssembliesPerSender; i++ {
+		// Each sender port counts for the same host
+		from.Port++
+		n.assemble(chunk{kind: packetSend, id: uint64(i), count: 2, data: []byte{1}}, from)
+	}
+
+	n.lock.Lock()
+	assert.Equal(t, maxAssembliesPerSender, len(n.assemblies))
+	assert.Equal(t, maxAssembliesPerSender*2*chunkSize, n.total.bytes)
+	n.lock.Unlock()
+
+	n.expire(time.Now().Add(2 * assemblyTimeout))
+
+	n.lock.Lock()
+	assert.Empty(t, n.assemblies)
+	assert.Empty(t, n.usages)
+	assert.Equal(t, usage{}, n.total)
+	n.lock.Unlock()
+}
+
+// Test that an empty table is bootstrapped with an exponential backoff.
+func TestBootstrapBackoff(t *testing.T) {
+	n, err := NewNode(Config{Address: "127.0.0.1:0", Bootstrap: []string{"127.0.0.1:1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stop the maintenance loop, the bootstrap packets are not sent
+	_ = n.Close()
+
+	n.bootstrapAt = time.Time{}
+	n.bootstrapBackoff = 0
+
+	n.refresh()
+	assert.Equal(t, minBootstrapBackoff, n.bootstrapBackoff)
+
+	next := n.bootstrapAt
+
+	// Not bootstrapped again before the backoff elapsed
+	n.refresh()
+	assert.Equal(t, next, n.bootstrapAt)
+
+	n.bootstrapAt = time.Time{}
+	n.refresh()
+	assert.Equal(t, 2*minBootstrapBackoff, n.bootstrapBackoff)
+}
+
+// Test that only the NODES replies to the lookups of the node are accepted,
+// and that they list IP literals only.
+func TestNodesReplies(t *testing.T) {
+	assert := assert.New(t)
+
+	n, err := NewNode(Config{Address: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = n.Close() }()
+
+	victim, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = victim.Close() }()
+
+	pinged := func() bool {
+		_ = victim.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+
+		buf := make([]byte, maxPacketSize)
+		size, _, err := victim.ReadFromUDP(buf)
+
+		return err == nil && size > 0 && buf[0] == packetPing
+	}
+
+	from := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 4000}
+	nodes := encodeNodes([]Peer{NewPeer(victim.LocalAddr().(*net.UDPAddr))})
+
+	// An unsolicited reply is dropped
+	n.handle(nodes, from)
+	assert.False(pinged())
+
+	// A reply to a lookup is accepted once
+	n.findNodes(n.Self().ID, from)
+
+	n.handle(nodes, from)
+	assert.True(pinged())
+
+	n.handle(nodes, from)
+	assert.False(pinged())
+
+	// Host names are not looked up
+	host := "localhost:7000"
+	packet := append([]byte{packetNodes, 1, byte(len(host))}, host...)
+
+	_, err = decodeNodes(packet)
+	assert.Equal(errMalformedPacket, err)
+}
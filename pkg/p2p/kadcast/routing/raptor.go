@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"math"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rcudp"
+)
+
+// redundancy returns the ratio of the raptor-coded packets sent to the
+// source symbols of a message. Decoding takes a few packets more than the
+// source symbols, which weighs more on small messages.
+func redundancy(size int) float64 {
+	symbols := size / rcudp.BlockSize
+
+	switch {
+	case symbols <= 16:
+		return 2
+	case symbols <= 128:
+		return 1.5
+	default:
+		return 1.25
+	}
+}
+
+// encodeRaptor returns the raptor-coded packets of a message, to be sent at
+// the height set with setRaptorHeight.
+func encodeRaptor(payload []byte) ([][]byte, error) {
+	factor := redundancy(len(payload))
+	compiled := uint8(math.Ceil(factor))
+
+	// Compiling is destructive to the message array
+	message := make([]byte, len(payload))
+	copy(message, payload)
+
+	_, blocks, err := rcudp.CompileRaptorRFC5053(0, message, compiled)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := len(blocks) / int(compiled)
+	if n := int(math.Ceil(float64(symbols) * factor)); n < len(blocks) {
+		blocks = blocks[:n]
+	}
+
+	packets := make([][]byte, len(blocks))
+	for i, b := range blocks {
+		packets[i] = append([]byte{packetRaptor}, b...)
+	}
+
+	return packets, nil
+}
+
+func setRaptorHeight(packets [][]byte, height byte) {
+	for _, p := range packets {
+		p[1+rcudp.BcastHeightPos] = height
+	}
+}
@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rcudp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaptorLoss(t *testing.T) {
+	assert := assert.New(t)
+
+	addr := net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9000}
+
+	for _, size := range []int{20 * 1024, 200 * 1024, 1024 * 1024} {
+		payload := make([]byte, size)
+		_, _ = rand.Read(payload)
+
+		packets, err := encodeRaptor(payload)
+		assert.NoError(err)
+
+		symbols := int(math.Ceil(float64(size) / rcudp.BlockSize))
+		assert.GreaterOrEqual(len(packets), int(float64(symbols)*redundancy(size)))
+
+		setRaptorHeight(packets, 7)
+
+		decoded := make(chan []byte, 1)
+
+		r, err := rcudp.NewUDPReader(&addr, func(height byte, src string, m []byte) error {
+			assert.Equal(byte(7), height)
+			assert.Equal(addr.String(), src)
+			decoded <- m
+			return nil
+		})
+		assert.NoError(err)
+
+		// Lose one packet in ten
+		for i, p := range packets {
+			if i%10 == 0 {
+				continue
+			}
+
+			assert.NoError(r.Process(addr, p[1:]))
+		}
+
+		select {
+		case m := <-decoded:
+			assert.True(bytes.Equal(payload, m))
+		case <-time.After(time.Second):
+			t.Fatalf("message of %d bytes not decoded", size)
+		}
+	}
+}
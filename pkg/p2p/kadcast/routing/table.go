@@ -0,0 +1,195 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	// peers ordered from the least recently seen
+	peers       []Peer
+	lastRefresh time.Time
+}
+
+func (b *bucket) indexOf(id ID) int {
+	for i, p := range b.peers {
+		if p.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Table is the routing table of a node. It keeps up to k peers per bucket,
+// the bucket of a peer being the height of its XOR distance to the node.
+type Table struct {
+	lock    sync.RWMutex
+	self    ID
+	k       int
+	buckets [BucketsNum]bucket
+}
+
+// NewTable returns an empty routing table of the node.
+func NewTable(self ID, k int) *Table {
+	t := &Table{self: self, k: k}
+
+	now := time.Now()
+	for i := range t.buckets {
+		t.buckets[i].lastRefresh = now
+	}
+
+	return t
+}
+
+// Seen marks the peer as the most recently seen of its bucket, inserting it
+// if there is room. If the bucket is full, the peer is not inserted and the
+// least recently seen peer of the bucket is returned for a liveness check.
+func (t *Table) Seen(p Peer) (Peer, bool) {
+	h := t.self.BucketHeight(p.ID)
+	if h < 0 {
+		return Peer{}, false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	b := &t.buckets[h]
+
+	if i := b.indexOf(p.ID); i >= 0 {
+		b.peers = append(append(b.peers[:i:i], b.peers[i+1:]...), p)
+		return Peer{}, false
+	}
+
+	if len(b.peers) >= t.k {
+		return b.peers[0], true
+	}
+
+	b.peers = append(b.peers, p)
+	b.lastRefresh = time.Now()
+
+	return Peer{}, false
+}
+
+// Replace evicts a peer in favour of another one of the same bucket.
+func (t *Table) Replace(old, p Peer) {
+	h := t.self.BucketHeight(old.ID)
+	if h < 0 || h != t.self.BucketHeight(p.ID) {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	b := &t.buckets[h]
+
+	i := b.indexOf(old.ID)
+	if i < 0 || b.indexOf(p.ID) >= 0 {
+		return
+	}
+
+	b.peers = append(append(b.peers[:i:i], b.peers[i+1:]...), p)
+}
+
+// Has reports whether the peer is in the table.
+func (t *Table) Has(id ID) bool {
+	h := t.self.BucketHeight(id)
+	if h < 0 {
+		return false
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.buckets[h].indexOf(id) >= 0
+}
+
+// Bucket returns up to n random peers of the bucket of the specified height.
+func (t *Table) Bucket(height, n int) []Peer {
+	t.lock.RLock()
+	peers := append([]Peer{}, t.buckets[height].peers...)
+	t.lock.RUnlock()
+
+	return sample(peers, n)
+}
+
+// Peers returns all the peers of the table.
+func (t *Table) Peers() []Peer {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	peers := make([]Peer, 0)
+	for i := range t.buckets {
+		peers = append(peers, t.buckets[i].peers...)
+	}
+
+	return peers
+}
+
+// Len returns the number of peers of the table.
+func (t *Table) Len() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	var n int
+	for i := range t.buckets {
+		n += len(t.buckets[i].peers)
+	}
+
+	return n
+}
+
+// Closest returns up to n peers sorted by XOR distance to the target.
+func (t *Table) Closest(target ID, n int) []Peer {
+	peers := t.Peers()
+
+	sort.Slice(peers, func(i, j int) bool {
+		return target.Closer(peers[i].ID, peers[j].ID)
+	})
+
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+
+	return peers
+}
+
+// StaleBuckets returns the heights of the buckets which have not been
+// refreshed for the specified duration and marks them as refreshed.
+func (t *Table) StaleBuckets(d time.Duration) []int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	heights := make([]int, 0)
+
+	for i := range t.buckets {
+		if now.Sub(t.buckets[i].lastRefresh) >= d {
+			t.buckets[i].lastRefresh = now
+			heights = append(heights, i)
+		}
+	}
+
+	return heights
+}
+
+// sample returns up to n random peers of the slice, which is shuffled.
+func sample(peers []Peer, n int) []Peer {
+	rand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+
+	if len(peers) > n {
+		peers = peers[:n]
+	}
+
+	return peers
+}
@@ -0,0 +1,116 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketHeight(t *testing.T) {
+	assert := assert.New(t)
+
+	var a, b ID
+	assert.Equal(-1, a.BucketHeight(b))
+
+	b[IDSize-1] = 1
+	assert.Equal(0, a.BucketHeight(b))
+
+	b[IDSize-1] = 0x80
+	assert.Equal(7, a.BucketHeight(b))
+
+	b[0] = 0x01
+	assert.Equal(BucketsNum-8, a.BucketHeight(b))
+
+	b[0] = 0x80
+	assert.Equal(BucketsNum-1, a.BucketHeight(b))
+
+	for h := 0; h < BucketsNum; h++ {
+		id := ComputeID("127.0.0.1:9000")
+		assert.Equal(h, id.BucketHeight(id.RandomAtHeight(h)))
+	}
+}
+
+func TestTableEviction(t *testing.T) {
+	assert := assert.New(t)
+
+	self := ComputeID("127.0.0.1:9000")
+	table := NewTable(self, 2)
+
+	// Group peers by bucket until one holds three of them
+	byHeight := make(map[int][]Peer)
+
+	var full []Peer
+
+	for port := 9001; full == nil; port++ {
+		p := NewPeer(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+		h := self.BucketHeight(p.ID)
+
+		byHeight[h] = append(byHeight[h], p)
+		if len(byHeight[h]) == 3 {
+			full = byHeight[h]
+		}
+	}
+
+	_, evict := table.Seen(full[0])
+	assert.False(evict)
+	_, evict = table.Seen(full[1])
+	assert.False(evict)
+
+	// The bucket is full: the least recently seen peer is the candidate
+	lru, evict := table.Seen(full[2])
+	assert.True(evict)
+	assert.Equal(full[0].ID, lru.ID)
+	assert.False(table.Has(full[2].ID))
+
+	// Seeing it again makes it the most recently seen
+	_, _ = table.Seen(full[0])
+	lru, _ = table.Seen(full[2])
+	assert.Equal(full[1].ID, lru.ID)
+
+	table.Replace(lru, full[2])
+	assert.False(table.Has(full[1].ID))
+	assert.True(table.Has(full[2].ID))
+	assert.Equal(2, table.Len())
+}
+
+func TestTableClosest(t *testing.T) {
+	assert := assert.New(t)
+
+	self := ComputeID("127.0.0.1:9000")
+	table := NewTable(self, DefaultBucketSize)
+
+	for port := 9001; port < 9101; port++ {
+		_, _ = table.Seen(NewPeer(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}))
+	}
+
+	target := ComputeID(fmt.Sprintf("127.0.0.1:%d", 10000))
+	closest := table.Closest(target, 10)
+	assert.Len(closest, 10)
+
+	for _, p := range table.Peers() {
+		// No peer left out is closer than the farthest one returned
+		in := false
+
+		for _, c := range closest {
+			if c.ID == p.ID {
+				in = true
+			}
+		}
+
+		if !in {
+			assert.False(target.Closer(p.ID, closest[9].ID))
+		}
+	}
+
+	assert.Empty(table.StaleBuckets(time.Hour))
+	assert.Len(table.StaleBuckets(0), BucketsNum)
+}
@@ -0,0 +1,218 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package routing
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Packet types.
+const (
+	packetPing byte = iota
+	packetPong
+	packetFindNodes
+	packetNodes
+	packetBroadcast
+	packetSend
+	// packetRaptor carries an rcudp packet.
+	packetRaptor
+)
+
+const (
+	// chunkSize is the max payload carried by a single packet, to avoid IP
+	// fragmentation with 1500 bytes MTU.
+	chunkSize = 1400
+
+	// header of a chunk: type, height, message ID, index and count.
+	chunkHeaderSize = 1 + 1 + 8 + 2 + 2
+
+	// maxChunks bounds the size of a message to the max wire frame size.
+	maxChunks = int((protocol.MaxFrameSize + chunkSize - 1) / chunkSize)
+
+	// maxPacketSize is the size of the read buffer.
+	maxPacketSize = 64 * 1024
+)
+
+var (
+	errMalformedPacket = errors.New("malformed packet")
+	errTooLarge        = errors.New("message too large")
+	errUnsolicited     = errors.New("unsolicited nodes packet")
+)
+
+// messageID identifies a message by its payload, so that the same message
+// received from several peers is delivered once.
+func messageID(payload []byte) uint64 {
+	h := blake2b.Sum256(payload)
+	return binary.LittleEndian.Uint64(h[:8])
+}
+
+// chunk is a part of a broadcast or point-to-point message.
+type chunk struct {
+	kind   byte
+	height byte
+	id     uint64
+	index  uint16
+	count  uint16
+	data   []byte
+}
+
+// encodeChunks splits a message into packets.
+func encodeChunks(kind, height byte, payload []byte) ([][]byte, error) {
+	count := (len(payload) + chunkSize - 1) / chunkSize
+	if count == 0 {
+		count = 1
+	}
+
+	if count > maxChunks {
+		return nil, errTooLarge
+	}
+
+	id := messageID(payload)
+	packets := make([][]byte, 0, count)
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		data := payload[i*chunkSize : end]
+
+		p := make([]byte, chunkHeaderSize, chunkHeaderSize+len(data))
+		p[0] = kind
+		p[1] = height
+		binary.LittleEndian.PutUint64(p[2:], id)
+		binary.LittleEndian.PutUint16(p[10:], uint16(i))
+		binary.LittleEndian.PutUint16(p[12:], uint16(count))
+
+		packets = append(packets, append(p, data...))
+	}
+
+	return packets, nil
+}
+
+func decodeChunk(p []byte) (chunk, error) {
+	if len(p) < chunkHeaderSize {
+		return chunk{}, errMalformedPacket
+	}
+
+	c := chunk{
+		kind:   p[0],
+		height: p[1],
+		id:     binary.LittleEndian.Uint64(p[2:]),
+		index:  binary.LittleEndian.Uint16(p[10:]),
+		count:  binary.LittleEndian.Uint16(p[12:]),
+		data:   p[chunkHeaderSize:],
+	}
+
+	if c.count == 0 || c.index >= c.count || len(c.data) > chunkSize {
+		return chunk{}, errMalformedPacket
+	}
+
+	if int(c.count) > maxChunks {
+		return chunk{}, errTooLarge
+	}
+
+	return c, nil
+}
+
+// encodePing returns a ping or pong packet.
+func encodePing(kind byte, nonce uint64) []byte {
+	p := make([]byte, 9)
+	p[0] = kind
+	binary.LittleEndian.PutUint64(p[1:], nonce)
+
+	return p
+}
+
+func decodePing(p []byte) (uint64, error) {
+	if len(p) != 9 {
+		return 0, errMalformedPacket
+	}
+
+	return binary.LittleEndian.Uint64(p[1:]), nil
+}
+
+func encodeFindNodes(target ID) []byte {
+	return append([]byte{packetFindNodes}, target[:]...)
+}
+
+func decodeFindNodes(p []byte) (ID, error) {
+	var target ID
+	if len(p) != 1+IDSize {
+		return target, errMalformedPacket
+	}
+
+	copy(target[:], p[1:])
+	return target, nil
+}
+
+// encodeNodes returns a nodes packet listing the address of the peers.
+func encodeNodes(peers []Peer) []byte {
+	p := []byte{packetNodes, byte(len(peers))}
+
+	for _, peer := range peers {
+		addr := peer.Addr.String()
+		p = append(p, byte(len(addr)))
+		p = append(p, addr...)
+	}
+
+	return p
+}
+
+func decodeNodes(p []byte) ([]*net.UDPAddr, error) {
+	if len(p) < 2 {
+		return nil, errMalformedPacket
+	}
+
+	count := int(p[1])
+	p = p[2:]
+
+	addrs := make([]*net.UDPAddr, 0, count)
+
+	for i := 0; i < count; i++ {
+		if len(p) < 1 || len(p) < 1+int(p[0]) {
+			return nil, errMalformedPacket
+		}
+
+		addr, err := parseUDPAddr(string(p[1 : 1+int(p[0])]))
+		if err != nil {
+			return nil, err
+		}
+
+		addrs = append(addrs, addr)
+		p = p[1+int(p[0]):]
+	}
+
+	return addrs, nil
+}
+
+// parseUDPAddr parses an IP literal address, without any name lookup.
+func parseUDPAddr(s string) (*net.UDPAddr, error) {
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return nil, errMalformedPacket
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, errMalformedPacket
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, errMalformedPacket
+	}
+
+	return &net.UDPAddr{IP: ip, Port: int(p)}, nil
+}
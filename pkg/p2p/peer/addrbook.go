@@ -0,0 +1,328 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package peer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus/capi"
+)
+
+const (
+	defaultReconnectPeers = 8
+	defaultMaxFailures    = 3
+	defaultMaxEntries     = 1024
+	// saveDelay is the delay of the persistence of the updates, so that a
+	// burst of them is written at once.
+	saveDelay = time.Second
+)
+
+// AddressBook records the peers met over the TCP gossip network, with their
+// last seen time, handshakes, failures, latency and bans, and persists them
+// into a file so that a restarted node reconnects to the known-good ones.
+// The inbound peers are not recorded, but for their bans, as they are not
+// reachable on the address they connect from.
+//
+// A nil AddressBook records nothing, so that the Connector can run without.
+type AddressBook struct {
+	lock  sync.Mutex
+	file  string
+	peers map[string]*capi.KnownPeerJSON
+
+	maxFailures uint32
+	maxEntries  int
+
+	// save is the pending persistence of the updates, if any.
+	save *time.Timer
+	// fileLock serializes the writes of the file.
+	fileLock sync.Mutex
+}
+
+// NewAddressBook returns the AddressBook persisted into file, or an empty
+// one if the file does not exist yet. If the file cannot be loaded, the
+// error is returned along with an empty AddressBook overwriting it.
+func NewAddressBook(file string) (*AddressBook, error) {
+	b := &AddressBook{
+		file:        file,
+		peers:       make(map[string]*capi.KnownPeerJSON),
+		maxFailures: defaultMaxFailures,
+		maxEntries:  defaultMaxEntries,
+	}
+
+	if n := config.Get().Network.AddressBook.MaxFailures; n > 0 {
+		b.maxFailures = uint32(n)
+	}
+
+	if n := config.Get().Network.AddressBook.MaxEntries; n > 0 {
+		b.maxEntries = int(n)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+
+	if err != nil {
+		return b, err
+	}
+
+	var peers []capi.KnownPeerJSON
+	if err := json.Unmarshal(data, &peers); err != nil {
+		return b, err
+	}
+
+	for i := range peers {
+		if len(b.peers) >= b.maxEntries {
+			b.evict()
+		}
+
+		b.peers[peers[i].Address] = &peers[i]
+	}
+
+	return b, nil
+}
+
+// Handshake records a successful handshake with a peer. id is the node key
+// of the peer on encrypted connections. Inbound peers are not recorded.
+func (b *AddressBook) Handshake(addr, id string, inbound bool, latency time.Duration) {
+	b.update(addr, !inbound, func(p *capi.KnownPeerJSON) {
+		p.ID = id
+		p.Inbound = inbound
+		p.LastSeen = time.Now()
+		p.Handshakes++
+		p.Failures = 0
+		p.Latency = latency
+	})
+}
+
+// Failure records a failed dial or handshake with a peer.
+func (b *AddressBook) Failure(addr string) {
+	b.update(addr, true, func(p *capi.KnownPeerJSON) {
+		p.Failures++
+	})
+}
+
+// Seen records the last time a recorded peer was connected.
+func (b *AddressBook) Seen(addr string) {
+	b.update(addr, false, func(p *capi.KnownPeerJSON) {
+		p.LastSeen = time.Now()
+	})
+}
+
+// Ban records the ban of a peer.
+func (b *AddressBook) Ban(addr string, until time.Time) {
+	b.update(addr, true, func(p *capi.KnownPeerJSON) {
+		p.BannedUntil = until
+	})
+}
+
+// Peers returns the entries of the address book.
+func (b *AddressBook) Peers() []capi.KnownPeerJSON {
+	if b == nil {
+		return nil
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	peers := make([]capi.KnownPeerJSON, 0, len(b.peers))
+	for _, p := range b.peers {
+		peers = append(peers, *p)
+	}
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Address < peers[j].Address
+	})
+
+	return peers
+}
+
+// Good returns up to n known-good peers to dial, the most recently seen
+// first. A known-good peer was dialed successfully, is not banned and did
+// not fail too many times in a row since.
+func (b *AddressBook) Good(n int) []string {
+	if b == nil {
+		return nil
+	}
+
+	b.lock.Lock()
+
+	now := time.Now()
+	good := make([]*capi.KnownPeerJSON, 0, len(b.peers))
+
+	for _, p := range b.peers {
+		if p.Inbound || p.Handshakes == 0 || p.Failures >= b.maxFailures || now.Before(p.BannedUntil) {
+			continue
+		}
+
+		good = append(good, p)
+	}
+
+	sort.Slice(good, func(i, j int) bool {
+		return good[i].LastSeen.After(good[j].LastSeen)
+	})
+
+	if len(good) > n {
+		good = good[:n]
+	}
+
+	addrs := make([]string, len(good))
+	for i, p := range good {
+		addrs[i] = p.Address
+	}
+
+	b.lock.Unlock()
+	return addrs
+}
+
+// Banned returns the bans still running, keyed by address.
+func (b *AddressBook) Banned() map[string]time.Time {
+	bans := make(map[string]time.Time)
+	if b == nil {
+		return bans
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := time.Now()
+
+	for _, p := range b.peers {
+		if now.Before(p.BannedUntil) {
+			bans[p.Address] = p.BannedUntil
+		}
+	}
+
+	return bans
+}
+
+// Flush persists the pending updates, if any.
+func (b *AddressBook) Flush() error {
+	if b == nil {
+		return nil
+	}
+
+	b.fileLock.Lock()
+	defer b.fileLock.Unlock()
+
+	b.lock.Lock()
+
+	if b.save == nil {
+		b.lock.Unlock()
+		return nil
+	}
+
+	b.save.Stop()
+	b.save = nil
+
+	peers := make([]capi.KnownPeerJSON, 0, len(b.peers))
+	for _, p := range b.peers {
+		peers = append(peers, *p)
+	}
+
+	b.lock.Unlock()
+
+	return b.write(peers)
+}
+
+// update applies fn to the entry of the peer, created if needed and create is
+// true, and schedules the persistence of the address book. Peers which never
+// completed a handshake are forgotten once they are no longer known-good nor
+// banned, so that unreachable addresses do not pile up.
+func (b *AddressBook) update(addr string, create bool, fn func(p *capi.KnownPeerJSON)) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	p, ok := b.peers[addr]
+	if !ok {
+		if !create {
+			return
+		}
+
+		if len(b.peers) >= b.maxEntries {
+			b.evict()
+		}
+
+		p = &capi.KnownPeerJSON{Address: addr}
+		b.peers[addr] = p
+	}
+
+	fn(p)
+
+	if p.Handshakes == 0 && p.Failures >= b.maxFailures && time.Now().After(p.BannedUntil) {
+		delete(b.peers, addr)
+	}
+
+	if b.save == nil {
+		b.save = time.AfterFunc(saveDelay, func() {
+			if err := b.Flush(); err != nil {
+				plog.WithError(err).WithField("file", b.file).
+					Warn("could not persist address book")
+			}
+		})
+	}
+}
+
+// evict forgets the least recently seen peer, sparing the banned ones if
+// possible.
+// The lock must be held.
+func (b *AddressBook) evict() {
+	var oldest *capi.KnownPeerJSON
+
+	now := time.Now()
+
+	for _, p := range b.peers {
+		if oldest == nil {
+			oldest = p
+			continue
+		}
+
+		banned, oldestBanned := now.Before(p.BannedUntil), now.Before(oldest.BannedUntil)
+
+		if banned != oldestBanned {
+			if oldestBanned {
+				oldest = p
+			}
+
+			continue
+		}
+
+		if p.LastSeen.Before(oldest.LastSeen) {
+			oldest = p
+		}
+	}
+
+	if oldest != nil {
+		delete(b.peers, oldest.Address)
+	}
+}
+
+// write writes the address book aside and then renames it, to avoid leaving
+// a truncated file behind.
+func (b *AddressBook) write(peers []capi.KnownPeerJSON) error {
+	data, err := json.MarshalIndent(peers, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := b.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, b.file)
+}
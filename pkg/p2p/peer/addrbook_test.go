@@ -0,0 +1,187 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package peer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+)
+
+// Test that the address book is persisted and selects the known-good peers.
+func TestAddressBook(t *testing.T) {
+	assert := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "addrbook.json")
+
+	b, err := NewAddressBook(file)
+	assert.NoError(err)
+
+	b.Handshake("10.0.0.1:7000", "", false, time.Millisecond)
+	b.Handshake("10.0.0.2:7000", "", false, time.Millisecond)
+	b.Handshake("10.0.0.3:7000", "", false, time.Millisecond)
+	b.Handshake("10.0.0.4:52000", "", true, time.Millisecond)
+
+	// Failing too many times in a row
+	for i := 0; i < defaultMaxFailures; i++ {
+		b.Failure("10.0.0.2:7000")
+	}
+
+	b.Ban("10.0.0.3:7000", time.Now().Add(time.Hour))
+
+	// Never reachable addresses are forgotten
+	for i := 0; i < defaultMaxFailures; i++ {
+		b.Failure("10.0.0.5:7000")
+	}
+
+	// Inbound peers are not recorded
+	b.Seen("10.0.0.4:52000")
+
+	// Reload from the file
+	assert.NoError(b.Flush())
+
+	b, err = NewAddressBook(file)
+	assert.NoError(err)
+
+	assert.Len(b.Peers(), 3)
+	assert.Equal([]string{"10.0.0.1:7000"}, b.Good(8))
+	assert.Contains(b.Banned(), "10.0.0.3:7000")
+
+	p := b.Peers()[0]
+	assert.Equal("10.0.0.1:7000", p.Address)
+	assert.Equal(uint32(1), p.Handshakes)
+	assert.Equal(time.Millisecond, p.Latency)
+
+	// A successful handshake resets the failures
+	b.Handshake("10.0.0.2:7000", "", false, time.Millisecond)
+	assert.Equal([]string{"10.0.0.2:7000", "10.0.0.1:7000"}, b.Good(8))
+	assert.Equal([]string{"10.0.0.2:7000"}, b.Good(1))
+}
+
+// Test that a Connector reconnects at startup to the known-good peers of its
+// address book.
+func TestConnectKnown(t *testing.T) {
+	assert := require.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	connectFn := func(ctx context.Context, r *Reader, w *Writer) {
+		r.ReadLoop(ctx, nil)
+	}
+
+	// Remote peer, without address book
+	remote := NewConnector(eventbus.New(), protocol.NewGossip(), "0", NewMessageProcessor(eventbus.New()), protocol.FullNode, connectFn)
+	defer remote.Close()
+
+	addr := "127.0.0.1:" + strconv.Itoa(remote.l.Addr().(*net.TCPAddr).Port)
+
+	file := filepath.Join(t.TempDir(), "addrbook.json")
+
+	b, err := NewAddressBook(file)
+	assert.NoError(err)
+
+	b.Handshake(addr, "", false, time.Millisecond)
+	b.Handshake("127.0.0.1:1", "", false, time.Millisecond)
+	b.Ban("127.0.0.2:7000", time.Now().Add(time.Hour))
+
+	cfg := r
+	cfg.Network.AddressBook.File = file
+	config.Mock(&cfg)
+
+	eb := eventbus.New()
+	processor := NewMessageProcessor(eb)
+
+	assert.NoError(b.Flush())
+
+	c := NewConnector(eb, protocol.NewGossip(), "0", processor, protocol.FullNode, connectFn)
+	defer c.Close()
+
+	// Persisted bans are restored
+	assert.True(processor.Scores().Banned("127.0.0.2:7000"))
+
+	// Known peers are dialed in the background
+	assert.Eventually(func() bool {
+		return c.GetConnectionsCount() == 1 && failures(c.book, "127.0.0.1:1") == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(c.book.Flush())
+
+	b, err = NewAddressBook(file)
+	assert.NoError(err)
+
+	for _, p := range b.Peers() {
+		switch p.Address {
+		case addr:
+			assert.Equal(uint32(2), p.Handshakes)
+			assert.Zero(p.Failures)
+		case "127.0.0.1:1":
+			assert.Equal(uint32(1), p.Failures)
+		}
+	}
+}
+
+func failures(b *AddressBook, addr string) uint32 {
+	for _, p := range b.Peers() {
+		if p.Address == addr {
+			return p.Failures
+		}
+	}
+
+	return 0
+}
+
+// Test that the address book is bounded, and persisted once per burst of
+// updates.
+func TestAddressBookBounds(t *testing.T) {
+	assert := require.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	cfg := r
+	cfg.Network.AddressBook.MaxEntries = 3
+	config.Mock(&cfg)
+
+	file := filepath.Join(t.TempDir(), "addrbook.json")
+
+	b, err := NewAddressBook(file)
+	assert.NoError(err)
+
+	b.Ban("10.0.0.1:7000", time.Now().Add(time.Hour))
+
+	for i := 2; i <= 5; i++ {
+		b.Handshake(fmt.Sprintf("10.0.0.%d:7000", i), "", false, time.Millisecond)
+	}
+
+	// The least recently seen peer is evicted, but the banned one
+	peers := b.Peers()
+	assert.Len(peers, 3)
+	assert.Equal("10.0.0.1:7000", peers[0].Address)
+	assert.Equal("10.0.0.4:7000", peers[1].Address)
+	assert.Equal("10.0.0.5:7000", peers[2].Address)
+
+	// The updates are written once delayed
+	_, err = os.Stat(file)
+	assert.True(os.IsNotExist(err))
+
+	assert.Eventually(func() bool {
+		_, err := os.Stat(file)
+		return err == nil
+	}, 2*saveDelay, 10*time.Millisecond)
+}
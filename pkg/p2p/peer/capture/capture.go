@@ -0,0 +1,364 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+// Package capture records the inbound wire messages into rotating capture
+// files, and replays them.
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+)
+
+// captureVersion is the version of the capture file format.
+const captureVersion uint8 = 1
+
+// maxRecordSize bounds the size of a record read from a capture file.
+const maxRecordSize = 2 * protocol.MaxFrameSize
+
+// ErrCaptureVersion capture file is written in an unsupported format.
+var ErrCaptureVersion = errors.New("unsupported capture version")
+
+// Record is an inbound message, as handed to the MessageProcessor.
+type Record struct {
+	Time     time.Time
+	Source   string
+	Services protocol.ServiceFlag
+	// Metadata is nil for the messages received over the TCP gossip
+	Metadata *message.Metadata
+	Packet   []byte
+}
+
+// Writer appends records to a capture file. Once the file exceeds maxSize,
+// it is rotated: file.1 holds the previous records, file.2 the ones before,
+// up to maxFiles rotated files.
+type Writer struct {
+	lock     sync.Mutex
+	file     string
+	maxSize  int64
+	maxFiles int
+
+	f    *os.File
+	size int64
+}
+
+// NewWriter creates a Writer appending to file.
+func NewWriter(file string, maxSize int64, maxFiles int) (*Writer, error) {
+	w := &Writer{
+		file:     file,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write a record to the capture file.
+func (w *Writer) Write(r Record) error {
+	buf := new(bytes.Buffer)
+	if err := marshalRecord(buf, r); err != nil {
+		return err
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.f == nil {
+		return os.ErrClosed
+	}
+
+	if w.size > 0 && w.size+int64(buf.Len()) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.f.Write(buf.Bytes())
+	w.size += int64(n)
+
+	return err
+}
+
+// Close the capture file.
+func (w *Writer) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.f == nil {
+		return nil
+	}
+
+	err := w.f.Close()
+	w.f = nil
+
+	return err
+}
+
+// open the capture file, writing the version of a new file.
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	w.f = f
+	w.size = info.Size()
+
+	if w.size == 0 {
+		n, err := f.Write([]byte{captureVersion})
+		if err != nil {
+			return err
+		}
+
+		w.size = int64(n)
+	}
+
+	return nil
+}
+
+// rotate shifts the rotated files, dropping the oldest one, and opens a new
+// capture file.
+func (w *Writer) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+
+	w.f = nil
+
+	if w.maxFiles > 0 {
+		for i := w.maxFiles - 1; i > 0; i-- {
+			_ = os.Rename(rotatedName(w.file, i), rotatedName(w.file, i+1))
+		}
+
+		if err := os.Rename(w.file, rotatedName(w.file, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(w.file); err != nil {
+		return err
+	}
+
+	return w.open()
+}
+
+func rotatedName(file string, i int) string {
+	return fmt.Sprintf("%s.%d", file, i)
+}
+
+// Reader reads the records of a capture file.
+type Reader struct {
+	f *os.File
+	r *bufio.Reader
+}
+
+// Open a capture file.
+func Open(file string) (*Reader, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{f: f, r: bufio.NewReader(f)}
+
+	v, err := r.r.ReadByte()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if v != captureVersion {
+		_ = f.Close()
+		return nil, ErrCaptureVersion
+	}
+
+	return r, nil
+}
+
+// Next returns the next record, or io.EOF at the end of the file.
+func (r *Reader) Next() (*Record, error) {
+	var size uint32
+	if err := binary.Read(r.r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+
+	if uint64(size) > maxRecordSize {
+		return nil, fmt.Errorf("invalid record size %d", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	return unmarshalRecord(bytes.NewBuffer(data))
+}
+
+// Close the capture file.
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
+
+// Replay hands the records of a capture file to fn, spacing them as when
+// they were recorded, divided by speed. A speed of 0 replays them without
+// delay. Errors returned by fn do not stop the replay.
+func Replay(ctx context.Context, r *Reader, speed float64, fn func(*Record) error) (int, error) {
+	var (
+		n     int
+		first time.Time
+		start = time.Now()
+	)
+
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		if first.IsZero() {
+			first = rec.Time
+		}
+
+		if speed > 0 {
+			at := start.Add(time.Duration(float64(rec.Time.Sub(first)) / speed))
+
+			select {
+			case <-ctx.Done():
+				return n, ctx.Err()
+			case <-time.After(time.Until(at)):
+			}
+		} else if ctx.Err() != nil {
+			return n, ctx.Err()
+		}
+
+		_ = fn(rec)
+		n++
+	}
+}
+
+// marshalRecord encodes a record, prefixed by its size.
+func marshalRecord(w *bytes.Buffer, r Record) error {
+	buf := new(bytes.Buffer)
+
+	if err := encoding.WriteUint64LE(buf, uint64(r.Time.UnixNano())); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteString(buf, r.Source); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint64LE(buf, uint64(r.Services)); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteBool(buf, r.Metadata != nil); err != nil {
+		return err
+	}
+
+	if r.Metadata != nil {
+		if err := encoding.WriteUint8(buf, r.Metadata.KadcastHeight); err != nil {
+			return err
+		}
+
+		if err := encoding.WriteString(buf, r.Metadata.Source); err != nil {
+			return err
+		}
+
+		if err := encoding.WriteUint8(buf, r.Metadata.NumNodes); err != nil {
+			return err
+		}
+	}
+
+	if err := encoding.WriteVarBytes(buf, r.Packet); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint32LE(w, uint32(buf.Len())); err != nil {
+		return err
+	}
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func unmarshalRecord(r *bytes.Buffer) (*Record, error) {
+	rec := &Record{}
+
+	var ts uint64
+	if err := encoding.ReadUint64LE(r, &ts); err != nil {
+		return nil, err
+	}
+
+	rec.Time = time.Unix(0, int64(ts))
+
+	var err error
+	if rec.Source, err = encoding.ReadString(r); err != nil {
+		return nil, err
+	}
+
+	var services uint64
+	if err := encoding.ReadUint64LE(r, &services); err != nil {
+		return nil, err
+	}
+
+	rec.Services = protocol.ServiceFlag(services)
+
+	var hasMetadata bool
+	if err := encoding.ReadBool(r, &hasMetadata); err != nil {
+		return nil, err
+	}
+
+	if hasMetadata {
+		rec.Metadata = &message.Metadata{}
+
+		if err := encoding.ReadUint8(r, &rec.Metadata.KadcastHeight); err != nil {
+			return nil, err
+		}
+
+		if rec.Metadata.Source, err = encoding.ReadString(r); err != nil {
+			return nil, err
+		}
+
+		if err := encoding.ReadUint8(r, &rec.Metadata.NumNodes); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoding.ReadVarBytes(r, &rec.Packet); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,128 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package capture
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+)
+
+func TestWriteRead(t *testing.T) {
+	assert := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "dusk.cap")
+
+	w, err := NewWriter(file, 1<<20, 2)
+	assert.NoError(err)
+
+	now := time.Now()
+	recs := []Record{
+		{Time: now, Source: "127.0.0.1:7000", Services: protocol.FullNode, Packet: []byte{1, 2, 3}},
+		{Time: now.Add(time.Second), Source: "10.0.0.1:7100", Services: protocol.FullNode, Metadata: &message.Metadata{KadcastHeight: 4, Source: "10.0.0.1:7100", NumNodes: 2}, Packet: []byte{4}},
+	}
+
+	for _, rec := range recs {
+		assert.NoError(w.Write(rec))
+	}
+
+	assert.NoError(w.Close())
+
+	r, err := Open(file)
+	assert.NoError(err)
+
+	defer r.Close()
+
+	for _, rec := range recs {
+		read, err := r.Next()
+		assert.NoError(err)
+
+		assert.True(rec.Time.Equal(read.Time))
+		assert.Equal(rec.Source, read.Source)
+		assert.Equal(rec.Services, read.Services)
+		assert.Equal(rec.Metadata, read.Metadata)
+		assert.Equal(rec.Packet, read.Packet)
+	}
+
+	_, err = r.Next()
+	assert.Equal(io.EOF, err)
+}
+
+func TestRotate(t *testing.T) {
+	assert := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "dusk.cap")
+
+	// Each record takes a file
+	w, err := NewWriter(file, 64, 2)
+	assert.NoError(err)
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(w.Write(Record{Time: time.Now(), Source: "peer", Packet: make([]byte, 40)}))
+	}
+
+	assert.NoError(w.Close())
+
+	for _, f := range []string{file, file + ".1", file + ".2"} {
+		r, err := Open(f)
+		assert.NoError(err)
+
+		n, err := Replay(context.Background(), r, 0, func(*Record) error { return nil })
+		assert.NoError(err)
+		assert.Equal(1, n)
+
+		_ = r.Close()
+	}
+
+	_, err = os.Stat(file + ".3")
+	assert.True(os.IsNotExist(err))
+}
+
+func TestReplaySpeed(t *testing.T) {
+	assert := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "dusk.cap")
+
+	w, err := NewWriter(file, 1<<20, 0)
+	assert.NoError(err)
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(w.Write(Record{Time: now.Add(time.Duration(i) * time.Second), Packet: []byte{byte(i)}}))
+	}
+
+	assert.NoError(w.Close())
+
+	r, err := Open(file)
+	assert.NoError(err)
+
+	defer r.Close()
+
+	// 2 seconds recorded, replayed 10 times faster
+	start := time.Now()
+	var packets []byte
+
+	n, err := Replay(context.Background(), r, 10, func(rec *Record) error {
+		packets = append(packets, rec.Packet...)
+		return nil
+	})
+
+	assert.NoError(err)
+	assert.Equal(3, n)
+	assert.Equal([]byte{0, 1, 2}, packets)
+
+	elapsed := time.Since(start)
+	assert.True(elapsed >= 200*time.Millisecond && elapsed < time.Second, elapsed.String())
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus/capi"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
@@ -32,6 +33,9 @@ const (
 
 var plog = logrus.WithField("process", "peer_conn")
 
+// ErrBanned is returned when connecting to a banned peer.
+var ErrBanned = errors.New("peer is banned")
+
 type connectFunc func(context.Context, *Reader, *Writer)
 
 // Connector is responsible for accepting incoming connection requests, and
@@ -43,8 +47,16 @@ type Connector struct {
 
 	l net.Listener
 
-	lock     sync.RWMutex
-	registry map[string]struct{}
+	lock sync.RWMutex
+	// registry of the connections, keyed by srcPeerID
+	registry map[string]net.Conn
+	scores   *score.Board
+	book     *AddressBook
+
+	// key identifies the node on the encrypted connections. It is nil if
+	// encryption is disabled
+	key        *NodeKey
+	encryption string
 
 	services protocol.ServiceFlag
 
@@ -70,19 +82,53 @@ func NewConnector(eb eventbus.Broker, gossip *protocol.Gossip, port string,
 		gossip:        gossip,
 		readerFactory: NewReaderFactory(processor),
 		l:             listener,
-		registry:      make(map[string]struct{}),
+		registry:      make(map[string]net.Conn),
+		scores:        processor.Scores(),
 		services:      services,
 		connectFunc:   connectFunc,
 	}
 
 	processor.Register(topics.Addr, c.ProcessNewAddress)
+	c.scores.OnDisconnect(c.disconnect)
+
+	enc := config.Get().Network.Encryption
+
+	c.encryption = enc.Mode
+	switch c.encryption {
+	case "", EncryptionDisabled:
+		c.encryption = EncryptionDisabled
+	case EncryptionOptional, EncryptionRequired:
+		c.key, err = LoadNodeKey(enc.KeyFile)
+		if err != nil {
+			plog.WithError(err).Fatal("could not load node key")
+		}
+
+		plog.WithField("id", c.key.ID()).
+			WithField("mode", c.encryption).
+			Infoln("peer connections encrypted")
+	default:
+		plog.WithField("mode", c.encryption).Fatal("unknown encryption mode")
+	}
+
+	if file := config.Get().Network.AddressBook.File; len(file) > 0 {
+		c.book, err = NewAddressBook(file)
+		if err != nil {
+			plog.WithError(err).WithField("file", file).
+				Warn("could not load address book")
+		}
+
+		for addr, until := range c.book.Banned() {
+			c.scores.Ban(addr, until)
+		}
+
+		capi.SetAddressBook(c.book.Peers)
+	}
 
 	go func(c *Connector) {
 		for {
 			conn, err := c.l.Accept()
 			if err != nil {
-				plog.WithField("r_addr", conn.RemoteAddr().String()).
-					WithError(err).
+				plog.WithError(err).
 					Warnln("error accepting conn request")
 				return
 			}
@@ -95,11 +141,57 @@ func NewConnector(eb eventbus.Broker, gossip *protocol.Gossip, port string,
 		go c.logPeerCount()
 	}
 
+	// Known-good peers are dialed in the background, along with the voucher
+	// seeder
+	go c.ConnectKnown()
 	return c
 }
 
-// Close the listener.
+// ConnectKnown dials the known-good peers of the address book, and returns
+// the number of established connections.
+func (c *Connector) ConnectKnown() int {
+	n := defaultReconnectPeers
+	if r := config.Get().Network.AddressBook.Reconnect; r > 0 {
+		n = int(r)
+	}
+
+	addrs := c.book.Good(n)
+	if len(addrs) == 0 {
+		return 0
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(len(addrs))
+
+	for _, addr := range addrs {
+		go func(addr string) {
+			defer wg.Done()
+
+			if err := c.Connect(addr); err != nil {
+				plog.WithField("r_addr", addr).WithError(err).
+					Debugln("could not reconnect to known peer")
+			}
+		}(addr)
+	}
+
+	wg.Wait()
+
+	connected := c.GetConnectionsCount()
+
+	plog.WithField("known", len(addrs)).
+		WithField("connected", connected).
+		Infoln("reconnected to known peers")
+
+	return connected
+}
+
+// Close the listener, and persist the address book.
 func (c *Connector) Close() error {
+	if err := c.book.Flush(); err != nil {
+		plog.WithError(err).Warn("could not persist address book")
+	}
+
 	return c.l.Close()
 }
 
@@ -111,10 +203,10 @@ func (c *Connector) logPeerCount() {
 
 		store := capi.GetStormDBInstance()
 
-		for addr := range c.registry {
+		for _, conn := range c.registry {
 			// save count
 			peerCount := capi.PeerCount{
-				ID:       addr,
+				ID:       conn.RemoteAddr().String(),
 				LastSeen: time.Now(),
 			}
 
@@ -144,15 +236,56 @@ func (c *Connector) ProcessNewAddress(srcPeerID string, m message.Message) ([]by
 // Connect dials a connection with its string, then on succession
 // we pass the connection and the address to the OnConn method.
 func (c *Connector) Connect(addr string) error {
+	if c.scores.Banned(addr) {
+		return ErrBanned
+	}
+
 	conn, err := c.Dial(addr)
 	if err != nil {
+		c.book.Failure(addr)
+		return err
+	}
+
+	conn, id, err := c.secure(addr, conn)
+	if err != nil {
+		c.book.Failure(addr)
 		return err
 	}
 
-	c.proposeConnection(conn)
+	c.proposeConnection(conn, id)
 	return nil
 }
 
+// secure runs the encryption handshake on an outbound connection, if
+// enabled, and returns the connection along with the identity of the peer.
+// In optional mode, the peers failing the handshake are redialed in clear
+// text.
+func (c *Connector) secure(addr string, conn net.Conn) (net.Conn, string, error) {
+	if c.key == nil {
+		return conn, "", nil
+	}
+
+	sconn, id, err := c.key.Client(conn)
+	if err == nil {
+		if c.scores.Banned(id) {
+			_ = sconn.Close()
+			return nil, "", ErrBanned
+		}
+
+		return sconn, id, nil
+	}
+
+	if c.encryption != EncryptionOptional {
+		return nil, "", err
+	}
+
+	plog.WithField("r_addr", addr).WithError(err).
+		Debugln("encryption handshake failed, falling back to clear text")
+
+	conn, err = c.Dial(addr)
+	return conn, "", err
+}
+
 // Dial dials up a connection, given its address string.
 func (c *Connector) Dial(addr string) (net.Conn, error) {
 	t := defaultDialTimeout
@@ -168,9 +301,32 @@ func (c *Connector) Dial(addr string) (net.Conn, error) {
 }
 
 func (c *Connector) acceptConnection(conn net.Conn) {
+	raddr := conn.RemoteAddr().String()
+
+	if c.scores.Banned(raddr) {
+		plog.WithField("r_addr", raddr).WithField("type", "inbound").
+			Debugln("refused banned peer")
+
+		_ = conn.Close()
+		return
+	}
+
+	start := time.Now()
+
+	conn, id, err := c.secureInbound(conn)
+	if err != nil {
+		plog.WithField("r_addr", raddr).
+			WithError(err).
+			WithField("type", "inbound").
+			Warnln("error performing encryption handshake")
+
+		_ = conn.Close()
+		return
+	}
+
 	pConn := NewConnection(conn, c.gossip)
+	pConn.id = id
 	peerReader := c.readerFactory.SpawnReader(pConn)
-	raddr := conn.RemoteAddr().String()
 
 	if err := peerReader.Accept(c.services); err != nil {
 		plog.WithField("r_addr", raddr).
@@ -183,24 +339,61 @@ func (c *Connector) acceptConnection(conn net.Conn) {
 	plog.WithField("r_addr", raddr).WithField("type", "inbound").
 		Infoln("peer_connection established")
 
-	c.addPeer(peerReader.Addr())
+	c.addPeer(pConn, true, time.Since(start))
 
 	peerWriter := NewWriter(pConn, c.eventBus)
 
 	go func() {
 		c.connectFunc(context.Background(), peerReader, peerWriter)
-		c.removePeer(peerReader.Addr())
+		c.removePeer(pConn)
 	}()
 }
 
-func (c *Connector) proposeConnection(conn net.Conn) {
+// secureInbound runs the encryption handshake on an inbound connection
+// opened with one, and returns the connection along with the identity of
+// the peer. Connections in clear text are refused if encryption is required.
+func (c *Connector) secureInbound(conn net.Conn) (net.Conn, string, error) {
+	if c.key == nil {
+		return conn, "", nil
+	}
+
+	pconn, secure, err := peekSecure(conn)
+	if err != nil {
+		return conn, "", err
+	}
+
+	if !secure {
+		if c.encryption == EncryptionRequired {
+			return conn, "", ErrEncryptionRequired
+		}
+
+		return pconn, "", nil
+	}
+
+	sconn, id, err := c.key.Server(pconn)
+	if err != nil {
+		return conn, "", err
+	}
+
+	if c.scores.Banned(id) {
+		return sconn, "", ErrBanned
+	}
+
+	return sconn, id, nil
+}
+
+func (c *Connector) proposeConnection(conn net.Conn, id string) {
 	pConn := NewConnection(conn, c.gossip)
+	pConn.id = id
 	peerWriter := NewWriter(pConn, c.eventBus)
+	start := time.Now()
 
 	if err := peerWriter.Connect(c.services); err != nil {
 		plog.WithField("r_addr", conn.RemoteAddr().String()).
 			WithField("type", "outbound").
 			WithError(err).Warnln("error performing handshake")
+
+		c.book.Failure(conn.RemoteAddr().String())
 		return
 	}
 
@@ -211,26 +404,56 @@ func (c *Connector) proposeConnection(conn net.Conn) {
 
 	peerReader := c.readerFactory.SpawnReader(pConn)
 
-	c.addPeer(peerWriter.Addr())
+	c.addPeer(pConn, false, time.Since(start))
 
 	go func() {
 		c.connectFunc(context.Background(), peerReader, peerWriter)
-		c.removePeer(peerWriter.Addr())
+		c.removePeer(pConn)
 	}()
 }
 
-func (c *Connector) addPeer(address string) {
+func (c *Connector) addPeer(pConn *Connection, inbound bool, latency time.Duration) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	c.registry[pConn.ID()] = pConn.Conn
+	c.lock.Unlock()
 
-	c.registry[address] = struct{}{}
+	c.book.Handshake(pConn.Addr(), pConn.id, inbound, latency)
 }
 
-func (c *Connector) removePeer(address string) {
+// disconnect closes the connection with a misbehaving peer. The peer is
+// removed from the registry once its read loop terminates.
+func (c *Connector) disconnect(peerID string) {
+	c.lock.RLock()
+	conn, ok := c.registry[peerID]
+	c.lock.RUnlock()
+
+	address := peerID
+
+	if ok {
+		address = conn.RemoteAddr().String()
+		_ = conn.Close()
+	}
+
+	if until := c.scores.BannedUntil(peerID); !until.IsZero() {
+		// An encrypted peer is banned by its node key, which it can replace
+		// at will. Its host is banned as well, and checked before the
+		// encryption handshake
+		if address != peerID {
+			c.scores.Ban(address, until)
+		}
+
+		c.book.Ban(address, until)
+	}
+}
+
+func (c *Connector) removePeer(pConn *Connection) {
+	address := pConn.Addr()
+	c.book.Seen(address)
+
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	delete(c.registry, address)
+	delete(c.registry, pConn.ID())
 
 	if config.Get().API.Enabled {
 		go func() {
@@ -0,0 +1,179 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package dupemap
+
+import (
+	"bytes"
+	"hash/maphash"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFalsePositiveRate = 0.001
+	defaultGenerations       = 2
+)
+
+// bloom is a bloom filter.
+type bloom struct {
+	bits  []uint64
+	items uint32
+}
+
+// RotatingFilter is a duplicate filter made of generations of bloom
+// filters. New payloads are added to the current generation, which is
+// rotated once it holds capacity items or is older than expire seconds. The
+// oldest generation is then dropped.
+//
+// Its memory footprint is fixed, and a payload is remembered for at least
+// (generations-1) * expire seconds, unless capacity forces the rotation.
+// As with any bloom filter, false positives are possible: a new payload is
+// taken for a duplicate with the configured rate. False negatives are not.
+type RotatingFilter struct {
+	lock sync.Mutex
+
+	capacity    uint32
+	expire      time.Duration
+	generations []*bloom
+	rotated     time.Time
+
+	// m is the number of bits and k the number of hashes of each filter
+	m uint64
+	k uint64
+
+	seed1, seed2 maphash.Seed
+}
+
+// NewRotatingFilter creates a RotatingFilter of the given number of
+// generations, each holding up to capacity items, with an overall false
+// positive rate of fpRate. Expire is number of seconds.
+func NewRotatingFilter(expire int64, capacity uint32, fpRate float64, generations int) *RotatingFilter {
+	if generations < 2 {
+		generations = 2
+	}
+
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = defaultFalsePositiveRate
+	}
+
+	if capacity == 0 {
+		capacity = 1
+	}
+
+	// A lookup checks every generation, each with its own false positives
+	p := fpRate / float64(generations)
+	n := float64(capacity)
+
+	m := uint64(math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+
+	k := uint64(math.Round(float64(m) / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	f := &RotatingFilter{
+		capacity:    capacity,
+		expire:      time.Duration(expire) * time.Second,
+		generations: make([]*bloom, generations),
+		rotated:     time.Now(),
+		m:           m,
+		k:           k,
+		seed1:       maphash.MakeSeed(),
+		seed2:       maphash.MakeSeed(),
+	}
+
+	for i := range f.generations {
+		f.generations[i] = &bloom{bits: make([]uint64, m/64)}
+	}
+
+	return f
+}
+
+// HasAnywhere returns true if the payload was not seen yet by any
+// generation, and adds it to the current one. It returns false for a
+// duplicate.
+func (f *RotatingFilter) HasAnywhere(payload *bytes.Buffer) bool {
+	h1, h2 := f.hash(payload.Bytes())
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, g := range f.generations {
+		if f.lookup(g, h1, h2) {
+			return false
+		}
+	}
+
+	current := f.generations[0]
+	if current.items >= f.capacity || time.Since(f.rotated) >= f.expire {
+		current = f.rotate()
+	}
+
+	f.insert(current, h1, h2)
+	return true
+}
+
+// Size returns the bytes count allocated by the filters.
+func (f *RotatingFilter) Size() int {
+	return len(f.generations) * int(f.m/8)
+}
+
+// rotate drops the oldest generation and returns the new current one.
+func (f *RotatingFilter) rotate() *bloom {
+	last := len(f.generations) - 1
+
+	oldest := f.generations[last]
+	for i := range oldest.bits {
+		oldest.bits[i] = 0
+	}
+
+	oldest.items = 0
+
+	copy(f.generations[1:], f.generations[:last])
+	f.generations[0] = oldest
+	f.rotated = time.Now()
+
+	return oldest
+}
+
+// hash returns the two hashes of the payload from which the k bit indexes
+// are derived.
+func (f *RotatingFilter) hash(b []byte) (uint64, uint64) {
+	var h maphash.Hash
+
+	h.SetSeed(f.seed1)
+	_, _ = h.Write(b)
+	h1 := h.Sum64()
+
+	h.SetSeed(f.seed2)
+	_, _ = h.Write(b)
+	h2 := h.Sum64()
+
+	return h1, h2 | 1
+}
+
+func (f *RotatingFilter) lookup(g *bloom, h1, h2 uint64) bool {
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		if g.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *RotatingFilter) insert(g *bloom, h1, h2 uint64) {
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		g.bits[idx/64] |= 1 << (idx % 64)
+	}
+
+	g.items++
+}
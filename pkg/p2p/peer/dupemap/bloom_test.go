@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package dupemap_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/dupemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func payload(i uint32) *bytes.Buffer {
+	d := make([]byte, 4)
+	binary.BigEndian.PutUint32(d, i)
+	return bytes.NewBuffer(d)
+}
+
+func TestRotatingFilterHasAnywhere(t *testing.T) {
+	// A low rate keeps false positives out of the expected results
+	f := dupemap.NewRotatingFilter(5, 100, 1e-9, 2)
+
+	for i, tt := range dupeFilterTests {
+		test := make([]byte, 2)
+		binary.BigEndian.PutUint16(test, tt.data)
+
+		res := f.HasAnywhere(bytes.NewBuffer(test))
+		if !assert.Equal(t, tt.canFwd, res) {
+			assert.FailNowf(t, "failure", "RotatingFilter.HasAnywhere: expected %t, got %t, index %d", res, tt.canFwd, i)
+		}
+	}
+}
+
+// Test that the oldest generation is dropped once the newer ones are full.
+func TestRotatingFilterRotation(t *testing.T) {
+	f := dupemap.NewRotatingFilter(60, 10, 1e-9, 2)
+	size := f.Size()
+
+	for i := uint32(0); i < 10; i++ {
+		assert.True(t, f.HasAnywhere(payload(i)))
+	}
+
+	// The first generation is full, the next items go to the second one
+	for i := uint32(10); i < 20; i++ {
+		assert.True(t, f.HasAnywhere(payload(i)))
+	}
+
+	// Both generations are remembered
+	assert.False(t, f.HasAnywhere(payload(0)))
+	assert.False(t, f.HasAnywhere(payload(19)))
+
+	// A new item drops the first generation
+	assert.True(t, f.HasAnywhere(payload(20)))
+	assert.True(t, f.HasAnywhere(payload(0)))
+	assert.False(t, f.HasAnywhere(payload(19)))
+
+	// The memory footprint is fixed
+	assert.Equal(t, size, f.Size())
+}
+
+func TestRotatingFilterFalsePositiveRate(t *testing.T) {
+	itemsCount := uint32(100000)
+	f := dupemap.NewRotatingFilter(60, itemsCount, 0.01, 2)
+
+	falsePositiveCount := 0
+
+	for i := uint32(0); i < itemsCount; i++ {
+		if !f.HasAnywhere(payload(i)) {
+			falsePositiveCount++
+		}
+	}
+
+	// No false negatives
+	for i := uint32(0); i < itemsCount; i++ {
+		assert.False(t, f.HasAnywhere(payload(i)))
+	}
+
+	// Leave some margin over the configured 1% rate
+	falsePositiveRate := float64(falsePositiveCount) / float64(itemsCount)
+	assert.Less(t, falsePositiveRate, 0.015)
+
+	// ~1.4 bytes per item and generation, each at 0.5%
+	assert.LessOrEqual(t, f.Size(), 2*140*1000)
+}
+
+func TestNewFilter(t *testing.T) {
+	r := config.Get()
+	defer config.Mock(&r)
+
+	_, ok := dupemap.NewFilter(5, 100).(*dupemap.DupeMap)
+	assert.True(t, ok)
+
+	cfg := r
+	cfg.Network.DupeFilter.Type = dupemap.FilterBloom
+	config.Mock(&cfg)
+
+	_, ok = dupemap.NewFilter(5, 100).(*dupemap.RotatingFilter)
+	assert.True(t, ok)
+}
+
+func benchmarkFilter(b *testing.B, newFilter func() dupemap.Filter) {
+	b.StopTimer()
+
+	testData := make([]*bytes.Buffer, 0)
+	for i := uint32(0); i < 900*1001; i++ {
+		testData = append(testData, payload(i))
+	}
+
+	var size int
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+
+		f := newFilter()
+
+		b.StartTimer()
+
+		for _, t := range testData {
+			_ = f.HasAnywhere(t)
+		}
+
+		for _, t := range testData {
+			_ = f.HasAnywhere(t)
+		}
+
+		b.StopTimer()
+
+		// The map allocates its filter on first use
+		size = f.Size()
+	}
+
+	b.ReportMetric(float64(size), "filter-bytes")
+}
+
+func BenchmarkFilterMap(b *testing.B) {
+	benchmarkFilter(b, func() dupemap.Filter {
+		return dupemap.NewDupeMap(5, 1000000)
+	})
+}
+
+func BenchmarkFilterBloom(b *testing.B) {
+	benchmarkFilter(b, func() dupemap.Filter {
+		return dupemap.NewRotatingFilter(5, 1000000, 0.001, 2)
+	})
+}
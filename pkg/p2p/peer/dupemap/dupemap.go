@@ -18,6 +18,67 @@ const (
 	defaultExpire   = int64(60)
 )
 
+// Filter types selected by the configuration.
+const (
+	// FilterMap is the DupeMap, a cuckoo filter reset on expiry.
+	FilterMap = "map"
+	// FilterBloom is the RotatingFilter, rotating bloom filters of fixed
+	// memory footprint.
+	FilterBloom = "bloom"
+)
+
+// Filter registers the payloads seen so far, to discard the duplicates.
+type Filter interface {
+	// HasAnywhere returns true if the payload was not seen yet, registering
+	// it, and false if it is a duplicate.
+	HasAnywhere(payload *bytes.Buffer) bool
+	// Size returns the bytes count allocated by the filter.
+	Size() int
+}
+
+// NewFilterDefault returns the configured filter, sized with the default
+// config.
+func NewFilterDefault() Filter {
+	capacity := cfg.Get().Network.MaxDupeMapItems
+	if capacity == 0 {
+		capacity = defaultCapacity
+	}
+
+	expire := int64(cfg.Get().Network.MaxDupeMapExpire)
+	if expire == 0 {
+		expire = defaultExpire
+	}
+
+	return NewFilter(expire, capacity)
+}
+
+// NewFilter creates the configured filter.
+// Expire is number of seconds.
+func NewFilter(expire int64, capacity uint32) Filter {
+	conf := cfg.Get().Network.DupeFilter
+
+	switch conf.Type {
+	case FilterBloom:
+		generations := int(conf.Generations)
+		if generations == 0 {
+			generations = defaultGenerations
+		}
+
+		f := NewRotatingFilter(expire, capacity, conf.FalsePositiveRate, generations)
+
+		log.WithField("cap", capacity).
+			WithField("size", f.Size()).
+			Info("create rotating bloom filter instance")
+
+		return f
+	case "", FilterMap:
+	default:
+		log.WithField("type", conf.Type).Warn("unknown duplicate filter, falling back to map")
+	}
+
+	return NewDupeMap(expire, capacity)
+}
+
 // TODO: DupeMap should deal with value bytes.Buffer rather than pointers as it is not supposed to mutate the struct.
 //nolint:golint
 type DupeMap struct {
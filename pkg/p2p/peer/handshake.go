@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/checksum"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
@@ -18,7 +19,9 @@ import (
 
 // Handshake with another peer.
 func (w *Writer) Handshake(services protocol.ServiceFlag) error {
-	if err := w.writeLocalMsgVersion(w.gossip, services); err != nil {
+	caps := localCapabilities()
+
+	if err := w.writeLocalMsgVersion(w.gossip, services, caps); err != nil {
 		return err
 	}
 
@@ -26,37 +29,67 @@ func (w *Writer) Handshake(services protocol.ServiceFlag) error {
 		return err
 	}
 
-	peerServices, err := w.readRemoteMsgVersion()
+	version, err := w.readRemoteMsgVersion()
 	if err != nil {
 		return err
 	}
 
-	w.services = peerServices
+	w.services = version.Services
+	w.negotiate(caps, version.Capabilities)
+
 	return w.writeVerAck(w.gossip)
 }
 
 // Handshake with another peer.
 func (p *Reader) Handshake(services protocol.ServiceFlag) error {
-	peerServices, err := p.readRemoteMsgVersion()
+	version, err := p.readRemoteMsgVersion()
 	if err != nil {
 		return err
 	}
 
-	p.services = peerServices
+	caps := localCapabilities()
+
+	p.services = version.Services
+	p.negotiate(caps, version.Capabilities)
 
 	if err := p.writeVerAck(p.gossip); err != nil {
 		return err
 	}
 
-	if err := p.writeLocalMsgVersion(p.gossip, services); err != nil {
+	if err := p.writeLocalMsgVersion(p.gossip, services, caps); err != nil {
 		return err
 	}
 
 	return p.readVerAck()
 }
 
-func (c *Connection) writeLocalMsgVersion(g *protocol.Gossip, services protocol.ServiceFlag) error {
-	message, e := c.createVersionBuffer(services)
+// localCapabilities returns the capabilities enabled in the configuration.
+func localCapabilities() protocol.Capability {
+	var caps protocol.Capability
+	if config.Get().Network.Compression.Enabled {
+		caps |= protocol.CompressionSnappy
+	}
+
+	return caps
+}
+
+// negotiate enables the features supported by both peers on the
+// connection.
+func (c *Connection) negotiate(local, remote protocol.Capability) {
+	if local&remote&protocol.CompressionSnappy == 0 {
+		return
+	}
+
+	c.compressThreshold = defaultCompressionThreshold
+	if t := config.Get().Network.Compression.Threshold; t > 0 {
+		c.compressThreshold = int(t)
+	}
+
+	c.compress = true
+}
+
+func (c *Connection) writeLocalMsgVersion(g *protocol.Gossip, services protocol.ServiceFlag, caps protocol.Capability) error {
+	message, e := c.createVersionBuffer(services, caps)
 	if e != nil {
 		return e
 	}
@@ -73,39 +106,39 @@ func (c *Connection) writeLocalMsgVersion(g *protocol.Gossip, services protocol.
 	return e
 }
 
-func (c *Connection) readRemoteMsgVersion() (protocol.ServiceFlag, error) {
+func (c *Connection) readRemoteMsgVersion() (*VersionMessage, error) {
 	msgBytes, err := c.ReadMessage()
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	m, cs, err := checksum.Extract(msgBytes)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if !checksum.Verify(m, cs) {
-		return 0, errors.New("invalid checksum")
+		return nil, errors.New("invalid checksum")
 	}
 
 	decodedMsg := bytes.NewBuffer(m)
 
 	topic, err := topics.Extract(decodedMsg)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if topic != topics.Version {
-		return 0, fmt.Errorf("did not receive the expected '%s' message - got %s",
+		return nil, fmt.Errorf("did not receive the expected '%s' message - got %s",
 			topics.Version, topic)
 	}
 
 	version, err := decodeVersionMessage(decodedMsg)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return version.Services, verifyVersionMessage(version)
+	return version, verifyVersionMessage(version)
 }
 
 func (c *Connection) readVerAck() error {
@@ -155,10 +188,10 @@ func (c *Connection) writeVerAck(g *protocol.Gossip) error {
 	return nil
 }
 
-func (c *Connection) createVersionBuffer(services protocol.ServiceFlag) (*bytes.Buffer, error) {
+func (c *Connection) createVersionBuffer(services protocol.ServiceFlag, caps protocol.Capability) (*bytes.Buffer, error) {
 	version := protocol.NodeVer
 
-	message, err := newVersionMessageBuffer(version, services)
+	message, err := newVersionMessageBuffer(version, services, caps)
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +204,7 @@ func verifyVersionMessage(v *VersionMessage) error {
 		return errors.New("version mismatch")
 	}
 
-	if v.Services != protocol.FullNode {
+	if _, ok := routingRegistry[v.Services]; !ok {
 		return errors.New("unknown service flag")
 	}
 
@@ -59,3 +59,53 @@ func TestHandshake(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// Test that the compression is enabled only when negotiated by both peers.
+func TestCompressionHandshake(t *testing.T) {
+	assert := require.New(t)
+
+	r := cfg.Get()
+	defer cfg.Mock(&r)
+
+	c := r
+	c.Network.Compression.Enabled = true
+	c.Network.Compression.Threshold = 100
+	cfg.Mock(&c)
+
+	eb := eventbus.New()
+	factory := NewReaderFactory(NewMessageProcessor(eb))
+
+	client, srv := net.Pipe()
+
+	pw := NewWriter(NewConnection(client, protocol.NewGossip()), eb)
+	pr := factory.SpawnReader(NewConnection(srv, protocol.NewGossip()))
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- pr.Accept(protocol.FullNode)
+	}()
+
+	assert.NoError(pw.Connect(protocol.FullNode))
+	assert.NoError(<-errChan)
+
+	assert.True(pw.compress)
+	assert.True(pr.compress)
+	assert.Equal(100, pw.compressThreshold)
+
+	_ = pw.Conn.Close()
+
+	// Peers not supporting any capability omit the field
+	buf, err := newVersionMessageBuffer(protocol.NodeVer, protocol.FullNode, 0)
+	assert.NoError(err)
+	buf.Truncate(buf.Len() - 8)
+
+	v, err := decodeVersionMessage(buf)
+	assert.NoError(err)
+	assert.Equal(protocol.FullNode, v.Services)
+	assert.Zero(v.Capabilities)
+
+	conn := &Connection{}
+	conn.negotiate(localCapabilities(), v.Capabilities)
+	assert.False(conn.compress)
+}
@@ -0,0 +1,199 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+// Package inventory tracks the items known by each peer, so that they are not
+// announced back to it.
+package inventory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+)
+
+const (
+	defaultMaxKnown = 5000
+	// peerExpire is how long a peer stays a relay peer after the last item
+	// received from it.
+	peerExpire = 10 * time.Minute
+	// maxPeers bounds the number of peers tracked. The least recently seen
+	// one is forgotten first.
+	maxPeers = 256
+)
+
+// known is the bounded set of items known by a peer, the oldest being
+// forgotten first.
+type known struct {
+	items    map[string]struct{}
+	order    []string
+	next     int
+	lastSeen time.Time
+}
+
+// Inventory tracks the items known by each peer, from the items it announced,
+// sent or requested, and the items announced to it. The peers which recently
+// announced, sent or requested items are the relay peers. Methods of a nil
+// Inventory are no-ops.
+type Inventory struct {
+	lock     sync.Mutex
+	peers    map[string]*known
+	maxKnown int
+}
+
+// NewDefault returns an Inventory sized with the default config.
+func NewDefault() *Inventory {
+	maxKnown := int(config.Get().Network.TxRelay.MaxKnown)
+	if maxKnown == 0 {
+		maxKnown = defaultMaxKnown
+	}
+
+	return New(maxKnown)
+}
+
+// New returns an Inventory tracking up to maxKnown items per peer.
+func New(maxKnown int) *Inventory {
+	return &Inventory{
+		peers:    make(map[string]*known),
+		maxKnown: maxKnown,
+	}
+}
+
+// Add marks the items received from the peer, or requested by it, as known
+// by the peer. The peer is then a relay peer.
+func (i *Inventory) Add(peer string, items ...[]byte) {
+	if i == nil || len(peer) == 0 {
+		return
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	k := i.get(peer)
+	k.lastSeen = time.Now()
+
+	for _, item := range items {
+		k.add(string(item), i.maxKnown)
+	}
+}
+
+// Has returns true if the item is known by the peer.
+func (i *Inventory) Has(peer string, item []byte) bool {
+	if i == nil {
+		return false
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	k, ok := i.peers[peer]
+	if !ok {
+		return false
+	}
+
+	_, ok = k.items[string(item)]
+	return ok
+}
+
+// Unknown returns the items not known by the peer, and marks them as known.
+// Announcing items to a peer does not make it a relay peer for longer.
+func (i *Inventory) Unknown(peer string, items [][]byte) [][]byte {
+	if i == nil {
+		return items
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	k, ok := i.peers[peer]
+	if !ok {
+		return items
+	}
+
+	unknown := make([][]byte, 0, len(items))
+
+	for _, item := range items {
+		if _, ok := k.items[string(item)]; ok {
+			continue
+		}
+
+		k.add(string(item), i.maxKnown)
+		unknown = append(unknown, item)
+	}
+
+	return unknown
+}
+
+// Peers returns the relay peers, forgetting the ones not exchanged with
+// recently.
+func (i *Inventory) Peers() []string {
+	if i == nil {
+		return nil
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	peers := make([]string, 0, len(i.peers))
+
+	for peer, k := range i.peers {
+		if time.Since(k.lastSeen) > peerExpire {
+			delete(i.peers, peer)
+			continue
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers
+}
+
+// get returns the items known by the peer, tracking it if needed.
+func (i *Inventory) get(peer string) *known {
+	k, ok := i.peers[peer]
+	if !ok {
+		if len(i.peers) >= maxPeers {
+			i.evict()
+		}
+
+		k = &known{items: make(map[string]struct{})}
+		i.peers[peer] = k
+	}
+
+	return k
+}
+
+// evict forgets the least recently seen peer.
+func (i *Inventory) evict() {
+	var (
+		oldest   string
+		lastSeen time.Time
+	)
+
+	for peer, k := range i.peers {
+		if len(oldest) == 0 || k.lastSeen.Before(lastSeen) {
+			oldest, lastSeen = peer, k.lastSeen
+		}
+	}
+
+	delete(i.peers, oldest)
+}
+
+func (k *known) add(item string, maxKnown int) {
+	if _, ok := k.items[item]; ok {
+		return
+	}
+
+	if len(k.order) < maxKnown {
+		k.order = append(k.order, item)
+	} else {
+		delete(k.items, k.order[k.next])
+		k.order[k.next] = item
+		k.next = (k.next + 1) % maxKnown
+	}
+
+	k.items[item] = struct{}{}
+}
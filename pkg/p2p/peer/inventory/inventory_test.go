@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package inventory
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventory(t *testing.T) {
+	assert := require.New(t)
+
+	inv := New(3)
+
+	inv.Add("a", []byte{1}, []byte{2})
+	assert.True(inv.Has("a", []byte{1}))
+	assert.False(inv.Has("b", []byte{1}))
+
+	// Unknown items are marked as known
+	assert.Equal([][]byte{{3}}, inv.Unknown("a", [][]byte{{1}, {3}}))
+	assert.Empty(inv.Unknown("a", [][]byte{{1}, {3}}))
+
+	// Items are not tracked for the peers never seen
+	assert.Equal([][]byte{{1}}, inv.Unknown("b", [][]byte{{1}}))
+	assert.Equal([][]byte{{1}}, inv.Unknown("b", [][]byte{{1}}))
+	assert.Equal([]string{"a"}, inv.Peers())
+
+	inv.Add("b", []byte{5})
+	assert.Equal([][]byte{{1}}, inv.Unknown("b", [][]byte{{1}}))
+
+	// The oldest item is forgotten
+	inv.Add("a", []byte{4})
+	assert.False(inv.Has("a", []byte{1}))
+	assert.True(inv.Has("a", []byte{2}))
+	assert.True(inv.Has("a", []byte{4}))
+
+	assert.ElementsMatch([]string{"a", "b"}, inv.Peers())
+
+	// Idle peers are no longer relay peers
+	inv.peers["b"].lastSeen = time.Now().Add(-2 * peerExpire)
+	assert.Equal([]string{"a"}, inv.Peers())
+	assert.False(inv.Has("b", []byte{1}))
+
+	// Announcing items does not keep a peer alive
+	inv.peers["a"].lastSeen = time.Now().Add(-2 * peerExpire)
+	inv.Unknown("a", [][]byte{{6}})
+	assert.Empty(inv.Peers())
+}
+
+func TestInventoryMaxPeers(t *testing.T) {
+	assert := require.New(t)
+
+	inv := New(3)
+
+	for i := 0; i < maxPeers; i++ {
+		inv.Add(strconv.Itoa(i), []byte{1})
+	}
+
+	inv.peers["0"].lastSeen = time.Now().Add(-time.Minute)
+
+	// The least recently seen peer is forgotten
+	inv.Add("new", []byte{1})
+	assert.Len(inv.peers, maxPeers)
+	assert.False(inv.Has("0", []byte{1}))
+	assert.True(inv.Has("new", []byte{1}))
+}
+
+func TestNilInventory(t *testing.T) {
+	assert := require.New(t)
+
+	var inv *Inventory
+
+	inv.Add("a", []byte{1})
+	assert.False(inv.Has("a", []byte{1}))
+	assert.Len(inv.Unknown("a", [][]byte{{1}}), 1)
+	assert.Empty(inv.Peers())
+}
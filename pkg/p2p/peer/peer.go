@@ -21,6 +21,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/checksum"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
@@ -36,6 +37,10 @@ var l = log.WithField("process", "peer")
 const (
 	defaultTimeoutReadWrite = 60
 	defaultKeepAliveTime    = 30
+
+	// defaultCompressionThreshold is the payload size above which frames
+	// are compressed.
+	defaultCompressionThreshold = 1024
 )
 
 // Connection holds the TCP connection to another node, and it's known protocol magic.
@@ -46,6 +51,13 @@ type Connection struct {
 	net.Conn
 	gossip   *protocol.Gossip
 	services protocol.ServiceFlag //nolint:structcheck
+
+	// compress is set when both peers negotiated the frame compression
+	compress          bool
+	compressThreshold int
+
+	// id is the node key of the peer on encrypted connections
+	id string
 }
 
 // NewConnection creates a peer connection struct.
@@ -69,7 +81,7 @@ func (g *GossipConnector) Write(b []byte, _ *message.Metadata, priority byte) (i
 	}
 
 	buf := bytes.NewBuffer(b)
-	if err := g.gossip.Process(buf); err != nil {
+	if err := g.frame(buf); err != nil {
 		return 0, err
 	}
 
@@ -307,7 +319,7 @@ func (p *Reader) ReadLoop(ctx context.Context, ringBuf *ring.Buffer) {
 			return
 		}
 
-		b, err := p.gossip.ReadMessage(p.Conn)
+		b, reserved, err := p.gossip.ReadMessageWithReserved(p.Conn)
 		if err != nil {
 			plog.WithError(err).Warnln("error reading message")
 			return
@@ -322,13 +334,23 @@ func (p *Reader) ReadLoop(ctx context.Context, ringBuf *ring.Buffer) {
 		if !checksum.Verify(message, cs) {
 			plog.WithError(errors.New("invalid checksum")).
 				Warnln("error verifying message cs")
+			p.processor.Penalize(p.ID(), score.BadChecksum)
 			return
 		}
 
+		// The reserved field flags compressed frames only once the
+		// compression is negotiated
+		if p.compress && protocol.Compressed(reserved) {
+			message, err = protocol.Decompress(message)
+			if err != nil {
+				plog.WithError(err).Warnln("error decompressing message")
+				p.processor.Penalize(p.ID(), score.Unmarshal)
+				return
+			}
+		}
+
 		go func() {
-			// TODO: error here should be checked in order to decrease reputation
-			// or blacklist spammers
-			if _, err = p.processor.Collect(p.Addr(), message, ringBuf, p.services, nil); err != nil {
+			if _, err = p.processor.Collect(p.ID(), message, ringBuf, p.services, nil); err != nil {
 				var topic string
 				if len(message) > 0 {
 					topic = topics.Topic(message[0]).String()
@@ -374,6 +396,16 @@ func (c *Connection) keepAlive() error {
 	return err
 }
 
+// frame wraps a message into a gossip frame, compressing it if negotiated
+// with the peer.
+func (c *Connection) frame(buf *bytes.Buffer) error {
+	if c.compress {
+		return c.gossip.ProcessCompressed(buf, c.compressThreshold)
+	}
+
+	return c.gossip.Process(buf)
+}
+
 // Write a message to the connection.
 // Conn needs to be locked, as this function can be called both by the WriteLoop,
 // and by the writer on the ring buffer.
@@ -394,3 +426,13 @@ func (c *Connection) Write(b []byte) (int, error) {
 func (c *Connection) Addr() string {
 	return c.Conn.RemoteAddr().String()
 }
+
+// ID returns the identity of the peer, used as srcPeerID. It is the node key
+// of the peer on encrypted connections, and its address otherwise.
+func (c *Connection) ID() string {
+	if len(c.id) > 0 {
+		return c.id
+	}
+
+	return c.Addr()
+}
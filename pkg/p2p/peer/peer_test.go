@@ -9,13 +9,18 @@ package peer
 import (
 	"bytes"
 	"context"
+	"errors"
 	"net"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/consensus"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/capture"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
@@ -81,6 +86,53 @@ func TestReader(t *testing.T) {
 	}
 }
 
+// Test that the Reader decompresses the frames flagged as compressed, once
+// the compression is negotiated.
+func TestReaderCompressed(t *testing.T) {
+	assert := require.New(t)
+	client, srv := net.Pipe()
+
+	processor := NewMessageProcessor(eventbus.New())
+	agreementChan := make(chan struct{}, 1)
+	respFn := func(_ string, _ message.Message) ([]bytes.Buffer, error) {
+		agreementChan <- struct{}{}
+		return nil, nil
+	}
+
+	processor.Register(topics.Agreement, respFn)
+
+	pConn := NewConnection(srv, protocol.NewGossip())
+	peerReader := NewReaderFactory(processor).SpawnReader(pConn)
+
+	peerReader.services = protocol.FullNode
+	peerReader.compress = true
+
+	go peerReader.ReadLoop(context.Background(), nil)
+
+	buf, err := message.Marshal(makeAgreementGossip(10))
+	assert.NoError(err)
+
+	wConn := NewConnection(client, protocol.NewGossip())
+	wConn.compress = true
+	wConn.compressThreshold = 1
+
+	framesOut, _, framesIn, _ := protocol.CompressionStats()
+
+	assert.NoError(wConn.frame(&buf))
+
+	_, _ = client.Write(buf.Bytes())
+
+	select {
+	case <-agreementChan:
+	case <-time.After(2 * time.Second):
+		t.Fatal("compressed message not processed")
+	}
+
+	out, _, in, _ := protocol.CompressionStats()
+	assert.Equal(framesOut+1, out)
+	assert.Equal(framesIn+1, in)
+}
+
 // Test the functionality of the peer.Writer through the use of the ring buffer.
 func TestWriteRingBuffer(t *testing.T) {
 	bus := eventbus.New()
@@ -99,6 +151,74 @@ func TestWriteRingBuffer(t *testing.T) {
 	}
 }
 
+// Test that the collected messages are captured, and replayed into a fresh
+// MessageProcessor, duplicates included.
+func TestCaptureReplay(t *testing.T) {
+	assert := require.New(t)
+
+	r := config.Get()
+	defer config.Mock(&r)
+
+	file := filepath.Join(t.TempDir(), "dusk.cap")
+
+	cfg := r
+	cfg.Network.Capture.File = file
+	config.Mock(&cfg)
+
+	processor := NewMessageProcessor(eventbus.New())
+
+	buf, err := message.Marshal(makeAgreementGossip(10))
+	assert.NoError(err)
+
+	metadata := &message.Metadata{KadcastHeight: 3, Source: "10.0.0.1:7100"}
+
+	for i := 0; i < 2; i++ {
+		_, err = processor.Collect("10.0.0.1:7100", buf.Bytes(), nil, protocol.FullNode, metadata)
+		assert.NoError(err)
+	}
+
+	assert.NoError(processor.capture.Close())
+
+	config.Mock(&r)
+
+	// Replay into a fresh processor, republishing on the eventbus
+	bus := eventbus.New()
+	agreementChan := make(chan message.Message, 2)
+	bus.Subscribe(topics.Agreement, eventbus.NewChanListener(agreementChan))
+
+	replayed := NewReplayProcessor(bus)
+	assert.Nil(replayed.capture)
+
+	replayed.Register(topics.Agreement, func(_ string, m message.Message) ([]bytes.Buffer, error) {
+		bus.Publish(topics.Agreement, m)
+		return nil, nil
+	})
+
+	c, err := capture.Open(file)
+	assert.NoError(err)
+
+	defer c.Close()
+
+	n, err := capture.Replay(context.Background(), c, 0, func(rec *capture.Record) error {
+		assert.Equal(metadata, rec.Metadata)
+
+		_, err := replayed.Collect(rec.Source, rec.Packet, nil, rec.Services, rec.Metadata)
+		return err
+	})
+
+	assert.NoError(err)
+	assert.Equal(2, n)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case m := <-agreementChan:
+			assert.Equal(metadata, m.Metadata())
+		case <-time.After(time.Second):
+			t.Fatal("replayed message not published")
+		}
+	}
+}
+
 func BenchmarkWriter(t *testing.B) {
 	bus := eventbus.New()
 
@@ -133,3 +253,24 @@ func addPeer(bus *eventbus.EventBus, receiveFunc func(net.Conn)) *Writer {
 	go receiveFunc(srv)
 	return pw
 }
+
+// Test the offences of the senders are scored.
+func TestCollectPenalty(t *testing.T) {
+	processor := NewMessageProcessor(eventbus.New())
+	src := "10.0.0.1:7100"
+
+	// Undecodable message
+	_, err := processor.Collect(src, []byte{byte(topics.Block), 1, 2, 3}, nil, protocol.FullNode, nil)
+	require.Error(t, err)
+	require.Equal(t, score.MaxScore-10, processor.Scores().Score(src))
+
+	// Offence returned by the processing function
+	processor.Register(topics.Ping, func(_ string, _ message.Message) ([]bytes.Buffer, error) {
+		return nil, score.Wrap(score.InvalidCertificate, errors.New("invalid certificate"))
+	})
+
+	buf := topics.Ping.ToBuffer()
+	_, err = processor.Collect(src, buf.Bytes(), nil, protocol.FullNode, nil)
+	require.Error(t, err)
+	require.Equal(t, score.MaxScore-60, processor.Scores().Score(src))
+}
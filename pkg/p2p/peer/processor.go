@@ -14,7 +14,10 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/capture"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/dupemap"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
@@ -22,23 +25,102 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
 )
 
+const (
+	defaultCaptureMaxSize  = 100
+	defaultCaptureMaxFiles = 5
+)
+
 // ProcessorFunc defines an interface for callbacks which can be registered
 // to the MessageProcessor, in order to process messages from the network.
 type ProcessorFunc func(srcPeerID string, m message.Message) ([]bytes.Buffer, error)
 
 // MessageProcessor is connected to all of the processing units that are tied to the peer.
 // It sends an incoming message in the right direction, according to its topic.
+// Offences of the senders are scored on its score.Board and the inbound
+// messages are rate limited per sender and topic. Messages the local node
+// type does not route are discarded.
+// The collected messages can be recorded into a capture file, to be
+// replayed later on.
 type MessageProcessor struct {
-	dupeMap    *dupemap.DupeMap
+	dupeMap    dupemap.Filter
 	processors map[topics.Topic]ProcessorFunc
+	scores     *score.Board
+	limiter    *rateLimiter
+	services   protocol.ServiceFlag
+	capture    *capture.Writer
 }
 
 // NewMessageProcessor returns an initialized MessageProcessor.
 func NewMessageProcessor(bus eventbus.Broker) *MessageProcessor {
 	return &MessageProcessor{
-		dupeMap:    dupemap.NewDupeMapDefault(),
+		dupeMap:    dupemap.NewFilterDefault(),
 		processors: make(map[topics.Topic]ProcessorFunc),
+		scores:     score.NewBoard(),
+		limiter:    newRateLimiter(),
+		services:   LocalServices(),
+		capture:    newCaptureWriter(),
+	}
+}
+
+// NewReplayProcessor returns a MessageProcessor replaying captured messages.
+// It neither rate limits, filters out duplicates nor captures the messages,
+// so that each recorded message reaches the processing functions as it did
+// on the recording node.
+func NewReplayProcessor(bus eventbus.Broker) *MessageProcessor {
+	return &MessageProcessor{
+		processors: make(map[topics.Topic]ProcessorFunc),
+		scores:     score.NewBoard(),
+		services:   LocalServices(),
+	}
+}
+
+// newCaptureWriter returns the capture Writer configured in
+// [network.capture], or nil if the capture is disabled.
+func newCaptureWriter() *capture.Writer {
+	cfg := config.Get().Network.Capture
+	if len(cfg.File) == 0 {
+		return nil
+	}
+
+	maxSize := uint(defaultCaptureMaxSize)
+	if cfg.MaxSize > 0 {
+		maxSize = cfg.MaxSize
+	}
+
+	maxFiles := defaultCaptureMaxFiles
+	if cfg.MaxFiles > 0 {
+		maxFiles = int(cfg.MaxFiles)
+	}
+
+	w, err := capture.NewWriter(cfg.File, int64(maxSize)*1024*1024, maxFiles)
+	if err != nil {
+		log.WithError(err).WithField("file", cfg.File).
+			Error("could not open capture file")
+		return nil
+	}
+
+	log.WithField("file", cfg.File).Info("capturing inbound messages")
+	return w
+}
+
+// LocalServices returns the service flag of the node set in config, full
+// node by default.
+func LocalServices() protocol.ServiceFlag {
+	if s := protocol.ServiceFlag(config.Get().Network.ServiceFlag); s != 0 {
+		return s
 	}
+
+	return protocol.FullNode
+}
+
+// Scores returns the misbehaviour scores of the peers.
+func (m *MessageProcessor) Scores() *score.Board {
+	return m.scores
+}
+
+// Penalize holds an offence against the peer.
+func (m *MessageProcessor) Penalize(srcPeerID string, o score.Offence) {
+	m.scores.Penalize(srcPeerID, o)
 }
 
 // Register a method to a certain topic. This method will be called when a message
@@ -47,6 +129,12 @@ func (m *MessageProcessor) Register(topic topics.Topic, fn ProcessorFunc) {
 	m.processors[topic] = fn
 }
 
+// Registered reports whether a method is registered to a topic.
+func (m *MessageProcessor) Registered(topic topics.Topic) bool {
+	_, ok := m.processors[topic]
+	return ok
+}
+
 // Collect a message from the network. The message is unmarshaled and passed down
 // to the processing function.
 func (m *MessageProcessor) Collect(srcPeerID string, packet []byte, respRingBuf *ring.Buffer, services protocol.ServiceFlag, metadata *message.Metadata) ([]bytes.Buffer, error) {
@@ -55,11 +143,33 @@ func (m *MessageProcessor) Collect(srcPeerID string, packet []byte, respRingBuf
 	}
 	defer m.trace("collected", srcPeerID, time.Now().UnixNano(), packet)
 
+	if m.capture != nil {
+		rec := capture.Record{
+			Time:     time.Now(),
+			Source:   srcPeerID,
+			Services: services,
+			Metadata: metadata,
+			Packet:   packet,
+		}
+
+		if err := m.capture.Write(rec); err != nil {
+			log.WithError(err).Warn("could not capture message")
+		}
+	}
+
 	b := bytes.NewBuffer(packet)
 	topic := topics.Topic(b.Bytes()[0])
 
+	if m.limiter != nil && !m.limiter.Allow(srcPeerID, topic) {
+		l.WithField("src", srcPeerID).
+			WithField("topic", topic.String()).
+			Trace("message throttled")
+		return nil, nil
+	}
+
 	msg, err := message.Unmarshal(b, metadata)
 	if err != nil {
+		m.Penalize(srcPeerID, score.Unmarshal)
 		return nil, fmt.Errorf("error while unmarshaling: %s - topic: %s", err, topic)
 	}
 
@@ -104,11 +214,21 @@ func (m *MessageProcessor) shouldBeCached(t topics.Topic) bool {
 
 func (m *MessageProcessor) process(srcPeerID string, msg message.Message, respRingBuf *ring.Buffer, services protocol.ServiceFlag) ([]bytes.Buffer, error) {
 	category := msg.Category()
+
+	// Kadcast broadcasts every topic to any node type
+	if !canRoute(m.services, category) {
+		l.WithField("topic", category.String()).
+			WithField("services", m.services.String()).
+			Trace("topic not routed by local node")
+		return nil, nil
+	}
+
 	if !canRoute(services, category) {
+		m.Penalize(srcPeerID, score.IllegalTopic)
 		return nil, fmt.Errorf("attempted to process an illegal topic %s for node type %v", category, services)
 	}
 
-	if m.shouldBeCached(category) {
+	if m.dupeMap != nil && m.shouldBeCached(category) {
 		if !m.dupeMap.HasAnywhere(bytes.NewBuffer(msg.Id())) {
 			return nil, nil
 		}
@@ -122,6 +242,10 @@ func (m *MessageProcessor) process(srcPeerID string, msg message.Message, respRi
 
 	bufs, err := processFn(srcPeerID, msg)
 	if err != nil {
+		if o, ok := score.OffenceOf(err); ok {
+			m.Penalize(srcPeerID, o)
+		}
+
 		return nil, fmt.Errorf("error while processing: %s - topic %s", err, msg.Category())
 	}
 
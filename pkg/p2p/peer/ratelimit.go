@@ -0,0 +1,137 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package peer
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"golang.org/x/time/rate"
+)
+
+// minIdleTime is the minimum time after which the bucket of an idle peer is
+// discarded.
+const minIdleTime = time.Minute
+
+// throttledMetrics exposes the number of throttled messages per topic on
+// /debug/vars.
+var throttledMetrics = expvar.NewMap("throttled_messages")
+
+type topicLimit struct {
+	limit rate.Limit
+	burst int
+
+	// idle time after which the bucket is full again
+	idle time.Duration
+}
+
+type bucket struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+type bucketKey struct {
+	peerID string
+	topic  topics.Topic
+}
+
+// rateLimiter applies a token bucket per source peer and topic.
+type rateLimiter struct {
+	lock      sync.Mutex
+	limits    map[topics.Topic]topicLimit
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+// newRateLimiter returns a rateLimiter configured with the
+// [network.ratelimits] settings, keyed by topic name.
+func newRateLimiter() *rateLimiter {
+	r := &rateLimiter{
+		limits:    make(map[topics.Topic]topicLimit),
+		buckets:   make(map[bucketKey]*bucket),
+		lastSweep: time.Now(),
+	}
+
+	for name, cfg := range config.Get().Network.RateLimits {
+		topic := topics.StringToTopic(name)
+		if topic == topics.Unknown {
+			l.WithField("topic", name).Fatal("rate limit of unknown topic")
+		}
+
+		if cfg.Rate <= 0 {
+			continue
+		}
+
+		burst := cfg.Burst
+		if burst < 1 {
+			burst = 1
+		}
+
+		idle := time.Duration(float64(burst) / cfg.Rate * float64(time.Second))
+		if idle < minIdleTime {
+			idle = minIdleTime
+		}
+
+		r.limits[topic] = topicLimit{
+			limit: rate.Limit(cfg.Rate),
+			burst: burst,
+			idle:  idle,
+		}
+	}
+
+	return r
+}
+
+// Allow reports whether a message of the topic from the peer is within the
+// limits. Throttled messages are counted per topic.
+func (r *rateLimiter) Allow(peerID string, topic topics.Topic) bool {
+	limit, ok := r.limits[topic]
+	if !ok {
+		return true
+	}
+
+	now := time.Now()
+
+	r.lock.Lock()
+
+	if now.Sub(r.lastSweep) >= minIdleTime {
+		r.sweep(now)
+	}
+
+	key := bucketKey{peerID, topic}
+
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{Limiter: rate.NewLimiter(limit.limit, limit.burst)}
+		r.buckets[key] = b
+	}
+
+	b.lastSeen = now
+	allowed := b.AllowN(now, 1)
+
+	r.lock.Unlock()
+
+	if !allowed {
+		throttledMetrics.Add(topic.String(), 1)
+	}
+
+	return allowed
+}
+
+// sweep discards the buckets which are full again.
+func (r *rateLimiter) sweep(now time.Time) {
+	for key, b := range r.buckets {
+		if now.Sub(b.lastSeen) >= r.limits[key.topic].idle {
+			delete(r.buckets, key)
+		}
+	}
+
+	r.lastSweep = now
+}
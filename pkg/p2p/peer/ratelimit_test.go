@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package peer
+
+import (
+	"bytes"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+)
+
+func throttledCount(topic topics.Topic) int64 {
+	v, ok := throttledMetrics.Get(topic.String()).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+
+	return v.Value()
+}
+
+// Test the inbound messages are rate limited per peer and topic.
+func TestCollectRateLimit(t *testing.T) {
+	processor := NewMessageProcessor(eventbus.New())
+	processor.limiter.limits[topics.Ping] = topicLimit{limit: 0.001, burst: 2, idle: minIdleTime}
+
+	var processed int
+
+	processor.Register(topics.Ping, func(_ string, _ message.Message) ([]bytes.Buffer, error) {
+		processed++
+		return nil, nil
+	})
+
+	collect := func(src string) {
+		buf := topics.Ping.ToBuffer()
+		_, err := processor.Collect(src, buf.Bytes(), nil, protocol.FullNode, nil)
+		require.NoError(t, err)
+	}
+
+	throttled := throttledCount(topics.Ping)
+
+	for i := 0; i < 3; i++ {
+		collect("10.0.0.1:7100")
+	}
+
+	require.Equal(t, 2, processed)
+	require.Equal(t, throttled+1, throttledCount(topics.Ping))
+
+	// Buckets are per peer
+	collect("10.0.0.2:7100")
+	require.Equal(t, 3, processed)
+
+	// Topics without a limit are not throttled
+	require.True(t, processor.limiter.Allow("10.0.0.1:7100", topics.Pong))
+}
@@ -11,7 +11,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
 )
 
-// TODO: implement light node registry.
+// routingRegistry lists the topics exchanged with a node, by service type.
 var routingRegistry = map[protocol.ServiceFlag]map[topics.Topic]struct{}{
 	// Full node
 	protocol.FullNode: {
@@ -33,6 +33,29 @@ var routingRegistry = map[protocol.ServiceFlag]map[topics.Topic]struct{}{
 		topics.Challenge:     {},
 		topics.Response:      {},
 		topics.GetAddrs:      {},
+		topics.GetHeaders:    {},
+		topics.Headers:       {},
+		topics.CompactBlock:  {},
+		topics.GetBlockTxs:   {},
+		topics.BlockTxs:      {},
+	},
+	// Light node
+	protocol.LightNode: {
+		topics.Ping:         {},
+		topics.Pong:         {},
+		topics.GetData:      {},
+		topics.GetBlocks:    {},
+		topics.Block:        {},
+		topics.Inv:          {},
+		topics.Addr:         {},
+		topics.Challenge:    {},
+		topics.Response:     {},
+		topics.GetAddrs:     {},
+		topics.GetHeaders:   {},
+		topics.Headers:      {},
+		topics.CompactBlock: {},
+		topics.GetBlockTxs:  {},
+		topics.BlockTxs:     {},
 	},
 }
 
@@ -0,0 +1,72 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package peer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+)
+
+// Test a light node discards the topics it does not route, while a full node
+// penalizes a light peer sending them.
+func TestLightNodeRouting(t *testing.T) {
+	processed := make(map[topics.Topic]int)
+	count := func(_ string, m message.Message) ([]bytes.Buffer, error) {
+		processed[m.Category()]++
+		return nil, nil
+	}
+
+	src := "10.0.0.1:7100"
+	ping := topics.Ping.ToBuffer()
+	mempool := topics.MemPool.ToBuffer()
+
+	light := NewMessageProcessor(eventbus.New())
+	light.services = protocol.LightNode
+	light.Register(topics.Ping, count)
+	light.Register(topics.MemPool, count)
+
+	_, err := light.Collect(src, ping.Bytes(), nil, protocol.FullNode, nil)
+	require.NoError(t, err)
+
+	_, err = light.Collect(src, mempool.Bytes(), nil, protocol.FullNode, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, processed[topics.Ping])
+	require.Equal(t, 0, processed[topics.MemPool])
+	require.Equal(t, score.MaxScore, light.Scores().Score(src))
+
+	full := NewMessageProcessor(eventbus.New())
+	full.Register(topics.MemPool, count)
+
+	_, err = full.Collect(src, mempool.Bytes(), nil, protocol.LightNode, nil)
+	require.Error(t, err)
+	require.Equal(t, 0, processed[topics.MemPool])
+	require.Less(t, full.Scores().Score(src), score.MaxScore)
+
+	_, err = full.Collect(src, mempool.Bytes(), nil, protocol.FullNode, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, processed[topics.MemPool])
+}
+
+func TestVerifyServiceFlag(t *testing.T) {
+	v := &VersionMessage{Version: protocol.NodeVer}
+
+	for _, s := range []protocol.ServiceFlag{protocol.FullNode, protocol.LightNode} {
+		v.Services = s
+		require.NoError(t, verifyVersionMessage(v))
+	}
+
+	v.Services = 3
+	require.Error(t, verifyVersionMessage(v))
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	log "github.com/sirupsen/logrus"
 )
 
 // BlockHashBroker is a processing unit which handles GetBlocks messages.
@@ -33,6 +34,10 @@ func NewBlockHashBroker(db database.DB) *BlockHashBroker {
 // AdvertiseMissingBlocks takes a GetBlocks wire message, finds the requesting peer's
 // height, and returns an inventory message of up to config.MaxInvBlocks blocks which follow the
 // provided locator.
+//
+// A pruned node cannot serve the blocks whose transactions it discarded, so
+// nothing is advertised to a peer behind the pruned height. Such a peer, as is
+// one running its initial sync, has to sync from an archive node.
 func (b *BlockHashBroker) AdvertiseMissingBlocks(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
 	msg := m.Payload().(message.GetBlocks)
 
@@ -42,6 +47,20 @@ func (b *BlockHashBroker) AdvertiseMissingBlocks(srcPeerID string, m message.Mes
 		return nil, err
 	}
 
+	if p, ok := b.db.(database.Pruner); ok {
+		prunedHeight, pruned, err := p.PrunedHeight()
+		if err != nil {
+			return nil, err
+		}
+
+		if pruned && height < prunedHeight {
+			log.WithField("height", height).
+				WithField("pruned_height", prunedHeight).
+				Debug("skipping GetBlocks, the requested blocks are pruned")
+			return nil, nil
+		}
+	}
+
 	// Fill an inv message with all block hashes between the locator
 	// and the chain tip.
 	inv := &message.Inv{}
@@ -79,6 +98,56 @@ func (b *BlockHashBroker) AdvertiseMissingBlocks(srcPeerID string, m message.Mes
 	return nil, nil
 }
 
+// ProvideHeaders takes a GetHeaders wire message and returns a headers
+// message of up to config.MaxInvBlocks block headers which follow the
+// provided locator.
+func (b *BlockHashBroker) ProvideHeaders(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+	msg := m.Payload().(message.GetBlocks)
+
+	height, err := b.fetchLocatorHeight(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := &message.Headers{}
+
+	err = b.db.View(func(t database.Transaction) error {
+		for len(headers.Headers) < cfg.MaxInvBlocks {
+			height++
+
+			hash, err := t.FetchBlockHashByHeight(height)
+			if err != nil {
+				// passed the tip of the chain
+				return nil
+			}
+
+			header, err := t.FetchBlockHeader(hash)
+			if err != nil {
+				return err
+			}
+
+			headers.Headers = append(headers.Headers, header)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(headers.Headers) == 0 {
+		return nil, nil
+	}
+
+	buf := new(bytes.Buffer)
+	if err := headers.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	_ = topics.Prepend(buf, topics.Headers)
+	return []bytes.Buffer{*buf}, nil
+}
+
 // Determine a peer's height from his locator hash.
 func (b *BlockHashBroker) fetchLocatorHeight(msg message.GetBlocks) (uint64, error) {
 	if len(msg.Locators) == 0 {
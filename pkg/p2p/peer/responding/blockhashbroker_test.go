@@ -54,6 +54,91 @@ func TestAdvertiseBlocks(t *testing.T) {
 	}
 }
 
+// prunedDB is a database whose blocks up to height are pruned.
+type prunedDB struct {
+	database.DB
+	height uint64
+}
+
+func (p prunedDB) Prune(depth uint64) (uint64, error) {
+	return 0, nil
+}
+
+func (p prunedDB) PrunedHeight() (uint64, bool, error) {
+	return p.height, true, nil
+}
+
+// Test that the pruned blocks are not advertised.
+func TestAdvertisePrunedBlocks(t *testing.T) {
+	assert := assert.New(t)
+	_, db := lite.CreateDBConnection()
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	hashes, blocks := generateBlocks(5)
+	assert.NoError(storeBlocks(db, blocks))
+
+	blockHashBroker := responding.NewBlockHashBroker(prunedDB{DB: db, height: 2})
+
+	// The blocks following the genesis one are partly pruned
+	blksBuf, err := blockHashBroker.AdvertiseMissingBlocks("", createGetBlocks(hashes[0]))
+	assert.NoError(err)
+	assert.Empty(blksBuf)
+
+	// The ones following the pruned height are not
+	blksBuf, err = blockHashBroker.AdvertiseMissingBlocks("", createGetBlocks(hashes[2]))
+	assert.NoError(err)
+	assert.Len(blksBuf, 1)
+
+	_, _ = topics.Extract(&blksBuf[0])
+
+	inv := &message.Inv{}
+	assert.NoError(inv.Decode(&blksBuf[0]))
+	assert.Len(inv.InvList, 2)
+	assert.Equal(hashes[3], inv.InvList[0].Hash)
+}
+
+// Test the behavior of the block hash broker, upon receiving a GetHeaders message.
+func TestProvideHeaders(t *testing.T) {
+	assert := assert.New(t)
+	_, db := lite.CreateDBConnection()
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	hashes, blocks := generateBlocks(5)
+	assert.NoError(storeBlocks(db, blocks))
+
+	blockHashBroker := responding.NewBlockHashBroker(db)
+
+	// Request the headers following the second block
+	getHeaders := message.GetBlocks{Locators: [][]byte{hashes[1]}}
+	bufs, err := blockHashBroker.ProvideHeaders("", message.New(topics.GetHeaders, getHeaders))
+	assert.NoError(err)
+	assert.Len(bufs, 1)
+
+	topic, _ := topics.Extract(&bufs[0])
+	assert.Equal(topics.Headers, topic)
+
+	headers := &message.Headers{}
+	assert.NoError(headers.Decode(&bufs[0]))
+	assert.Len(headers.Headers, 3)
+
+	for i, header := range headers.Headers {
+		assert.Equal(hashes[i+2], header.Hash)
+		assert.Equal(uint64(i+2), header.Height)
+	}
+
+	// Nothing follows the tip
+	getHeaders = message.GetBlocks{Locators: [][]byte{hashes[4]}}
+	bufs, err = blockHashBroker.ProvideHeaders("", message.New(topics.GetHeaders, getHeaders))
+	assert.NoError(err)
+	assert.Empty(bufs)
+}
+
 // Generate a set of random blocks, which follow each other up in the chain.
 func generateBlocks(amount int) ([][]byte, []*block.Block) {
 	var hashes [][]byte
@@ -0,0 +1,550 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package responding
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rpcbus"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCompactBlockTimeout = 5 * time.Second
+	// recentBlocksNum is the number of blocks kept at hand to serve the
+	// GetBlockTxs requests while they are being accepted.
+	recentBlocksNum = 8
+	// maxPendingBlocks bounds the compact blocks waiting for their missing
+	// txs, and maxPendingPerSender the ones of a same sender.
+	maxPendingBlocks    = 64
+	maxPendingPerSender = 4
+)
+
+// ErrInvalidCompactBlock is returned for a compact block whose header hash
+// does not match.
+var ErrInvalidCompactBlock = errors.New("invalid compact block hash")
+
+var compactMetrics = expvar.NewMap("compactblocks")
+
+// BlockProcessor processes a full block received from the network.
+type BlockProcessor func(srcPeerID string, m message.Message) ([]bytes.Buffer, error)
+
+// pendingBlock is a compact block waiting for its missing txs.
+type pendingBlock struct {
+	blk      *block.Block
+	key      message.ShortIDKey
+	ids      []uint64
+	missing  []int
+	sender   string
+	metadata *message.Metadata
+	// received is the number of bytes received for the block so far.
+	received int
+	// fallback is set once the full block is requested.
+	fallback bool
+	timer    *time.Timer
+}
+
+// CompactBlockBroker rebuilds the compact blocks received from the network
+// out of the mempool txs, requesting the missing ones from the sender. The
+// rebuilt blocks are passed down to the BlockProcessor, as are the full
+// blocks it wraps.
+type CompactBlockBroker struct {
+	db           database.DB
+	rpcBus       *rpcbus.RPCBus
+	publisher    eventbus.Publisher
+	processBlock BlockProcessor
+	timeout      time.Duration
+
+	lock    sync.Mutex
+	pending map[string]*pendingBlock
+	recent  []*block.Block
+}
+
+// NewCompactBlockBroker returns an initialized CompactBlockBroker.
+func NewCompactBlockBroker(db database.DB, rpcBus *rpcbus.RPCBus, publisher eventbus.Publisher, processBlock BlockProcessor) *CompactBlockBroker {
+	timeout := defaultCompactBlockTimeout
+
+	if t := config.Get().Network.CompactBlocks.Timeout; len(t) > 0 {
+		var err error
+
+		timeout, err = time.ParseDuration(t)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse compact block timeout")
+		}
+	}
+
+	return &CompactBlockBroker{
+		db:           db,
+		rpcBus:       rpcBus,
+		publisher:    publisher,
+		processBlock: processBlock,
+		timeout:      timeout,
+		pending:      make(map[string]*pendingBlock),
+	}
+}
+
+// ProcessBlock passes a full block down to the BlockProcessor. A block
+// requested as fallback of a compact block inherits its Kadcast metadata, so
+// that it is propagated further.
+// Handles topics.Block wire messages.
+func (c *CompactBlockBroker) ProcessBlock(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+	blk := m.Payload().(block.Block)
+	metadata := m.Metadata()
+
+	c.lock.Lock()
+	if p, ok := c.pending[string(blk.Header.Hash)]; ok {
+		p.stop()
+		delete(c.pending, string(blk.Header.Hash))
+
+		if p.metadata != nil {
+			metadata = p.metadata
+		}
+	}
+	c.lock.Unlock()
+
+	return c.process(srcPeerID, &blk, metadata)
+}
+
+// ProcessCompactBlock rebuilds a compact block from its prefilled txs and the
+// mempool. If any tx is missing, a GetBlockTxs message is sent back to the
+// sender.
+// Handles topics.CompactBlock wire messages.
+func (c *CompactBlockBroker) ProcessCompactBlock(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+	cb := m.Payload().(message.CompactBlock)
+	compactMetrics.Add("received", 1)
+
+	hash, err := cb.Header.CalculateHash()
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(hash, cb.Header.Hash) {
+		return nil, ErrInvalidCompactBlock
+	}
+
+	c.lock.Lock()
+	_, pending := c.pending[string(hash)]
+	c.lock.Unlock()
+
+	if pending || c.recentBlock(hash) != nil {
+		return nil, nil
+	}
+
+	blk := block.NewBlock()
+	blk.Header = cb.Header
+	blk.Txs = make([]transactions.ContractCall, cb.TxsNum())
+
+	// ids are the short IDs at the block indexes, zero for the prefilled txs
+	ids := make([]uint64, len(blk.Txs))
+	positions := make([]int, 0, len(cb.ShortIDs))
+
+	for _, p := range cb.Prefilled {
+		blk.Txs[p.Index] = p.Tx
+	}
+
+	for i := range blk.Txs {
+		if blk.Txs[i] == nil {
+			ids[i] = cb.ShortIDs[len(positions)]
+			positions = append(positions, i)
+		}
+	}
+
+	key := cb.Key()
+
+	// A node without mempool, such as a light node, requests all the txs
+	txs, err := getMempoolTxsByShortIDs(c.rpcBus, key, cb.ShortIDs)
+	if err != nil {
+		log.WithError(err).Trace("could not look up compact block txs")
+	}
+
+	missing := make([]int, 0)
+
+	for j, i := range positions {
+		if j < len(txs) && txs[j] != nil {
+			blk.Txs[i] = txs[j].Copy().(transactions.ContractCall)
+			continue
+		}
+
+		missing = append(missing, i)
+	}
+
+	received := encodedSize(&cb)
+
+	if len(missing) == 0 {
+		return c.complete(srcPeerID, blk, m.Metadata(), received)
+	}
+
+	get := &message.GetBlockTxs{Hash: hash, Nonce: cb.Nonce, ShortIDs: make([]uint64, len(missing))}
+	for i, idx := range missing {
+		get.ShortIDs[i] = ids[idx]
+	}
+
+	buf := new(bytes.Buffer)
+	if err := get.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	if err := topics.Prepend(buf, topics.GetBlockTxs); err != nil {
+		return nil, err
+	}
+
+	p := &pendingBlock{
+		blk:      blk,
+		key:      key,
+		ids:      ids,
+		missing:  missing,
+		sender:   srcPeerID,
+		metadata: m.Metadata(),
+		received: received,
+	}
+
+	c.lock.Lock()
+	tracked := c.track(string(hash), p)
+	if tracked {
+		p.timer = time.AfterFunc(c.timeout, func() { c.onTimeout(string(hash)) })
+	}
+	c.lock.Unlock()
+
+	if !tracked {
+		compactMetrics.Add("dropped", 1)
+
+		log.WithField("hash", util.StringifyBytes(hash)).
+			WithField("sender", srcPeerID).
+			Debug("too many pending compact blocks")
+		return nil, nil
+	}
+
+	compactMetrics.Add("txs_requested", int64(len(missing)))
+
+	log.WithField("hash", util.StringifyBytes(hash)).
+		WithField("missing", len(missing)).
+		WithField("txs", len(blk.Txs)).
+		Trace("request compact block txs")
+
+	return []bytes.Buffer{*buf}, nil
+}
+
+// ProvideBlockTxs sends back the requested txs of a block, looking them up
+// in the recent blocks, the database and eventually the mempool, as the
+// block might be still under acceptance.
+// Handles topics.GetBlockTxs wire messages.
+func (c *CompactBlockBroker) ProvideBlockTxs(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+	get := m.Payload().(message.GetBlockTxs)
+	resp := &message.BlockTxs{Hash: get.Hash}
+
+	blk := c.recentBlock(get.Hash)
+	if blk == nil {
+		err := c.db.View(func(t database.Transaction) error {
+			var err error
+			blk, err = t.FetchBlock(get.Hash)
+			return err
+		})
+		if err != nil {
+			blk = nil
+		}
+	}
+
+	key := get.Key()
+
+	if blk != nil {
+		byID := make(map[uint64]transactions.ContractCall, len(blk.Txs))
+
+		for _, tx := range blk.Txs {
+			txID, err := tx.CalculateHash()
+			if err != nil {
+				return nil, err
+			}
+
+			byID[key.ShortID(txID)] = tx
+		}
+
+		for _, id := range get.ShortIDs {
+			if tx, ok := byID[id]; ok {
+				resp.Txs = append(resp.Txs, tx)
+			}
+		}
+	} else {
+		txs, err := getMempoolTxsByShortIDs(c.rpcBus, key, get.ShortIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range txs {
+			if tx != nil {
+				resp.Txs = append(resp.Txs, tx)
+			}
+		}
+	}
+
+	// An incomplete response makes the requester fall back to the full block
+	buf := new(bytes.Buffer)
+	if err := resp.Encode(buf); err != nil {
+		return nil, err
+	}
+
+	if err := topics.Prepend(buf, topics.BlockTxs); err != nil {
+		return nil, err
+	}
+
+	return []bytes.Buffer{*buf}, nil
+}
+
+// ProcessBlockTxs completes a pending compact block with the received txs.
+// The full block is requested if they do not match the missing ones.
+// Handles topics.BlockTxs wire messages.
+func (c *CompactBlockBroker) ProcessBlockTxs(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+	resp := m.Payload().(message.BlockTxs)
+
+	c.lock.Lock()
+
+	p, ok := c.pending[string(resp.Hash)]
+	if !ok || p.fallback {
+		c.lock.Unlock()
+		return nil, nil
+	}
+
+	if !p.fill(resp.Txs) {
+		c.fallback(string(resp.Hash), p)
+		c.lock.Unlock()
+
+		buf, err := marshalGetData(blockInv(resp.Hash))
+		if err != nil {
+			return nil, err
+		}
+
+		return []bytes.Buffer{*buf}, nil
+	}
+
+	p.stop()
+	delete(c.pending, string(resp.Hash))
+	c.lock.Unlock()
+
+	return c.complete(srcPeerID, p.blk, p.metadata, p.received+encodedSize(&resp))
+}
+
+// fill sets the missing txs of the block, if all of them match.
+func (p *pendingBlock) fill(txs []transactions.ContractCall) bool {
+	if len(txs) != len(p.missing) {
+		return false
+	}
+
+	for i, idx := range p.missing {
+		txID, err := txs[i].CalculateHash()
+		if err != nil || p.key.ShortID(txID) != p.ids[idx] {
+			return false
+		}
+	}
+
+	for i, idx := range p.missing {
+		p.blk.Txs[idx] = txs[i]
+	}
+
+	return true
+}
+
+func (p *pendingBlock) stop() {
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+}
+
+// track adds a pending block, unless there are too many of them already, in
+// total or from its sender.
+// The lock must be held.
+func (c *CompactBlockBroker) track(key string, p *pendingBlock) bool {
+	if len(c.pending) >= maxPendingBlocks {
+		return false
+	}
+
+	n := 0
+
+	for _, q := range c.pending {
+		if q.sender == p.sender {
+			n++
+		}
+	}
+
+	if n >= maxPendingPerSender {
+		return false
+	}
+
+	c.pending[key] = p
+	return true
+}
+
+// fallback marks the pending block as requested in full. It is kept until
+// the block is received or the timeout expires again.
+// The lock must be held.
+func (c *CompactBlockBroker) fallback(key string, p *pendingBlock) {
+	compactMetrics.Add("fallbacks", 1)
+
+	p.fallback = true
+	p.stop()
+	p.timer = time.AfterFunc(c.timeout, func() { c.onTimeout(key) })
+}
+
+// onTimeout requests the full block of a compact block whose missing txs were
+// not received in time. The request can be sent only to Kadcast peers, which
+// are addressed through the metadata.
+func (c *CompactBlockBroker) onTimeout(key string) {
+	c.lock.Lock()
+
+	p, ok := c.pending[key]
+	if !ok {
+		c.lock.Unlock()
+		return
+	}
+
+	if p.fallback || p.metadata == nil || c.publisher == nil {
+		delete(c.pending, key)
+		c.lock.Unlock()
+		return
+	}
+
+	c.fallback(key, p)
+	c.lock.Unlock()
+
+	buf, err := marshalGetData(blockInv([]byte(key)))
+	if err != nil {
+		log.WithError(err).Warn("could not request full block")
+		return
+	}
+
+	c.publisher.Publish(topics.KadcastSendToOne, message.NewWithMetadata(topics.KadcastSendToOne, *buf, p.metadata))
+}
+
+// complete passes a rebuilt block down to the BlockProcessor, accounting the
+// bytes saved compared to the full block. If the block is rejected, as it is
+// if a short ID matched the wrong tx, the full block is requested instead.
+func (c *CompactBlockBroker) complete(srcPeerID string, blk *block.Block, metadata *message.Metadata, received int) ([]bytes.Buffer, error) {
+	buf := new(bytes.Buffer)
+	if err := message.MarshalBlock(buf, blk); err != nil {
+		return nil, err
+	}
+
+	compactMetrics.Add("reconstructed", 1)
+
+	if saved := buf.Len() - received; saved > 0 {
+		compactMetrics.Add("bytes_saved", int64(saved))
+	}
+
+	bufs, err := c.process(srcPeerID, blk, metadata)
+	if err == nil {
+		return bufs, nil
+	}
+
+	key := string(blk.Header.Hash)
+	p := &pendingBlock{blk: blk, sender: srcPeerID, metadata: metadata}
+
+	c.lock.Lock()
+	tracked := c.track(key, p)
+	if tracked {
+		c.fallback(key, p)
+	}
+	c.lock.Unlock()
+
+	if !tracked {
+		return nil, err
+	}
+
+	log.WithError(err).
+		WithField("hash", util.StringifyBytes(blk.Header.Hash)).
+		Debug("rebuilt block rejected, request full block")
+
+	buf, merr := marshalGetData(blockInv(blk.Header.Hash))
+	if merr != nil {
+		return nil, merr
+	}
+
+	return []bytes.Buffer{*buf}, nil
+}
+
+// process keeps the block at hand for the GetBlockTxs requests while the
+// BlockProcessor runs.
+func (c *CompactBlockBroker) process(srcPeerID string, blk *block.Block, metadata *message.Metadata) ([]bytes.Buffer, error) {
+	if c.recentBlock(blk.Header.Hash) == nil {
+		c.lock.Lock()
+		c.recent = append(c.recent, blk)
+		if len(c.recent) > recentBlocksNum {
+			c.recent = c.recent[1:]
+		}
+		c.lock.Unlock()
+	}
+
+	bufs, err := c.processBlock(srcPeerID, message.NewWithMetadata(topics.Block, *blk, metadata))
+	if err != nil {
+		c.forget(blk)
+	}
+
+	return bufs, err
+}
+
+func (c *CompactBlockBroker) forget(blk *block.Block) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i, b := range c.recent {
+		if b == blk {
+			c.recent = append(c.recent[:i], c.recent[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *CompactBlockBroker) recentBlock(hash []byte) *block.Block {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, b := range c.recent {
+		if bytes.Equal(b.Header.Hash, hash) {
+			return b
+		}
+	}
+
+	return nil
+}
+
+func blockInv(hash []byte) *message.Inv {
+	inv := &message.Inv{}
+	inv.AddItem(message.InvTypeBlock, hash)
+
+	return inv
+}
+
+// encodedSize returns the wire size of a message payload, topic included.
+func encodedSize(p interface{ Encode(*bytes.Buffer) error }) int {
+	buf := new(bytes.Buffer)
+	_ = p.Encode(buf)
+
+	return buf.Len() + 1
+}
+
+// getMempoolTxsByShortIDs is a wire.GetMempoolTxsByShortIDs API wrapper.
+func getMempoolTxsByShortIDs(bus *rpcbus.RPCBus, key message.ShortIDKey, ids []uint64) ([]transactions.ContractCall, error) {
+	timeoutGetMempoolTXs := time.Duration(config.Get().Timeout.TimeoutGetMempoolTXs) * time.Second
+
+	params := message.ShortIDsLookup{Key: key, IDs: ids}
+
+	resp, err := bus.Call(topics.GetMempoolTxsByShortIDs, rpcbus.NewRequest(params), timeoutGetMempoolTXs)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.([]transactions.ContractCall), nil
+}
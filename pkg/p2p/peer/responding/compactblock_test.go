@@ -0,0 +1,331 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package responding_test
+
+import (
+	"bytes"
+	"errors"
+	"expvar"
+	"fmt"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/responding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rpcbus"
+	assert "github.com/stretchr/testify/require"
+)
+
+// mockMempool serves the short ID lookups out of a set of txs.
+func mockMempool(t *testing.T, txs []transactions.ContractCall) *rpcbus.RPCBus {
+	rpcBus := rpcbus.New()
+	c := make(chan rpcbus.Request, 1)
+
+	if err := rpcBus.Register(topics.GetMempoolTxsByShortIDs, c); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for r := range c {
+			lookup := r.Params.(message.ShortIDsLookup)
+			resp := make([]transactions.ContractCall, len(lookup.IDs))
+
+			for i, id := range lookup.IDs {
+				for _, tx := range txs {
+					txID, _ := tx.CalculateHash()
+					if lookup.Key.ShortID(txID) == id {
+						resp[i] = tx
+					}
+				}
+			}
+
+			r.RespChan <- rpcbus.NewResponse(resp, nil)
+		}
+	}()
+
+	return rpcBus
+}
+
+func compactBlockMessage(t *testing.T, blk *block.Block) message.Message {
+	cb, err := message.NewCompactBlock(blk)
+	assert.NoError(t, err)
+
+	return message.New(topics.CompactBlock, *cb)
+}
+
+// unmarshal decodes the single response of a broker.
+func unmarshal(t *testing.T, bufs []bytes.Buffer, topic topics.Topic) message.Message {
+	assert.Len(t, bufs, 1)
+
+	msg, err := message.Unmarshal(&bufs[0], nil)
+	assert.NoError(t, err)
+	assert.Equal(t, topic, msg.Category())
+
+	return msg
+}
+
+func TestCompactBlockFromMempool(t *testing.T) {
+	assert := assert.New(t)
+
+	_, db := lite.CreateDBConnection()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	blk := helper.RandomBlock(1, 2)
+
+	var processed *block.Block
+
+	process := func(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+		b := m.Payload().(block.Block)
+		processed = &b
+		return nil, nil
+	}
+
+	cbb := responding.NewCompactBlockBroker(db, mockMempool(t, blk.Txs), nil, process)
+
+	bufs, err := cbb.ProcessCompactBlock("", compactBlockMessage(t, blk))
+	assert.NoError(err)
+	assert.Empty(bufs)
+
+	assert.NotNil(processed)
+	assert.True(blk.Equals(processed))
+
+	saved := expvar.Get("compactblocks").(*expvar.Map).Get("bytes_saved").(*expvar.Int)
+	assert.Greater(saved.Value(), int64(0))
+
+	// The block is not processed twice
+	processed = nil
+	_, err = cbb.ProcessCompactBlock("", compactBlockMessage(t, blk))
+	assert.NoError(err)
+	assert.Nil(processed)
+}
+
+// Test that a block is rebuilt with no round trip when the mempool holds all
+// the txs but the prefilled ones.
+func TestCompactBlockPrefilled(t *testing.T) {
+	assert := assert.New(t)
+
+	_, db := lite.CreateDBConnection()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	blk := helper.RandomBlock(1, 4)
+
+	var processed *block.Block
+
+	process := func(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+		b := m.Payload().(block.Block)
+		processed = &b
+		return nil, nil
+	}
+
+	// Neither the first tx, standing for the Distribute one, nor a late tx
+	// are in the mempool
+	known := []transactions.ContractCall{blk.Txs[1], blk.Txs[3]}
+	cbb := responding.NewCompactBlockBroker(db, mockMempool(t, known), nil, process)
+
+	cb, err := message.NewCompactBlock(blk, 0, 2)
+	assert.NoError(err)
+	assert.Len(cb.Prefilled, 2)
+	assert.Len(cb.ShortIDs, 2)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(cb.Encode(buf))
+	assert.NoError(topics.Prepend(buf, topics.CompactBlock))
+
+	msg, err := message.Unmarshal(buf, nil)
+	assert.NoError(err)
+
+	bufs, err := cbb.ProcessCompactBlock("", msg)
+	assert.NoError(err)
+	assert.Empty(bufs)
+
+	assert.NotNil(processed)
+	assert.True(blk.Equals(processed))
+}
+
+func TestCompactBlockMissingTxs(t *testing.T) {
+	assert := assert.New(t)
+
+	_, db := lite.CreateDBConnection()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	blk := helper.RandomBlock(1, 3)
+	assert.NoError(storeBlocks(db, []*block.Block{blk}))
+
+	var processed *block.Block
+
+	process := func(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+		b := m.Payload().(block.Block)
+		processed = &b
+		return nil, nil
+	}
+
+	// The receiver knows only some of the txs
+	receiver := responding.NewCompactBlockBroker(db, mockMempool(t, blk.Txs[:1]), nil, process)
+	sender := responding.NewCompactBlockBroker(db, mockMempool(t, nil), nil, process)
+
+	bufs, err := receiver.ProcessCompactBlock("", compactBlockMessage(t, blk))
+	assert.NoError(err)
+	assert.Nil(processed)
+
+	get := unmarshal(t, bufs, topics.GetBlockTxs)
+	assert.Len(get.Payload().(message.GetBlockTxs).ShortIDs, len(blk.Txs)-1)
+
+	bufs, err = sender.ProvideBlockTxs("", get)
+	assert.NoError(err)
+
+	bufs, err = receiver.ProcessBlockTxs("", unmarshal(t, bufs, topics.BlockTxs))
+	assert.NoError(err)
+	assert.Empty(bufs)
+
+	assert.NotNil(processed)
+	assert.True(blk.Equals(processed))
+}
+
+func TestCompactBlockFallback(t *testing.T) {
+	assert := assert.New(t)
+
+	_, db := lite.CreateDBConnection()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	blk := helper.RandomBlock(1, 1)
+
+	var (
+		processed *block.Block
+		metadata  *message.Metadata
+	)
+
+	process := func(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+		b := m.Payload().(block.Block)
+		processed = &b
+		metadata = m.Metadata()
+		return nil, nil
+	}
+
+	receiver := responding.NewCompactBlockBroker(db, mockMempool(t, nil), nil, process)
+
+	cb, err := message.NewCompactBlock(blk)
+	assert.NoError(err)
+
+	kadcast := &message.Metadata{KadcastHeight: 5, Source: "127.0.0.1:9000"}
+
+	bufs, err := receiver.ProcessCompactBlock("", message.NewWithMetadata(topics.CompactBlock, *cb, kadcast))
+	assert.NoError(err)
+	unmarshal(t, bufs, topics.GetBlockTxs)
+
+	// Txs not matching the requested ones make the full block requested
+	resp := message.BlockTxs{Hash: blk.Header.Hash, Txs: transactions.RandContractCalls(len(blk.Txs), 0, true)}
+
+	bufs, err = receiver.ProcessBlockTxs("", message.New(topics.BlockTxs, resp))
+	assert.NoError(err)
+
+	inv := unmarshal(t, bufs, topics.GetData).Payload().(message.Inv)
+	assert.Len(inv.InvList, 1)
+	assert.Equal(message.InvTypeBlock, inv.InvList[0].Type)
+	assert.Equal(blk.Header.Hash, inv.InvList[0].Hash)
+
+	// The full block inherits the Kadcast metadata of the compact block
+	assert.Nil(processed)
+
+	_, err = receiver.ProcessBlock("", message.New(topics.Block, *blk))
+	assert.NoError(err)
+	assert.True(blk.Equals(processed))
+	assert.Equal(kadcast, metadata)
+}
+
+func TestCompactBlockRejected(t *testing.T) {
+	assert := assert.New(t)
+
+	_, db := lite.CreateDBConnection()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	blk := helper.RandomBlock(1, 2)
+
+	var processed int
+
+	process := func(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+		processed++
+		if processed == 1 {
+			return nil, errors.New("invalid block")
+		}
+
+		return nil, nil
+	}
+
+	receiver := responding.NewCompactBlockBroker(db, mockMempool(t, blk.Txs), nil, process)
+
+	// A rejected rebuilt block makes the full block requested
+	bufs, err := receiver.ProcessCompactBlock("", compactBlockMessage(t, blk))
+	assert.NoError(err)
+	assert.Equal(1, processed)
+
+	inv := unmarshal(t, bufs, topics.GetData).Payload().(message.Inv)
+	assert.Len(inv.InvList, 1)
+	assert.Equal(blk.Header.Hash, inv.InvList[0].Hash)
+
+	// The compact block is not rebuilt again meanwhile
+	bufs, err = receiver.ProcessCompactBlock("", compactBlockMessage(t, blk))
+	assert.NoError(err)
+	assert.Empty(bufs)
+	assert.Equal(1, processed)
+
+	_, err = receiver.ProcessBlock("", message.New(topics.Block, *blk))
+	assert.NoError(err)
+	assert.Equal(2, processed)
+}
+
+func TestCompactBlockPendingBound(t *testing.T) {
+	assert := assert.New(t)
+
+	_, db := lite.CreateDBConnection()
+	defer func() {
+		_ = db.Close()
+	}()
+
+	process := func(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
+		return nil, nil
+	}
+
+	receiver := responding.NewCompactBlockBroker(db, mockMempool(t, nil), nil, process)
+
+	// A sender gets only a few blocks pending at once
+	requested := 0
+
+	for i := 0; i < 10; i++ {
+		bufs, err := receiver.ProcessCompactBlock("a", compactBlockMessage(t, helper.RandomBlock(1, 1)))
+		assert.NoError(err)
+
+		requested += len(bufs)
+	}
+
+	assert.Equal(4, requested)
+
+	// And all the senders a bounded number of blocks
+	requested = 0
+
+	for i := 0; i < 100; i++ {
+		bufs, err := receiver.ProcessCompactBlock(fmt.Sprintf("%d", i), compactBlockMessage(t, helper.RandomBlock(1, 1)))
+		assert.NoError(err)
+
+		requested += len(bufs)
+	}
+
+	assert.Equal(60, requested)
+}
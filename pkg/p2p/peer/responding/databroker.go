@@ -14,9 +14,12 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/inventory"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util"
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rpcbus"
+	log "github.com/sirupsen/logrus"
 )
 
 // DataBroker is a processing unit responsible for handling GetData messages. It
@@ -25,6 +28,9 @@ import (
 type DataBroker struct {
 	db     database.DB
 	rpcBus *rpcbus.RPCBus
+
+	// inventory records the transactions served to each peer.
+	inventory *inventory.Inventory
 }
 
 // NewDataBroker returns an initialized DataBroker.
@@ -35,6 +41,12 @@ func NewDataBroker(db database.DB, rpcBus *rpcbus.RPCBus) *DataBroker {
 	}
 }
 
+// TrackInventory makes the DataBroker record the transactions served to each
+// peer, so that they are not announced back to it.
+func (d *DataBroker) TrackInventory(inv *inventory.Inventory) {
+	d.inventory = inv
+}
+
 // MarshalObjects marshals requested objects by a message of type message.Inv.
 func (d *DataBroker) MarshalObjects(srcPeerID string, m message.Message) ([]bytes.Buffer, error) {
 	msg := m.Payload().(message.Inv)
@@ -51,6 +63,16 @@ func (d *DataBroker) MarshalObjects(srcPeerID string, m message.Message) ([]byte
 				b, err = t.FetchBlock(obj.Hash)
 				return err
 			})
+			if err == database.ErrBlockPruned {
+				// Only the header of this block is kept. Serve the rest of
+				// the requested objects, the peer falls back on another one
+				// when its request times out.
+				log.WithField("hash", util.StringifyBytes(obj.Hash)).
+					WithField("peer", srcPeerID).
+					Info("skipping requested block, its transactions are pruned")
+				continue
+			}
+
 			if err != nil {
 				return nil, err
 			}
@@ -78,6 +100,8 @@ func (d *DataBroker) MarshalObjects(srcPeerID string, m message.Message) ([]byte
 				}
 
 				bufs = append(bufs, *buf)
+
+				d.inventory.Add(srcPeerID, obj.Hash)
 			}
 		}
 	}
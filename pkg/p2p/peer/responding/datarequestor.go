@@ -17,22 +17,42 @@ import (
 	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/dupemap"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/inventory"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
 	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rpcbus"
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	defaultTxRequestTimeout = 5 * time.Second
+	// maxTxRequests bounds the txs requested at once, and maxTxAnnouncers
+	// the peers recorded per tx to fall back to.
+	maxTxRequests   = 10000
+	maxTxAnnouncers = 8
+)
+
+// txRequest is a tx requested from a peer.
+type txRequest struct {
+	// announcers are the Kadcast peers which advertised the tx, and were
+	// not requested it yet.
+	announcers []string
+	timer      *time.Timer
+}
+
 // DataRequestor is a processing unit which handles inventory messages received from peers
 // on the Dusk wire protocol. It maintains a connection to the outgoing message queue
 // of an individual peer.
 type DataRequestor struct {
-	db     database.DB
-	rpcBus *rpcbus.RPCBus
+	db        database.DB
+	rpcBus    *rpcbus.RPCBus
+	publisher eventbus.Publisher
 	// The DataRequestor maintains a separate instance of the dupemap,
-	// to ensure advertised hashes are not followed up on more than once.
-	dupemap *dupemap.DupeMap
+	// to ensure advertised block hashes are not followed up on more than
+	// once.
+	dupemap dupemap.Filter
 
 	// This mutex is used to ensure that blocks are requested in a serial
 	// manner. If multiple goroutines are constructing `GetData` messages,
@@ -41,17 +61,55 @@ type DataRequestor struct {
 	// during sync, such as flooding the network with more requests than is
 	// necessary.
 	lock sync.Mutex
+
+	// requests are the txs requested and not received yet, by hash.
+	requests map[string]*txRequest
+	// txTimeout is the time a peer is given to send a requested tx, before
+	// it is requested from another peer which advertised it.
+	txTimeout time.Duration
+
+	// blocksOnly ignores the advertised transactions.
+	blocksOnly bool
+
+	// inventory records the transactions advertised by each peer.
+	inventory *inventory.Inventory
 }
 
 // NewDataRequestor returns an initialized DataRequestor.
-func NewDataRequestor(db database.DB, rpcBus *rpcbus.RPCBus) *DataRequestor {
+func NewDataRequestor(db database.DB, rpcBus *rpcbus.RPCBus, publisher eventbus.Publisher) *DataRequestor {
+	txTimeout := defaultTxRequestTimeout
+
+	if t := config.Get().Network.TxRelay.RequestTimeout; len(t) > 0 {
+		var err error
+
+		txTimeout, err = time.ParseDuration(t)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse tx request timeout")
+		}
+	}
+
 	return &DataRequestor{
-		db:      db,
-		rpcBus:  rpcBus,
-		dupemap: dupemap.NewDupeMap(5, 100000),
+		db:        db,
+		rpcBus:    rpcBus,
+		publisher: publisher,
+		dupemap:   dupemap.NewFilter(5, 100000),
+		requests:  make(map[string]*txRequest),
+		txTimeout: txTimeout,
 	}
 }
 
+// BlocksOnly makes the DataRequestor ignore advertised transactions, as on a
+// light node which keeps no mempool.
+func (d *DataRequestor) BlocksOnly() {
+	d.blocksOnly = true
+}
+
+// TrackInventory makes the DataRequestor record the transactions advertised
+// by each peer, so that they are not announced back to it.
+func (d *DataRequestor) TrackInventory(inv *inventory.Inventory) {
+	d.inventory = inv
+}
+
 // RequestMissingItems takes an inventory message, checks it for any items that the node
 // is missing, puts these items in a GetData wire message, and sends it off to the peer's
 // outgoing message queue, requesting the items in full.
@@ -78,12 +136,13 @@ func (d *DataRequestor) RequestMissingItems(srcPeerID string, m message.Message)
 			})
 
 			// In Gossip network, topics.Inv msg could be received from
-			// all (up to 9) peers when a new transaction or a block hash
-			// is propagated. To ensure we request full tx/block data from
-			// not more than a single peer and reduce bandwidth needs, we
-			// introduce a dupemap here with short expiry time.
+			// all (up to 9) peers when a new block hash is propagated. To
+			// ensure we request full block data from not more than a
+			// single peer and reduce bandwidth needs, we introduce a
+			// dupemap here with short expiry time.
 
-			// In Kadcast network, topics.Inv is never used.
+			// In Kadcast network, topics.Inv carries the block hashes
+			// advertised on GetBlocks, and the announced tx hashes.
 
 			if err == database.ErrBlockNotFound {
 				if d.dupemap.HasAnywhere(bytes.NewBuffer(obj.Hash)) {
@@ -102,6 +161,12 @@ func (d *DataRequestor) RequestMissingItems(srcPeerID string, m message.Message)
 				}
 			}
 		case message.InvTypeMempoolTx:
+			if d.blocksOnly {
+				continue
+			}
+
+			d.inventory.Add(srcPeerID, obj.Hash)
+
 			txs, _ := getMempoolTxs(d.rpcBus, obj.Hash)
 
 			// TxID not found in the local mempool:
@@ -111,21 +176,8 @@ func (d *DataRequestor) RequestMissingItems(srcPeerID string, m message.Message)
 			// Tx has been included in this mempool but lost on a suddent restart
 			// Tx has been already accepted.
 			// TODO: To check that look for this Tx in the last 10 blocks (db.FetchTxExists())
-			if len(txs) == 0 {
-				if d.dupemap.HasAnywhere(bytes.NewBuffer(obj.Hash)) {
-					// .. if not, let's request the full tx data from the InvMsg initiator node
-					getData.AddItem(message.InvTypeMempoolTx, obj.Hash)
-
-					log.
-						WithField("hash", hex.EncodeToString(obj.Hash)).
-						WithField("src_addr", srcPeerID).
-						Trace("dupemap registers tx hash")
-				} else {
-					log.
-						WithField("hash", hex.EncodeToString(obj.Hash)).
-						WithField("src_addr", srcPeerID).
-						Trace("dupemap rejects tx hash")
-				}
+			if len(txs) == 0 && d.requestTx(obj.Hash, srcPeerID, m.Metadata() != nil) {
+				getData.AddItem(message.InvTypeMempoolTx, obj.Hash)
 			}
 		}
 	}
@@ -145,6 +197,81 @@ func (d *DataRequestor) RequestMissingItems(srcPeerID string, m message.Message)
 	return nil, nil
 }
 
+// requestTx returns true if a tx advertised by a peer is to be requested from
+// it, as it is not requested from another peer already. Otherwise, a Kadcast
+// peer is recorded to request the tx from, should the other one not send it
+// in time.
+// The lock must be held.
+func (d *DataRequestor) requestTx(txID []byte, srcPeerID string, kadcast bool) bool {
+	key := string(txID)
+
+	if r, ok := d.requests[key]; ok {
+		if kadcast && len(r.announcers) < maxTxAnnouncers {
+			r.announcers = append(r.announcers, srcPeerID)
+		}
+
+		log.WithField("hash", hex.EncodeToString(txID)).
+			WithField("src_addr", srcPeerID).
+			Trace("tx hash requested already")
+		return false
+	}
+
+	if len(d.requests) >= maxTxRequests {
+		log.WithField("hash", hex.EncodeToString(txID)).
+			WithField("src_addr", srcPeerID).
+			Debug("too many txs requested")
+		return false
+	}
+
+	r := &txRequest{}
+	r.timer = time.AfterFunc(d.txTimeout, func() { d.onTxTimeout(key, r) })
+	d.requests[key] = r
+
+	return true
+}
+
+// onTxTimeout requests a tx not received in time from the next peer which
+// advertised it. The request can be sent only to Kadcast peers, which are
+// addressed by their address. Once none is left, the tx is forgotten, and it
+// is requested again on the next advertisement.
+func (d *DataRequestor) onTxTimeout(key string, r *txRequest) {
+	txs, _ := getMempoolTxs(d.rpcBus, []byte(key))
+
+	d.lock.Lock()
+
+	if d.requests[key] != r {
+		d.lock.Unlock()
+		return
+	}
+
+	if len(txs) > 0 || len(r.announcers) == 0 || d.publisher == nil {
+		delete(d.requests, key)
+		d.lock.Unlock()
+		return
+	}
+
+	peer := r.announcers[0]
+	r.announcers = r.announcers[1:]
+	r.timer = time.AfterFunc(d.txTimeout, func() { d.onTxTimeout(key, r) })
+
+	d.lock.Unlock()
+
+	getData := &message.Inv{}
+	getData.AddItem(message.InvTypeMempoolTx, []byte(key))
+
+	buf, err := marshalGetData(getData)
+	if err != nil {
+		log.WithError(err).Warn("could not request tx")
+		return
+	}
+
+	log.WithField("hash", hex.EncodeToString([]byte(key))).
+		WithField("r_addr", peer).
+		Trace("request tx from another peer")
+
+	d.publisher.Publish(topics.KadcastSendToOne, message.NewWithMetadata(topics.KadcastSendToOne, *buf, &message.Metadata{Source: peer}))
+}
+
 func marshalGetData(getData *message.Inv) (*bytes.Buffer, error) {
 	buf := new(bytes.Buffer)
 	if err := getData.Encode(buf); err != nil {
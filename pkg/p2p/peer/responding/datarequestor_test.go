@@ -9,11 +9,17 @@ package responding_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
 	"github.com/dusk-network/dusk-blockchain/pkg/core/database/lite"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/inventory"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/responding"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
 	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/rpcbus"
 	crypto "github.com/dusk-network/dusk-crypto/hash"
 )
 
@@ -24,7 +30,7 @@ func TestRequestData(t *testing.T) {
 		_ = db.Close()
 	}()
 
-	dataRequestor := responding.NewDataRequestor(db, nil)
+	dataRequestor := responding.NewDataRequestor(db, nil, nil)
 
 	// Send topics.Inv
 	hash, msg := createInv()
@@ -55,6 +61,148 @@ func TestRequestData(t *testing.T) {
 	}
 }
 
+// Test that the advertised txs are recorded in the inventory of the peer,
+// and requested from the first peer advertising them only.
+func TestRequestTxsTracksInventory(t *testing.T) {
+	_, db := lite.CreateDBConnection()
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// The txs are missing from the mempool
+	rpcBus := rpcbus.New()
+	c := make(chan rpcbus.Request, 1)
+
+	if err := rpcBus.Register(topics.GetMempoolTxs, c); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for r := range c {
+			r.RespChan <- rpcbus.NewResponse([]transactions.ContractCall{}, nil)
+		}
+	}()
+
+	inv := inventory.New(100)
+
+	dataRequestor := responding.NewDataRequestor(db, rpcBus, nil)
+	dataRequestor.TrackInventory(inv)
+
+	msg := &message.Inv{}
+	hash, _ := crypto.RandEntropy(32)
+	msg.AddItem(message.InvTypeMempoolTx, hash)
+
+	bufs, err := dataRequestor.RequestMissingItems("a", message.New(topics.Inv, *msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bufs) != 1 {
+		t.Fatal("tx not requested")
+	}
+
+	bufs, err = dataRequestor.RequestMissingItems("b", message.New(topics.Inv, *msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bufs) != 0 {
+		t.Error("tx requested twice")
+	}
+
+	if !inv.Has("a", hash) || !inv.Has("b", hash) {
+		t.Error("advertised tx not recorded in the inventory")
+	}
+}
+
+// Test that a tx not received in time is requested from another peer which
+// advertised it, and forgotten once none is left.
+func TestRequestTxFallback(t *testing.T) {
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := r
+	c.Network.TxRelay.RequestTimeout = "50ms"
+	config.Mock(&c)
+
+	_, db := lite.CreateDBConnection()
+
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// The txs are missing from the mempool
+	rpcBus := rpcbus.New()
+	reqs := make(chan rpcbus.Request, 1)
+
+	if err := rpcBus.Register(topics.GetMempoolTxs, reqs); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for r := range reqs {
+			r.RespChan <- rpcbus.NewResponse([]transactions.ContractCall{}, nil)
+		}
+	}()
+
+	eb := eventbus.New()
+	sent := make(chan message.Message, 4)
+	eb.Subscribe(topics.KadcastSendToOne, eventbus.NewChanListener(sent))
+
+	dataRequestor := responding.NewDataRequestor(db, rpcBus, eb)
+
+	msg := &message.Inv{}
+	hash, _ := crypto.RandEntropy(32)
+	msg.AddItem(message.InvTypeMempoolTx, hash)
+
+	advertise := func(peer string) []bytes.Buffer {
+		m := message.NewWithMetadata(topics.Inv, *msg, &message.Metadata{Source: peer})
+
+		bufs, err := dataRequestor.RequestMissingItems(peer, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return bufs
+	}
+
+	if len(advertise("a")) != 1 {
+		t.Fatal("tx not requested")
+	}
+
+	if len(advertise("b")) != 0 {
+		t.Fatal("tx requested twice")
+	}
+
+	// The tx is requested from the other announcer
+	select {
+	case m := <-sent:
+		if m.Metadata().Source != "b" {
+			t.Fatalf("tx requested from %s", m.Metadata().Source)
+		}
+
+		buf := m.Payload().(message.SafeBuffer)
+
+		topic, _ := topics.Extract(&buf.Buffer)
+		if topic != topics.GetData {
+			t.Fatalf("unexpected topic %s, expected GetData", topic)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("tx not requested from another peer")
+	}
+
+	// Once forgotten, the tx is requested on the next advertisement
+	time.Sleep(150 * time.Millisecond)
+
+	if len(advertise("c")) != 1 {
+		t.Fatal("tx not requested again")
+	}
+
+	// Let the last request expire before the config is restored
+	time.Sleep(150 * time.Millisecond)
+}
+
 func createInv() ([]byte, message.Message) {
 	msg := &message.Inv{}
 	hash, _ := crypto.RandEntropy(32)
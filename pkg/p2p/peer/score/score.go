@@ -0,0 +1,387 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package score
+
+import (
+	"errors"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.WithField("process", "peer_score")
+
+const (
+	// MaxScore is the score of a peer without recent offences.
+	MaxScore = 100
+
+	defaultDisconnectScore  = 50
+	defaultBanScore         = 0
+	defaultBanDuration      = time.Hour
+	defaultRecoveryInterval = time.Minute
+
+	// maxScores bounds the peers with a score below MaxScore. Fully
+	// recovered peers and expired bans are swept every sweepInterval.
+	maxScores     = 10000
+	sweepInterval = time.Minute
+)
+
+// Offence is a misbehaviour held against the peer which sent a message.
+type Offence uint8
+
+// Offences, with the penalties listed in penalties.
+const (
+	// BadChecksum is a wire frame with an invalid checksum.
+	BadChecksum Offence = iota
+	// Unmarshal is a message that could not be decoded.
+	Unmarshal
+	// IllegalTopic is a message with a topic not routed for the node type.
+	IllegalTopic
+	// InvalidCertificate is a block with an invalid certificate.
+	InvalidCertificate
+	// RejectedTx is a tx that failed verification.
+	RejectedTx
+)
+
+var penalties = map[Offence]int{
+	BadChecksum:        10,
+	Unmarshal:          10,
+	IllegalTopic:       20,
+	InvalidCertificate: 50,
+	RejectedTx:         5,
+}
+
+func (o Offence) String() string {
+	switch o {
+	case BadChecksum:
+		return "bad_checksum"
+	case Unmarshal:
+		return "unmarshal"
+	case IllegalTopic:
+		return "illegal_topic"
+	case InvalidCertificate:
+		return "invalid_certificate"
+	case RejectedTx:
+		return "rejected_tx"
+	default:
+		return "unknown"
+	}
+}
+
+// Error is returned by message processors to hold an offence against the
+// sender of the message.
+type Error struct {
+	Offence Offence
+	Err     error
+}
+
+// Wrap returns err bound to the offence.
+func Wrap(o Offence, err error) error {
+	return &Error{Offence: o, Err: err}
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// OffenceOf returns the offence bound to err, if any.
+func OffenceOf(err error) (Offence, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Offence, true
+	}
+
+	return 0, false
+}
+
+type entry struct {
+	score   int
+	updated time.Time
+}
+
+// Board keeps the score of each peer and a list of temporary bans, both keyed
+// by the host of the srcPeerID, so that a peer reconnecting from another port
+// keeps its score. A score starts at MaxScore, decreases on
+// each offence and recovers one point per recovery interval. Under the
+// disconnect score the peer is disconnected, under the ban score its host is
+// also banned. Fully recovered peers and expired bans are swept periodically.
+type Board struct {
+	lock   sync.Mutex
+	scores map[string]*entry
+	bans   map[string]time.Time
+	// sweepAt is the time of the next sweep.
+	sweepAt time.Time
+
+	disconnectScore  int
+	banScore         int
+	banDuration      time.Duration
+	recoveryInterval time.Duration
+
+	disconnectFns []func(peerID string)
+}
+
+// NewBoard returns a Board configured with the [network.scoring] settings.
+func NewBoard() *Board {
+	cfg := config.Get().Network.Scoring
+
+	b := &Board{
+		scores:           make(map[string]*entry),
+		bans:             make(map[string]time.Time),
+		disconnectScore:  defaultDisconnectScore,
+		banScore:         defaultBanScore,
+		banDuration:      defaultBanDuration,
+		recoveryInterval: defaultRecoveryInterval,
+	}
+
+	if cfg.DisconnectScore != 0 {
+		b.disconnectScore = cfg.DisconnectScore
+	}
+
+	if cfg.BanScore != 0 {
+		b.banScore = cfg.BanScore
+	}
+
+	if len(cfg.BanDuration) > 0 {
+		d, err := time.ParseDuration(cfg.BanDuration)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse ban duration")
+		}
+
+		b.banDuration = d
+	}
+
+	if len(cfg.RecoveryInterval) > 0 {
+		d, err := time.ParseDuration(cfg.RecoveryInterval)
+		if err != nil {
+			log.WithError(err).Fatal("could not parse score recovery interval")
+		}
+
+		b.recoveryInterval = d
+	}
+
+	return b
+}
+
+// OnDisconnect registers a callback called when a peer falls under the
+// disconnect score.
+func (b *Board) OnDisconnect(fn func(peerID string)) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.disconnectFns = append(b.disconnectFns, fn)
+}
+
+// Penalize decreases the score of the peer by the penalty of the offence. It
+// returns the updated score.
+func (b *Board) Penalize(peerID string, o Offence) int {
+	b.lock.Lock()
+
+	now := time.Now()
+	if now.After(b.sweepAt) {
+		b.sweep(now)
+	}
+
+	e := b.entry(peerID, now, true)
+	prev := e.score
+	e.score -= penalties[o]
+
+	score := e.score
+	l := log.WithField("r_addr", peerID).
+		WithField("offence", o.String()).
+		WithField("score", score)
+
+	banned := prev > b.banScore && score <= b.banScore
+	if banned {
+		b.bans[host(peerID)] = time.Now().Add(b.banDuration)
+		delete(b.scores, host(peerID))
+	}
+
+	var fns []func(string)
+	if banned || (prev > b.disconnectScore && score <= b.disconnectScore) {
+		fns = b.disconnectFns
+	}
+
+	b.lock.Unlock()
+
+	switch {
+	case banned:
+		l.WithField("duration", b.banDuration.String()).Warn("peer banned")
+	case len(fns) > 0:
+		l.Warn("peer disconnected")
+	default:
+		l.Debug("peer penalized")
+	}
+
+	for _, fn := range fns {
+		fn(peerID)
+	}
+
+	return score
+}
+
+// Score returns the current score of the peer.
+func (b *Board) Score(peerID string) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	e := b.entry(peerID, time.Now(), false)
+	if e == nil {
+		return MaxScore
+	}
+
+	// Fully recovered peers are forgotten
+	if e.score == MaxScore {
+		delete(b.scores, host(peerID))
+	}
+
+	return e.score
+}
+
+// Banned reports whether the host of the address is banned.
+func (b *Board) Banned(addr string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	h := host(addr)
+
+	until, ok := b.bans[h]
+	if !ok {
+		return false
+	}
+
+	if time.Now().After(until) {
+		delete(b.bans, h)
+		return false
+	}
+
+	return true
+}
+
+// BannedUntil returns the expiry of the ban of the host of the address, or
+// the zero time if it is not banned.
+func (b *Board) BannedUntil(addr string) time.Time {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	until, ok := b.bans[host(addr)]
+	if !ok || time.Now().After(until) {
+		return time.Time{}
+	}
+
+	return until
+}
+
+// Ban bans the host of the address until the given time. It is used to
+// restore the bans persisted by a previous run.
+func (b *Board) Ban(addr string, until time.Time) {
+	if time.Now().After(until) {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.bans[host(addr)] = until
+}
+
+// sweep forgets the fully recovered peers and the expired bans.
+// The lock must be held.
+func (b *Board) sweep(now time.Time) {
+	for h, e := range b.scores {
+		if b.recover(e, now); e.score == MaxScore {
+			delete(b.scores, h)
+		}
+	}
+
+	for h, until := range b.bans {
+		if now.After(until) {
+			delete(b.bans, h)
+		}
+	}
+
+	b.sweepAt = now.Add(sweepInterval)
+}
+
+// entry returns the entry of the host of the peer with the score recovered
+// up to now. Peers without offences have no entry, unless create is set. At
+// most maxScores entries are kept, the highest score being forgotten first.
+func (b *Board) entry(peerID string, now time.Time, create bool) *entry {
+	h := host(peerID)
+
+	e, ok := b.scores[h]
+	if !ok {
+		if !create {
+			return nil
+		}
+
+		if len(b.scores) >= maxScores {
+			b.evict()
+		}
+
+		e = &entry{score: MaxScore, updated: now}
+		b.scores[h] = e
+
+		return e
+	}
+
+	b.recover(e, now)
+
+	return e
+}
+
+// evict forgets the peer with the highest score.
+func (b *Board) evict() {
+	var (
+		highest string
+		score   = math.MinInt32
+	)
+
+	for h, e := range b.scores {
+		if e.score > score {
+			highest, score = h, e.score
+		}
+	}
+
+	delete(b.scores, highest)
+}
+
+// recover adds to the score of the entry the points recovered up to now.
+func (b *Board) recover(e *entry, now time.Time) {
+	if b.recoveryInterval > 0 {
+		points := int(now.Sub(e.updated) / b.recoveryInterval)
+		if points > 0 {
+			e.score += points
+			e.updated = e.updated.Add(time.Duration(points) * b.recoveryInterval)
+		}
+	}
+
+	if e.score >= MaxScore {
+		e.score = MaxScore
+		e.updated = now
+	}
+}
+
+// host returns the host part of an address, or the address itself if it has
+// no port.
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return h
+}
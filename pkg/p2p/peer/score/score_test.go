@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package score
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestPenalize(t *testing.T) {
+	b := NewBoard()
+
+	var disconnected []string
+
+	b.OnDisconnect(func(peerID string) {
+		disconnected = append(disconnected, peerID)
+	})
+
+	peerID := "10.0.0.1:7100"
+
+	assert.Equal(t, MaxScore, b.Score(peerID))
+	assert.Equal(t, 80, b.Penalize(peerID, IllegalTopic))
+	assert.Empty(t, disconnected)
+
+	// Crossing the disconnect score
+	assert.Equal(t, 30, b.Penalize(peerID, InvalidCertificate))
+	assert.Equal(t, []string{peerID}, disconnected)
+
+	assert.Equal(t, 25, b.Penalize(peerID, RejectedTx))
+	assert.Equal(t, 1, len(disconnected))
+	assert.False(t, b.Banned(peerID))
+
+	// Crossing the ban score bans the host, whatever the port
+	b.Penalize(peerID, InvalidCertificate)
+	assert.Equal(t, 2, len(disconnected))
+	assert.True(t, b.Banned("10.0.0.1:43210"))
+	assert.False(t, b.Banned("10.0.0.2:7100"))
+
+	// Score is reset for the ban duration
+	assert.Equal(t, MaxScore, b.Score(peerID))
+}
+
+func TestScoreByHost(t *testing.T) {
+	b := NewBoard()
+
+	var disconnected []string
+
+	b.OnDisconnect(func(peerID string) {
+		disconnected = append(disconnected, peerID)
+	})
+
+	// Reconnecting from another port does not reset the score
+	assert.Equal(t, 80, b.Penalize("10.0.0.1:43210", IllegalTopic))
+	assert.Equal(t, 80, b.Score("10.0.0.1:43211"))
+	assert.Equal(t, MaxScore, b.Score("10.0.0.2:43210"))
+
+	assert.Equal(t, 30, b.Penalize("10.0.0.1:43211", InvalidCertificate))
+	assert.Equal(t, []string{"10.0.0.1:43211"}, disconnected)
+}
+
+func TestBanExpiry(t *testing.T) {
+	r := config.Get()
+	defer config.Mock(&r)
+
+	c := config.Get()
+	c.Network.Scoring.BanScore = 90
+	c.Network.Scoring.BanDuration = "10ms"
+	config.Mock(&c)
+
+	b := NewBoard()
+
+	b.Penalize("10.0.0.1:7100", BadChecksum)
+	assert.True(t, b.Banned("10.0.0.1"))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.False(t, b.Banned("10.0.0.1"))
+}
+
+func TestRecovery(t *testing.T) {
+	b := NewBoard()
+	b.recoveryInterval = time.Second
+
+	peerID := "10.0.0.1:7100"
+	b.Penalize(peerID, Unmarshal)
+
+	// Move the last update back in time
+	b.scores["10.0.0.1"].updated = b.scores["10.0.0.1"].updated.Add(-3 * time.Second)
+	assert.Equal(t, MaxScore-penalties[Unmarshal]+3, b.Score(peerID))
+
+	b.scores["10.0.0.1"].updated = b.scores["10.0.0.1"].updated.Add(-time.Hour)
+	assert.Equal(t, MaxScore, b.Score(peerID))
+
+	// Fully recovered peers are forgotten
+	assert.Empty(t, b.scores)
+}
+
+// Test that the recovered peers and expired bans are swept, and that the
+// scores are bounded.
+func TestSweep(t *testing.T) {
+	b := NewBoard()
+	b.recoveryInterval = time.Second
+
+	b.Penalize("10.0.0.1:7100", Unmarshal)
+	b.Ban("10.0.0.2:7100", time.Now().Add(time.Second))
+
+	b.scores["10.0.0.1"].updated = b.scores["10.0.0.1"].updated.Add(-time.Hour)
+	b.sweepAt = time.Time{}
+
+	// A penalty of another peer sweeps the recovered one away
+	b.Penalize("10.0.0.3:7100", Unmarshal)
+	assert.Len(t, b.scores, 1)
+	assert.Contains(t, b.scores, "10.0.0.3")
+	assert.Len(t, b.bans, 1)
+
+	b.sweep(time.Now().Add(time.Hour))
+	assert.Empty(t, b.scores)
+	assert.Empty(t, b.bans)
+
+	// The highest score is forgotten first
+	b.Penalize("10.0.0.4:7100", InvalidCertificate)
+
+	for i := 0; i < maxScores; i++ {
+		b.Penalize(fmt.Sprintf("10.1.%d.%d:7100", i/256, i%256), RejectedTx)
+	}
+
+	assert.Len(t, b.scores, maxScores)
+	assert.Contains(t, b.scores, "10.0.0.4")
+}
+
+func TestOffenceOf(t *testing.T) {
+	err := fmt.Errorf("accept block: %w", Wrap(InvalidCertificate, errors.New("invalid")))
+
+	o, ok := OffenceOf(err)
+	assert.True(t, ok)
+	assert.Equal(t, InvalidCertificate, o)
+	assert.Equal(t, "accept block: invalid", err.Error())
+
+	_, ok = OffenceOf(errors.New("invalid"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,234 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package peer
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+// Encryption modes of the TCP gossip connections.
+const (
+	// EncryptionDisabled keeps all connections in clear text.
+	EncryptionDisabled = "disabled"
+	// EncryptionOptional encrypts the connections with the peers supporting
+	// it, and falls back to clear text with the others.
+	EncryptionOptional = "optional"
+	// EncryptionRequired refuses the connections in clear text.
+	EncryptionRequired = "required"
+)
+
+const (
+	secureHandshakeTimeout = 5 * time.Second
+
+	// recordTypeHandshake is the first byte of a TLS ClientHello. A gossip
+	// frame starts with its length, which is never as small.
+	recordTypeHandshake = 0x16
+)
+
+var (
+	// ErrInvalidPeerKey is returned when a peer does not present a valid
+	// self-signed ed25519 certificate.
+	ErrInvalidPeerKey = errors.New("invalid peer node key")
+
+	// ErrEncryptionRequired is returned when a peer attempts a connection in
+	// clear text, while encryption is required.
+	ErrEncryptionRequired = errors.New("encryption required")
+)
+
+// NodeKey is the ed25519 key identifying the node on the encrypted
+// connections. The connections are secured by TLS 1.3, each peer presenting
+// a certificate self-signed with its node key. The certificates are not
+// bound to any authority: the node key itself is the identity of the peer.
+type NodeKey struct {
+	priv ed25519.PrivateKey
+	cert tls.Certificate
+}
+
+// LoadNodeKey loads the node key from a PEM file, generating and persisting
+// a new one if the file does not exist. If file is empty, an ephemeral key is
+// generated.
+func LoadNodeKey(file string) (*NodeKey, error) {
+	if len(file) == 0 {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		return newNodeKey(priv)
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+
+		data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := ioutil.WriteFile(file, data, 0o600); err != nil {
+			return nil, err
+		}
+
+		return newNodeKey(priv)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data in node key file")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("node key is not an ed25519 key")
+	}
+
+	return newNodeKey(priv)
+}
+
+func newNodeKey(priv ed25519.PrivateKey) (*NodeKey, error) {
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  priv,
+	}
+
+	return &NodeKey{priv: priv, cert: cert}, nil
+}
+
+// ID returns the identity of the node, the hex encoding of its public key.
+func (k *NodeKey) ID() string {
+	return hex.EncodeToString(k.priv.Public().(ed25519.PublicKey))
+}
+
+// Client secures an outbound connection, and returns it along with the
+// identity of the peer.
+func (k *NodeKey) Client(conn net.Conn) (net.Conn, string, error) {
+	return k.handshake(tls.Client(conn, k.config()))
+}
+
+// Server secures an inbound connection, and returns it along with the
+// identity of the peer.
+func (k *NodeKey) Server(conn net.Conn) (net.Conn, string, error) {
+	return k.handshake(tls.Server(conn, k.config()))
+}
+
+func (k *NodeKey) handshake(conn *tls.Conn) (net.Conn, string, error) {
+	_ = conn.SetDeadline(time.Now().Add(secureHandshakeTimeout))
+
+	if err := conn.Handshake(); err != nil {
+		_ = conn.Close()
+		return nil, "", err
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		_ = conn.Close()
+		return nil, "", ErrInvalidPeerKey
+	}
+
+	pub := certs[0].PublicKey.(ed25519.PublicKey)
+	return conn, hex.EncodeToString(pub), nil
+}
+
+// config returns the TLS configuration of both sides. The certificate chain
+// is not verified, as the peers are identified by their key only.
+func (k *NodeKey) config() *tls.Config {
+	return &tls.Config{
+		MinVersion:            tls.VersionTLS13,
+		Certificates:          []tls.Certificate{k.cert},
+		ClientAuth:            tls.RequireAnyClientCert,
+		InsecureSkipVerify:    true, //nolint:gosec
+		VerifyPeerCertificate: verifyNodeCertificate,
+	}
+}
+
+// verifyNodeCertificate checks that the peer presented a single certificate,
+// self-signed with an ed25519 key. TLS 1.3 proves the possession of the key.
+func verifyNodeCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) != 1 {
+		return ErrInvalidPeerKey
+	}
+
+	cert, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cert.PublicKey.(ed25519.PublicKey); !ok {
+		return ErrInvalidPeerKey
+	}
+
+	if err := cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature); err != nil {
+		return ErrInvalidPeerKey
+	}
+
+	return nil
+}
+
+// peekedConn is a net.Conn whose first bytes were peeked.
+type peekedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// peekSecure reports whether the peer opens an inbound connection with a
+// TLS handshake. The returned connection replays the peeked byte.
+func peekSecure(conn net.Conn) (net.Conn, bool, error) {
+	r := bufio.NewReader(conn)
+
+	_ = conn.SetReadDeadline(time.Now().Add(secureHandshakeTimeout))
+	b, err := r.Peek(1)
+	_ = conn.SetReadDeadline(time.Time{})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &peekedConn{Conn: conn, r: r}, b[0] == recordTypeHandshake, nil
+}
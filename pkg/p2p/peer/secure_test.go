@@ -0,0 +1,222 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package peer
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/peer/score"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/dusk-network/dusk-blockchain/pkg/util/nativeutils/eventbus"
+)
+
+// Test that the node key is persisted.
+func TestLoadNodeKey(t *testing.T) {
+	assert := require.New(t)
+
+	file := filepath.Join(t.TempDir(), "node.key")
+
+	k, err := LoadNodeKey(file)
+	assert.NoError(err)
+
+	k2, err := LoadNodeKey(file)
+	assert.NoError(err)
+	assert.Equal(k.ID(), k2.ID())
+
+	// Ephemeral keys differ
+	k3, err := LoadNodeKey("")
+	assert.NoError(err)
+	assert.NotEqual(k.ID(), k3.ID())
+}
+
+// Test that the messages received over an encrypted connection are
+// collected with the node key of the peer as srcPeerID.
+func TestSecureConnection(t *testing.T) {
+	assert := require.New(t)
+
+	clientKey, err := LoadNodeKey("")
+	assert.NoError(err)
+
+	srvKey, err := LoadNodeKey("")
+	assert.NoError(err)
+
+	c := &Connector{key: srvKey, encryption: EncryptionRequired, scores: score.NewBoard()}
+
+	client, srv := net.Pipe()
+
+	type accepted struct {
+		conn net.Conn
+		id   string
+		err  error
+	}
+
+	acceptChan := make(chan accepted, 1)
+
+	go func() {
+		conn, id, err := c.secureInbound(srv)
+		acceptChan <- accepted{conn, id, err}
+	}()
+
+	sclient, srvID, err := clientKey.Client(client)
+	assert.NoError(err)
+	assert.Equal(srvKey.ID(), srvID)
+
+	a := <-acceptChan
+	assert.NoError(a.err)
+	assert.Equal(clientKey.ID(), a.id)
+
+	// Gossip handshake over the encrypted connection
+	eb := eventbus.New()
+	processor := NewMessageProcessor(eb)
+
+	peerIDChan := make(chan string, 1)
+	processor.Register(topics.Agreement, func(srcPeerID string, _ message.Message) ([]bytes.Buffer, error) {
+		peerIDChan <- srcPeerID
+		return nil, nil
+	})
+
+	pConn := NewConnection(a.conn, protocol.NewGossip())
+	pConn.id = a.id
+	peerReader := NewReaderFactory(processor).SpawnReader(pConn)
+
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- peerReader.Accept(protocol.FullNode)
+	}()
+
+	pw := NewWriter(NewConnection(sclient, protocol.NewGossip()), eb)
+	assert.NoError(pw.Connect(protocol.FullNode))
+	assert.NoError(<-errChan)
+
+	defer func() {
+		_ = pw.Conn.Close()
+	}()
+
+	go peerReader.ReadLoop(context.Background(), nil)
+
+	buf, err := message.Marshal(makeAgreementGossip(10))
+	assert.NoError(err)
+	assert.NoError(pw.gossip.Process(&buf))
+
+	_, err = pw.Write(buf.Bytes())
+	assert.NoError(err)
+
+	select {
+	case id := <-peerIDChan:
+		assert.Equal(clientKey.ID(), id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not collected")
+	}
+}
+
+// Test the mixed mode: peers in clear text are accepted unless encryption is
+// required, and peers without encryption fail the handshake of the others.
+func TestSecureMixedMode(t *testing.T) {
+	assert := require.New(t)
+
+	key, err := LoadNodeKey("")
+	assert.NoError(err)
+
+	for _, mode := range []string{EncryptionOptional, EncryptionRequired} {
+		c := &Connector{key: key, encryption: mode, scores: score.NewBoard()}
+
+		eb := eventbus.New()
+		client, srv := net.Pipe()
+
+		errChan := make(chan error, 1)
+
+		go func() {
+			conn, id, err := c.secureInbound(srv)
+			if err != nil {
+				_ = conn.Close()
+				errChan <- err
+				return
+			}
+
+			if len(id) > 0 {
+				t.Error("clear text peer with an identity")
+			}
+
+			pConn := NewConnection(conn, protocol.NewGossip())
+			errChan <- NewReaderFactory(NewMessageProcessor(eb)).SpawnReader(pConn).Accept(protocol.FullNode)
+		}()
+
+		pw := NewWriter(NewConnection(client, protocol.NewGossip()), eb)
+		err := pw.Connect(protocol.FullNode)
+
+		if mode == EncryptionOptional {
+			assert.NoError(err)
+			assert.NoError(<-errChan)
+		} else {
+			assert.Error(err)
+			assert.Equal(ErrEncryptionRequired, <-errChan)
+		}
+
+		_ = client.Close()
+	}
+
+	// A peer without encryption fails the handshake, so that the dialer
+	// falls back to clear text
+	eb := eventbus.New()
+	client, srv := net.Pipe()
+
+	go func() {
+		pConn := NewConnection(srv, protocol.NewGossip())
+		_ = NewReaderFactory(NewMessageProcessor(eb)).SpawnReader(pConn).Accept(protocol.FullNode)
+	}()
+
+	_, _, err = key.Client(client)
+	assert.Error(err)
+}
+
+// remoteConn is a net.Conn with a given remote address.
+type remoteConn struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c *remoteConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// Test that banning an encrypted peer bans its host as well, so that it
+// cannot reconnect with a new node key.
+func TestSecureBanHost(t *testing.T) {
+	assert := require.New(t)
+
+	key, err := LoadNodeKey("")
+	assert.NoError(err)
+
+	c := &Connector{
+		registry: make(map[string]net.Conn),
+		scores:   score.NewBoard(),
+	}
+	c.scores.OnDisconnect(c.disconnect)
+
+	conn, _ := net.Pipe()
+	c.registry[key.ID()] = &remoteConn{
+		Conn: conn,
+		addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4000},
+	}
+
+	for !c.scores.Banned(key.ID()) {
+		c.scores.Penalize(key.ID(), score.InvalidCertificate)
+	}
+
+	// Any connection from the host is refused before the handshake
+	assert.True(c.scores.Banned("10.0.0.1:4001"))
+}
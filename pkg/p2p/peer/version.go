@@ -19,9 +19,12 @@ type VersionMessage struct {
 	Version   *protocol.Version
 	Timestamp int64
 	Services  protocol.ServiceFlag
+	// Capabilities are appended to the message, and are left unread by the
+	// peers not supporting any.
+	Capabilities protocol.Capability
 }
 
-func newVersionMessageBuffer(v *protocol.Version, services protocol.ServiceFlag) (*bytes.Buffer, error) {
+func newVersionMessageBuffer(v *protocol.Version, services protocol.ServiceFlag, caps protocol.Capability) (*bytes.Buffer, error) {
 	buffer := new(bytes.Buffer)
 	if err := v.Encode(buffer); err != nil {
 		return nil, err
@@ -35,6 +38,10 @@ func newVersionMessageBuffer(v *protocol.Version, services protocol.ServiceFlag)
 		return nil, err
 	}
 
+	if err := encoding.WriteUint64LE(buffer, uint64(caps)); err != nil {
+		return nil, err
+	}
+
 	return buffer, nil
 }
 
@@ -59,5 +66,17 @@ func decodeVersionMessage(r *bytes.Buffer) (*VersionMessage, error) {
 	}
 
 	versionMessage.Services = protocol.ServiceFlag(services)
+
+	// Peers not supporting any capability do not send the field
+	if r.Len() == 0 {
+		return versionMessage, nil
+	}
+
+	var caps uint64
+	if err := encoding.ReadUint64LE(r, &caps); err != nil {
+		return nil, err
+	}
+
+	versionMessage.Capabilities = protocol.Capability(caps)
 	return versionMessage, nil
 }
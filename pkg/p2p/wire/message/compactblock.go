@@ -0,0 +1,468 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package message
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message/payload"
+)
+
+// maxShortIDs is the maximum amount of short tx IDs decoded at once.
+const maxShortIDs = math.MaxInt32 / 8
+
+// ShortIDKey is the SipHash key of the short IDs of the transactions of a
+// compact block. Being salted per block, colliding short IDs cannot be
+// ground in advance.
+type ShortIDKey struct {
+	k0, k1 uint64
+}
+
+// NewShortIDKey derives the key of the short IDs from the block hash and the
+// nonce of the compact block, as the first 16 bytes of their SHA-256 hash.
+func NewShortIDKey(blockHash []byte, nonce uint64) ShortIDKey {
+	h := sha256.New()
+	_, _ = h.Write(blockHash)
+	_ = binary.Write(h, binary.LittleEndian, nonce)
+
+	sum := h.Sum(nil)
+
+	return ShortIDKey{
+		k0: binary.LittleEndian.Uint64(sum[0:8]),
+		k1: binary.LittleEndian.Uint64(sum[8:16]),
+	}
+}
+
+// ShortID returns the short ID of a transaction, the SipHash-2-4 of its hash.
+func (k ShortIDKey) ShortID(txID []byte) uint64 {
+	return sipHash24(k.k0, k.k1, txID)
+}
+
+// ShortIDsLookup is a request of the mempool transactions matching the short
+// IDs of a compact block.
+type ShortIDsLookup struct {
+	Key ShortIDKey
+	IDs []uint64
+}
+
+// PrefilledTx is a transaction carried in full by a compact block, at its
+// index in the block.
+type PrefilledTx struct {
+	Index uint32
+	Tx    transactions.ContractCall
+}
+
+// CompactBlock defines a compact block message on the Dusk wire protocol. It
+// carries the block header, with its certificate, the transactions the
+// receivers are not expected to have, and the short IDs of the other ones,
+// salted with the nonce. The receivers rebuild the block from their mempool.
+type CompactBlock struct {
+	Header    *block.Header
+	Nonce     uint64
+	Prefilled []PrefilledTx
+	ShortIDs  []uint64
+}
+
+// NewCompactBlock returns the compact form of a block, with a random nonce.
+// The txs at the prefilled indexes, such as the Distribute tx or the ones the
+// sender received late, are carried in full. The others are carried by their
+// short ID, in the block order.
+func NewCompactBlock(b *block.Block, prefilled ...int) (*CompactBlock, error) {
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	c := &CompactBlock{
+		Header:    b.Header,
+		Nonce:     binary.LittleEndian.Uint64(nonce[:]),
+		Prefilled: make([]PrefilledTx, 0, len(prefilled)),
+		ShortIDs:  make([]uint64, 0, len(b.Txs)),
+	}
+
+	full := make(map[int]bool, len(prefilled))
+	for _, i := range prefilled {
+		full[i] = true
+	}
+
+	key := c.Key()
+
+	for i, tx := range b.Txs {
+		if full[i] {
+			c.Prefilled = append(c.Prefilled, PrefilledTx{Index: uint32(i), Tx: tx})
+			continue
+		}
+
+		txID, err := tx.CalculateHash()
+		if err != nil {
+			return nil, err
+		}
+
+		c.ShortIDs = append(c.ShortIDs, key.ShortID(txID))
+	}
+
+	return c, nil
+}
+
+// TxsNum returns the number of transactions of the block.
+func (c CompactBlock) TxsNum() int {
+	return len(c.Prefilled) + len(c.ShortIDs)
+}
+
+// Key returns the key of the short IDs.
+func (c CompactBlock) Key() ShortIDKey {
+	return NewShortIDKey(c.Header.Hash, c.Nonce)
+}
+
+// Copy a CompactBlock.
+// Implements the payload.Safe interface.
+func (c CompactBlock) Copy() payload.Safe {
+	prefilled := make([]PrefilledTx, len(c.Prefilled))
+	for i, p := range c.Prefilled {
+		prefilled[i] = PrefilledTx{Index: p.Index, Tx: p.Tx.Copy().(transactions.ContractCall)}
+	}
+
+	ids := make([]uint64, len(c.ShortIDs))
+	copy(ids, c.ShortIDs)
+
+	return CompactBlock{Header: c.Header.Copy(), Nonce: c.Nonce, Prefilled: prefilled, ShortIDs: ids}
+}
+
+// Encode a CompactBlock into a buffer.
+func (c *CompactBlock) Encode(w *bytes.Buffer) error {
+	if err := MarshalHeader(w, c.Header); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint64LE(w, c.Nonce); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(c.Prefilled))); err != nil {
+		return err
+	}
+
+	for _, p := range c.Prefilled {
+		if err := encoding.WriteVarInt(w, uint64(p.Index)); err != nil {
+			return err
+		}
+
+		if err := transactions.Marshal(w, p.Tx); err != nil {
+			return err
+		}
+	}
+
+	return encodeShortIDs(w, c.ShortIDs)
+}
+
+// Decode a CompactBlock from a buffer.
+func (c *CompactBlock) Decode(r *bytes.Buffer) error {
+	c.Header = block.NewHeader()
+	if err := UnmarshalHeader(r, c.Header); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadUint64LE(r, &c.Nonce); err != nil {
+		return err
+	}
+
+	lPrefilled, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lPrefilled > maxShortIDs || lPrefilled > uint64(r.Len()) {
+		return errors.New("invalid prefilled tx count")
+	}
+
+	c.Prefilled = make([]PrefilledTx, lPrefilled)
+	for i := range c.Prefilled {
+		index, err := encoding.ReadVarInt(r)
+		if err != nil {
+			return err
+		}
+
+		// The indexes are strictly increasing
+		if index > maxShortIDs || (i > 0 && index <= uint64(c.Prefilled[i-1].Index)) {
+			return errors.New("invalid prefilled tx index")
+		}
+
+		tx := transactions.NewTransaction()
+		if err := transactions.Unmarshal(r, tx); err != nil {
+			return err
+		}
+
+		c.Prefilled[i] = PrefilledTx{Index: uint32(index), Tx: tx}
+	}
+
+	c.ShortIDs, err = decodeShortIDs(r)
+	if err != nil {
+		return err
+	}
+
+	if n := len(c.Prefilled); n > 0 && int(c.Prefilled[n-1].Index) >= c.TxsNum() {
+		return errors.New("invalid prefilled tx index")
+	}
+
+	return nil
+}
+
+// UnmarshalCompactBlockMessage unmarshals a CompactBlock into a
+// SerializableMessage.
+func UnmarshalCompactBlockMessage(r *bytes.Buffer, m SerializableMessage) error {
+	c := &CompactBlock{}
+	if err := c.Decode(r); err != nil {
+		return err
+	}
+
+	m.SetPayload(*c)
+	return nil
+}
+
+// GetBlockTxs defines a request of the transactions of a block, identified
+// by their short IDs, which could not be found in the mempool while
+// rebuilding a CompactBlock. The nonce is the one of the CompactBlock.
+type GetBlockTxs struct {
+	Hash     []byte
+	Nonce    uint64
+	ShortIDs []uint64
+}
+
+// Key returns the key of the short IDs.
+func (g GetBlockTxs) Key() ShortIDKey {
+	return NewShortIDKey(g.Hash, g.Nonce)
+}
+
+// Copy a GetBlockTxs message.
+// Implements the payload.Safe interface.
+func (g GetBlockTxs) Copy() payload.Safe {
+	hash := make([]byte, len(g.Hash))
+	copy(hash, g.Hash)
+
+	ids := make([]uint64, len(g.ShortIDs))
+	copy(ids, g.ShortIDs)
+
+	return GetBlockTxs{Hash: hash, Nonce: g.Nonce, ShortIDs: ids}
+}
+
+// Encode a GetBlockTxs message into a buffer.
+func (g *GetBlockTxs) Encode(w *bytes.Buffer) error {
+	if err := encoding.Write256(w, g.Hash); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteUint64LE(w, g.Nonce); err != nil {
+		return err
+	}
+
+	return encodeShortIDs(w, g.ShortIDs)
+}
+
+// Decode a GetBlockTxs message from a buffer.
+func (g *GetBlockTxs) Decode(r *bytes.Buffer) error {
+	g.Hash = make([]byte, 32)
+	if err := encoding.Read256(r, g.Hash); err != nil {
+		return err
+	}
+
+	if err := encoding.ReadUint64LE(r, &g.Nonce); err != nil {
+		return err
+	}
+
+	var err error
+	g.ShortIDs, err = decodeShortIDs(r)
+	return err
+}
+
+// UnmarshalGetBlockTxsMessage unmarshals a GetBlockTxs message into a
+// SerializableMessage.
+func UnmarshalGetBlockTxsMessage(r *bytes.Buffer, m SerializableMessage) error {
+	g := &GetBlockTxs{}
+	if err := g.Decode(r); err != nil {
+		return err
+	}
+
+	m.SetPayload(*g)
+	return nil
+}
+
+// BlockTxs defines the response to a GetBlockTxs message, with the requested
+// transactions in the same order.
+type BlockTxs struct {
+	Hash []byte
+	Txs  []transactions.ContractCall
+}
+
+// Copy a BlockTxs message.
+// Implements the payload.Safe interface.
+func (b BlockTxs) Copy() payload.Safe {
+	hash := make([]byte, len(b.Hash))
+	copy(hash, b.Hash)
+
+	txs := make([]transactions.ContractCall, len(b.Txs))
+	for i, tx := range b.Txs {
+		txs[i] = tx.Copy().(transactions.ContractCall)
+	}
+
+	return BlockTxs{Hash: hash, Txs: txs}
+}
+
+// Encode a BlockTxs message into a buffer.
+func (b *BlockTxs) Encode(w *bytes.Buffer) error {
+	if err := encoding.Write256(w, b.Hash); err != nil {
+		return err
+	}
+
+	if err := encoding.WriteVarInt(w, uint64(len(b.Txs))); err != nil {
+		return err
+	}
+
+	for _, tx := range b.Txs {
+		if err := transactions.Marshal(w, tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decode a BlockTxs message from a buffer.
+func (b *BlockTxs) Decode(r *bytes.Buffer) error {
+	b.Hash = make([]byte, 32)
+	if err := encoding.Read256(r, b.Hash); err != nil {
+		return err
+	}
+
+	lTxs, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lTxs > maxShortIDs {
+		return errors.New("too many txs in BlockTxs message")
+	}
+
+	b.Txs = make([]transactions.ContractCall, lTxs)
+	for i := range b.Txs {
+		tx := transactions.NewTransaction()
+		if err := transactions.Unmarshal(r, tx); err != nil {
+			return err
+		}
+
+		b.Txs[i] = tx
+	}
+
+	return nil
+}
+
+// UnmarshalBlockTxsMessage unmarshals a BlockTxs message into a
+// SerializableMessage.
+func UnmarshalBlockTxsMessage(r *bytes.Buffer, m SerializableMessage) error {
+	b := &BlockTxs{}
+	if err := b.Decode(r); err != nil {
+		return err
+	}
+
+	m.SetPayload(*b)
+	return nil
+}
+
+func encodeShortIDs(w *bytes.Buffer, ids []uint64) error {
+	if err := encoding.WriteVarInt(w, uint64(len(ids))); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := encoding.WriteUint64LE(w, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func decodeShortIDs(r *bytes.Buffer) ([]uint64, error) {
+	lIDs, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if lIDs > maxShortIDs || lIDs*8 > uint64(r.Len()) {
+		return nil, errors.New("invalid short tx ID count")
+	}
+
+	ids := make([]uint64, lIDs)
+	for i := range ids {
+		if err := encoding.ReadUint64LE(r, &ids[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// sipHash24 returns the SipHash-2-4 of b with the key (k0, k1).
+func sipHash24(k0, k1 uint64, b []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13)
+		v1 ^= v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16)
+		v3 ^= v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21)
+		v3 ^= v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17)
+		v1 ^= v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+
+	n := len(b)
+
+	for ; len(b) >= 8; b = b[8:] {
+		m := binary.LittleEndian.Uint64(b)
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	m := uint64(n) << 56
+	for i, c := range b {
+		m |= uint64(c) << (8 * uint(i))
+	}
+
+	v3 ^= m
+	round()
+	round()
+	v0 ^= m
+
+	v2 ^= 0xff
+	round()
+	round()
+	round()
+	round()
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
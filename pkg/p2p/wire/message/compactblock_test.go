@@ -0,0 +1,133 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package message_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/ipc/transactions"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeCompactBlock(t *testing.T) {
+	blk := helper.RandomBlock(10, 3)
+
+	cb, err := message.NewCompactBlock(blk, 0, 2)
+	assert.NoError(t, err)
+	assert.Len(t, cb.ShortIDs, len(blk.Txs)-2)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, cb.Encode(buf))
+	assert.NoError(t, topics.Prepend(buf, topics.CompactBlock))
+
+	msg, err := message.Unmarshal(buf, nil)
+	assert.NoError(t, err)
+
+	cb2 := msg.Payload().(message.CompactBlock)
+	assert.True(t, blk.Header.Equals(cb2.Header))
+	assert.Equal(t, cb.Nonce, cb2.Nonce)
+
+	assert.Equal(t, len(blk.Txs), cb2.TxsNum())
+	assert.Len(t, cb2.Prefilled, 2)
+
+	for i, p := range cb2.Prefilled {
+		assert.Equal(t, uint32(2*i), p.Index)
+		assert.True(t, transactions.Equal(blk.Txs[p.Index], p.Tx))
+	}
+
+	ids := cb2.ShortIDs
+
+	for i, tx := range blk.Txs {
+		if i == 0 || i == 2 {
+			continue
+		}
+
+		txID, _ := tx.CalculateHash()
+		assert.Equal(t, cb2.Key().ShortID(txID), ids[0])
+
+		ids = ids[1:]
+	}
+}
+
+// Test that the prefilled tx indexes are validated on decoding.
+func TestDecodeCompactBlockInvalidPrefilled(t *testing.T) {
+	blk := helper.RandomBlock(10, 2)
+
+	cb, err := message.NewCompactBlock(blk, 0, 1)
+	assert.NoError(t, err)
+
+	// Out of the block
+	cb.Prefilled[1].Index = 5
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, cb.Encode(buf))
+	assert.Error(t, (&message.CompactBlock{}).Decode(buf))
+
+	// Not increasing
+	cb.Prefilled[1].Index = 0
+
+	buf = new(bytes.Buffer)
+	assert.NoError(t, cb.Encode(buf))
+	assert.Error(t, (&message.CompactBlock{}).Decode(buf))
+}
+
+// Test that the short IDs are salted per compact block.
+func TestShortIDsSalted(t *testing.T) {
+	blk := helper.RandomBlock(10, 3)
+
+	cb1, err := message.NewCompactBlock(blk)
+	assert.NoError(t, err)
+
+	cb2, err := message.NewCompactBlock(blk)
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, cb1.Nonce, cb2.Nonce)
+	assert.NotEqual(t, cb1.ShortIDs, cb2.ShortIDs)
+
+	txID := make([]byte, 32)
+	key := message.NewShortIDKey(blk.Header.Hash, 1)
+
+	assert.Equal(t, key.ShortID(txID), message.NewShortIDKey(blk.Header.Hash, 1).ShortID(txID))
+	assert.NotEqual(t, key.ShortID(txID), message.NewShortIDKey(blk.Header.Hash, 2).ShortID(txID))
+}
+
+func TestEncodeDecodeBlockTxs(t *testing.T) {
+	blk := helper.RandomBlock(10, 2)
+
+	get := &message.GetBlockTxs{Hash: blk.Header.Hash, Nonce: 7, ShortIDs: []uint64{1, 2}}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, get.Encode(buf))
+	assert.NoError(t, topics.Prepend(buf, topics.GetBlockTxs))
+
+	msg, err := message.Unmarshal(buf, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, *get, msg.Payload().(message.GetBlockTxs))
+
+	resp := &message.BlockTxs{Hash: blk.Header.Hash, Txs: blk.Txs}
+
+	buf = new(bytes.Buffer)
+	assert.NoError(t, resp.Encode(buf))
+	assert.NoError(t, topics.Prepend(buf, topics.BlockTxs))
+
+	msg, err = message.Unmarshal(buf, nil)
+	assert.NoError(t, err)
+
+	resp2 := msg.Payload().(message.BlockTxs)
+	assert.Equal(t, resp.Hash, resp2.Hash)
+	assert.Len(t, resp2.Txs, len(blk.Txs))
+
+	for i, tx := range resp2.Txs {
+		txID, _ := tx.CalculateHash()
+		expected, _ := blk.Txs[i].CalculateHash()
+		assert.Equal(t, expected, txID)
+	}
+}
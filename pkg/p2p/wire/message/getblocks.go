@@ -15,7 +15,8 @@ import (
 )
 
 // GetBlocks defines a getblocks message on the Dusk wire protocol. It is used to
-// request blocks from another peer.
+// request blocks from another peer, or their headers only with
+// topics.GetHeaders.
 type GetBlocks struct {
 	Locators [][]byte
 }
@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package message
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/config"
+	"github.com/dusk-network/dusk-blockchain/pkg/core/data/block"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/encoding"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message/payload"
+)
+
+// Headers defines a headers message on the Dusk wire protocol. It is sent in
+// response to a GetHeaders message, with the consecutive block headers
+// following the locator.
+type Headers struct {
+	Headers []*block.Header
+}
+
+// Copy a Headers message.
+// Implements the payload.Safe interface.
+func (h Headers) Copy() payload.Safe {
+	headers := make([]*block.Header, len(h.Headers))
+	for i, header := range h.Headers {
+		headers[i] = header.Copy()
+	}
+
+	return Headers{headers}
+}
+
+// Encode a Headers struct and write it to w.
+func (h *Headers) Encode(w *bytes.Buffer) error {
+	if err := encoding.WriteVarInt(w, uint64(len(h.Headers))); err != nil {
+		return err
+	}
+
+	for _, header := range h.Headers {
+		if err := MarshalHeader(w, header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalHeadersMessage unmarshals a Headers message into a
+// SerializableMessage.
+func UnmarshalHeadersMessage(r *bytes.Buffer, m SerializableMessage) error {
+	h := &Headers{}
+	if err := h.Decode(r); err != nil {
+		return err
+	}
+
+	m.SetPayload(*h)
+	return nil
+}
+
+// Decode a Headers struct from r into h.
+func (h *Headers) Decode(r *bytes.Buffer) error {
+	lenHeaders, err := encoding.ReadVarInt(r)
+	if err != nil {
+		return err
+	}
+
+	if lenHeaders > config.MaxInvBlocks {
+		return errors.New("too many headers in Headers message")
+	}
+
+	h.Headers = make([]*block.Header, lenHeaders)
+	for i := uint64(0); i < lenHeaders; i++ {
+		h.Headers[i] = block.NewHeader()
+		if err = UnmarshalHeader(r, h.Headers[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package message_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/core/tests/helper"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/message"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/topics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeHeaders(t *testing.T) {
+	headers := &message.Headers{}
+
+	for i := 0; i < 5; i++ {
+		headers.Headers = append(headers.Headers, helper.RandomBlock(uint64(i), 1).Header)
+	}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, headers.Encode(buf))
+	assert.NoError(t, topics.Prepend(buf, topics.Headers))
+
+	msg, err := message.Unmarshal(buf, nil)
+	assert.NoError(t, err)
+
+	headers2 := msg.Payload().(message.Headers)
+	assert.Len(t, headers2.Headers, 5)
+
+	for i, h := range headers2.Headers {
+		assert.True(t, headers.Headers[i].Equals(h))
+	}
+}
@@ -242,8 +242,16 @@ func Unmarshal(b *bytes.Buffer, h *Metadata) (Message, error) {
 	switch topic {
 	case topics.Block:
 		err = UnmarshalBlockMessage(b, msg)
-	case topics.GetBlocks:
+	case topics.GetBlocks, topics.GetHeaders:
 		err = UnmarshalGetBlocksMessage(b, msg)
+	case topics.Headers:
+		err = UnmarshalHeadersMessage(b, msg)
+	case topics.CompactBlock:
+		err = UnmarshalCompactBlockMessage(b, msg)
+	case topics.GetBlockTxs:
+		err = UnmarshalGetBlockTxsMessage(b, msg)
+	case topics.BlockTxs:
+		err = UnmarshalBlockTxsMessage(b, msg)
 	case topics.Inv, topics.GetData:
 		err = UnmarshalInvMessage(b, msg)
 	case topics.GetCandidate:
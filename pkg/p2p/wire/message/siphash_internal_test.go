@@ -0,0 +1,34 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package message
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors of the SipHash reference implementation, keyed with 00..0f and
+// hashing the messages 00..(n-1).
+func TestSipHash24(t *testing.T) {
+	key := make([]byte, 16)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	k0 := binary.LittleEndian.Uint64(key[:8])
+	k1 := binary.LittleEndian.Uint64(key[8:])
+
+	msg := make([]byte, 15)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+
+	assert.Equal(t, uint64(0x726fdb47dd0e0e31), sipHash24(k0, k1, nil))
+	assert.Equal(t, uint64(0xa129ca6149be45e5), sipHash24(k0, k1, msg))
+}
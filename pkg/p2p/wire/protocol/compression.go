@@ -0,0 +1,94 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// Capability indicates the optional protocol features supported by a node.
+// The capabilities are announced in the version handshake, and a feature is
+// used on a connection only if both peers support it.
+type Capability uint64
+
+const (
+	// CompressionSnappy indicates that the node accepts snappy-compressed
+	// gossip frames.
+	CompressionSnappy Capability = 1
+)
+
+// CompressedFlag is set in the reserved field of a compressed gossip frame.
+// Peers which did not negotiate the compression never receive such frames.
+const CompressedFlag = uint64(1) << 63
+
+// ErrDecompressedSize is returned when a compressed frame would exceed
+// MaxFrameSize once decompressed.
+var ErrDecompressedSize = errors.New("decompressed frame exceeds MaxFrameSize")
+
+// ProcessCompressed is the same as Process, but compresses the message if
+// it is larger than threshold and compression saves bytes, flagging the
+// frame in the reserved field. The checksum covers the compressed payload.
+func (g *Gossip) ProcessCompressed(m *bytes.Buffer, threshold int) error {
+	if m.Len() <= threshold || uint64(m.Len()) > MaxFrameSize {
+		return g.Process(m)
+	}
+
+	compressed := snappy.Encode(nil, m.Bytes())
+	if len(compressed) >= m.Len() {
+		return g.Process(m)
+	}
+
+	s.registerCompression(m.Len(), len(compressed))
+
+	*m = *bytes.NewBuffer(compressed)
+	return g.ProcessWithReserved(m, CompressedFlag)
+}
+
+// Decompress returns the message carried by a compressed frame.
+func Decompress(m []byte) ([]byte, error) {
+	n, err := snappy.DecodedLen(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(n) > MaxFrameSize {
+		return nil, ErrDecompressedSize
+	}
+
+	decoded, err := snappy.Decode(nil, m)
+	if err != nil {
+		return nil, err
+	}
+
+	s.registerDecompression(len(m), len(decoded))
+	return decoded, nil
+}
+
+// ReadMessageWithReserved is the same as ReadMessage, but also returns the
+// reserved field of the frame.
+func (g *Gossip) ReadMessageWithReserved(src io.Reader) ([]byte, uint64, error) {
+	length, reserved, err := g.unpackFrame(src)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	buf := make([]byte, int(length))
+	if _, err := io.ReadFull(src, buf); err != nil {
+		return nil, 0, err
+	}
+
+	return buf, reserved, nil
+}
+
+// Compressed reports whether the reserved field flags a compressed frame.
+func Compressed(reserved uint64) bool {
+	return reserved&CompressedFlag != 0
+}
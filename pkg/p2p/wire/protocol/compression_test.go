@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package protocol_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/checksum"
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessCompressed(t *testing.T) {
+	g := protocol.NewGossip()
+	payload := bytes.Repeat([]byte("pippo"), 1000)
+
+	_, savedOut, _, savedIn := protocol.CompressionStats()
+
+	m := bytes.NewBuffer(payload)
+	assert.NoError(t, g.ProcessCompressed(m, 100))
+
+	frame, reserved, err := g.ReadMessageWithReserved(m)
+	assert.NoError(t, err)
+	assert.True(t, protocol.Compressed(reserved))
+	assert.Less(t, len(frame), len(payload))
+
+	// The checksum covers the compressed payload
+	compressed, cs, err := checksum.Extract(frame)
+	assert.NoError(t, err)
+	assert.True(t, checksum.Verify(compressed, cs))
+
+	decoded, err := protocol.Decompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+
+	_, out, _, in := protocol.CompressionStats()
+	assert.Equal(t, int64(len(payload)-len(compressed)), out-savedOut)
+	assert.Equal(t, int64(len(payload)-len(compressed)), in-savedIn)
+}
+
+func TestProcessCompressedBelowThreshold(t *testing.T) {
+	g := protocol.NewGossip()
+
+	m := bytes.NewBufferString("pippo")
+	assert.NoError(t, g.ProcessCompressed(m, 100))
+
+	frame, reserved, err := g.ReadMessageWithReserved(m)
+	assert.NoError(t, err)
+	assert.False(t, protocol.Compressed(reserved))
+	assert.Equal(t, []byte("pippo"), frame[checksum.Length:])
+}
+
+func TestDecompressOversized(t *testing.T) {
+	payload := make([]byte, protocol.MaxFrameSize+1)
+
+	_, err := protocol.Decompress(snappy.Encode(nil, payload))
+	assert.Equal(t, protocol.ErrDecompressedSize, err)
+}
@@ -42,28 +42,36 @@ func (g *Gossip) ProcessWithReserved(m *bytes.Buffer, reserved uint64) error {
 
 // UnpackLength unwraps the incoming packet (likely from a net.Conn struct) and returns the length of the packet without reading the payload (which is left to the user of this method).
 func (g *Gossip) UnpackLength(r io.Reader) (uint64, error) {
+	ln, _, err := g.unpackFrame(r)
+	return ln, err
+}
+
+// unpackFrame is the same as UnpackLength, but also returns the reserved
+// field.
+func (g *Gossip) unpackFrame(r io.Reader) (uint64, uint64, error) {
 	packetLength, err := ReadFrame(r)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	version, err := ExtractVersion(r)
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	if !VersionConstraint.Check(version) {
-		return 0, fmt.Errorf("invalid message version %s received, expected %s", version, VersionConstraintString)
+		return 0, 0, fmt.Errorf("invalid message version %s received, expected %s", version, VersionConstraintString)
 	}
 
 	// if magic != g.Magic {
 	// 	return 0, fmt.Errorf("magic mismatch, received %s expected %s", magic, g.Magic)
 	// }
 
-	// Reserved field is the message timestamp in DevNet/TestNet
-	_, rfSize, err := g.extractReservedField(r)
+	// Reserved field is the message timestamp in DevNet/TestNet, or the
+	// compression flag on the TCP gossip connections
+	reserved, rfSize, err := g.extractReservedField(r)
 	if err != nil {
-		return 0, errors.New("reserved field mismatch")
+		return 0, 0, errors.New("reserved field mismatch")
 	}
 
 	// Uncomment on measuring average arrival time
@@ -74,10 +82,10 @@ func (g *Gossip) UnpackLength(r io.Reader) (uint64, error) {
 	ln := packetLength - uint64(rfSize) - VersionLength
 
 	if ln > MaxFrameSize {
-		return 0, fmt.Errorf("invalid packet length %d", packetLength)
+		return 0, 0, fmt.Errorf("invalid packet length %d", packetLength)
 	}
 
-	return ln, nil
+	return ln, uint64(reserved), nil
 }
 
 // ReadMessage reads from the connection.
@@ -23,10 +23,23 @@ const (
 	// FullNode indicates that a user is running the full node implementation of Dusk.
 	FullNode ServiceFlag = 1
 
-	// LightNode indicates that a user is running a Dusk light node.
-	// LightNode ServiceFlag = 2 // Not implemented.
+	// LightNode indicates that a user is running a Dusk light node, which
+	// takes no part in consensus and only exchanges headers and requested
+	// blocks.
+	LightNode ServiceFlag = 2
 )
 
+func (s ServiceFlag) String() string {
+	switch s {
+	case FullNode:
+		return "full"
+	case LightNode:
+		return "light"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint64(s))
+	}
+}
+
 // NodeVer is the current node version.
 // This is used only in the handshake, need to be removed.
 var NodeVer = &Version{
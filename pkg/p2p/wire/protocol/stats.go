@@ -8,6 +8,7 @@
 package protocol
 
 import (
+	"expvar"
 	"sync"
 	"time"
 
@@ -16,6 +17,10 @@ import (
 
 var s stats
 
+// compressionMetrics exposes the bandwidth saved by the gossip frame
+// compression on /debug/vars.
+var compressionMetrics = expvar.NewMap("gossip_compression")
+
 type stats struct {
 	// cumulativeDuration cumulative arrival time
 	cumulativeDuration int64
@@ -26,9 +31,50 @@ type stats struct {
 	// maxPacketLength recently registered
 	maxPacketLength uint64
 
+	// compressedFrames sent and decompressedFrames received
+	compressedFrames   int64
+	decompressedFrames int64
+
+	// bytesSavedOut and bytesSavedIn by the compression of the frames sent
+	// and received
+	bytesSavedOut int64
+	bytesSavedIn  int64
+
 	lock sync.Mutex
 }
 
+// registerCompression counts a frame sent compressed from raw to wire bytes.
+func (s *stats) registerCompression(raw, wire int) {
+	s.lock.Lock()
+	s.compressedFrames++
+	s.bytesSavedOut += int64(raw - wire)
+	s.lock.Unlock()
+
+	compressionMetrics.Add("compressed_frames", 1)
+	compressionMetrics.Add("bytes_saved_out", int64(raw-wire))
+}
+
+// registerDecompression counts a frame received compressed from wire to raw
+// bytes.
+func (s *stats) registerDecompression(wire, raw int) {
+	s.lock.Lock()
+	s.decompressedFrames++
+	s.bytesSavedIn += int64(raw - wire)
+	s.lock.Unlock()
+
+	compressionMetrics.Add("decompressed_frames", 1)
+	compressionMetrics.Add("bytes_saved_in", int64(raw-wire))
+}
+
+// CompressionStats returns the number of frames sent compressed and the
+// bytes saved by their compression, for the frames sent and received.
+func CompressionStats() (framesOut, savedOut, framesIn, savedIn int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.compressedFrames, s.bytesSavedOut, s.decompressedFrames, s.bytesSavedIn
+}
+
 // registerPacket reports stats based on collected data of the last 1000 messages.
 // this can be enabled manually in case of evaluating network performance.
 func (s *stats) registerPacket(packetLength uint64, timestamp int64) {
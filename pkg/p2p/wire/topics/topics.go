@@ -95,6 +95,22 @@ const (
 
 	// KadcastSendToMany send to many nodes.
 	KadcastSendToMany
+
+	// Mempool notifications.
+	EvictedTx
+
+	// Kadcast connectivity notifications.
+	KadcastStatus
+
+	// Header-only synchronisation.
+	GetHeaders
+	Headers
+
+	// Compact block relay.
+	CompactBlock
+	GetBlockTxs
+	BlockTxs
+	GetMempoolTxsByShortIDs
 )
 
 type topicBuf struct {
@@ -156,6 +172,16 @@ var Topics = [...]topicBuf{
 	{GetCandidate, *(bytes.NewBuffer([]byte{byte(GetCandidate)})), "getcandidate"},
 	{SyncProgress, *(bytes.NewBuffer([]byte{byte(SyncProgress)})), "syncprogress"},
 	{Kadcast, *(bytes.NewBuffer([]byte{byte(Kadcast)})), "kadcast"},
+	{KadcastSendToOne, *(bytes.NewBuffer([]byte{byte(KadcastSendToOne)})), "kadcastsendtoone"},
+	{KadcastSendToMany, *(bytes.NewBuffer([]byte{byte(KadcastSendToMany)})), "kadcastsendtomany"},
+	{EvictedTx, *(bytes.NewBuffer([]byte{byte(EvictedTx)})), "evictedtx"},
+	{KadcastStatus, *(bytes.NewBuffer([]byte{byte(KadcastStatus)})), "kadcaststatus"},
+	{GetHeaders, *(bytes.NewBuffer([]byte{byte(GetHeaders)})), "getheaders"},
+	{Headers, *(bytes.NewBuffer([]byte{byte(Headers)})), "headers"},
+	{CompactBlock, *(bytes.NewBuffer([]byte{byte(CompactBlock)})), "compactblock"},
+	{GetBlockTxs, *(bytes.NewBuffer([]byte{byte(GetBlockTxs)})), "getblocktxs"},
+	{BlockTxs, *(bytes.NewBuffer([]byte{byte(BlockTxs)})), "blocktxs"},
+	{GetMempoolTxsByShortIDs, *(bytes.NewBuffer([]byte{byte(GetMempoolTxsByShortIDs)})), "getmempooltxsbyshortids"},
 }
 
 func checkConsistency(topics []topicBuf) {
@@ -10,6 +10,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"time"
+
+	"github.com/dusk-network/dusk-blockchain/pkg/p2p/wire/protocol"
 )
 
 const (
@@ -42,6 +44,21 @@ const (
 	// UDP Recv buffer size.
 	readBufferSize = 208 * 1024
 
+	// The messages being decoded are bounded per sender host and in total,
+	// in number and in bytes. The bytes of a message are reserved for its
+	// announced transfer length.
+	maxObjects          = 1024
+	maxObjectsPerSender = 16
+	maxObjectsBytes     = 64 * 1024 * 1024
+	maxSenderBytes      = 4 * maxTransferLength
+
+	// maxTransferLength is the largest wire frame, padded to whole blocks.
+	maxTransferLength = (protocol.MaxFrameSize + BlockSize - 1) / BlockSize * BlockSize
+
+	// NumSourceSymbols range supported by the raptor codec.
+	minSourceSymbols = 4
+	maxSourceSymbols = 8192
+
 	// Writer configs.
 	backoffTimeout = 50 * time.Microsecond
 	// UDP Sender buffer size.
@@ -54,4 +71,8 @@ var (
 
 	// ErrTooLargeUDP packet cannot fit into default MTU of 1500.
 	ErrTooLargeUDP = errors.New("packet cannot fit into default MTU of 1500")
+
+	// ErrTooManyObjects the sender or the reader are over the limits of
+	// messages being decoded.
+	ErrTooManyObjects = errors.New("too many messages being decoded")
 )
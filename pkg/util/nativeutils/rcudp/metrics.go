@@ -0,0 +1,28 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package rcudp
+
+import "expvar"
+
+// Metric names of the rcudp map on /debug/vars. The decode success rate is
+// decoded / (decoded + failed), the overhead of the redundancy is
+// packets_sent / packets_needed.
+const (
+	metricDecoded         = "decoded"
+	metricFailed          = "failed"
+	metricPacketsSent     = "packets_sent"
+	metricPacketsReceived = "packets_received"
+	metricPacketsNeeded   = "packets_needed"
+	metricRejected        = "rejected"
+)
+
+var metrics = expvar.NewMap("rcudp")
+
+// RecordSent counts the packets sent by a writer other than WriteBlocks.
+func RecordSent(n int) {
+	metrics.Add(metricPacketsSent, int64(n))
+}
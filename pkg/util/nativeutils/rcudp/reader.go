@@ -30,6 +30,20 @@ type message struct {
 	srcAddr     net.UDPAddr
 	recv_time   int64
 	bcastHeight byte
+
+	// number of packets received
+	received int
+
+	// sender host and reserved bytes
+	sender string
+	size   uint64
+}
+
+// usage is the number of messages being decoded and reserved bytes of a
+// sender.
+type usage struct {
+	objects int
+	bytes   uint64
 }
 
 // MessageCollector callback to be run on a newly decoded message.
@@ -42,6 +56,12 @@ type UDPReader struct {
 	lock    sync.RWMutex
 	objects map[msgID]*message
 
+	usages map[string]*usage
+	total  usage
+
+	// seconds after which a message is stale
+	timeout int64
+
 	collector MessageCollector
 }
 
@@ -49,11 +69,28 @@ type UDPReader struct {
 func NewUDPReader(lAddr *net.UDPAddr, h MessageCollector) (*UDPReader, error) {
 	return &UDPReader{
 		objects:   make(map[msgID]*message),
+		usages:    make(map[string]*usage),
 		lAddr:     lAddr,
+		timeout:   staleTimeout,
 		collector: h,
 	}, nil
 }
 
+// SetTimeout sets the time after which a message is discarded, whether
+// decoded or not. It is rounded up to the second.
+func (r *UDPReader) SetTimeout(d time.Duration) {
+	r.timeout = int64((d + time.Second - 1) / time.Second)
+}
+
+// Process decodes a packet read from a socket owned by the caller, as an
+// alternative to Serve. Expire must then be called periodically.
+func (r *UDPReader) Process(srcAddr net.UDPAddr, data []byte) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.processPacket(srcAddr, data)
+}
+
 // Serve reads data from UDP socket and tries to re-assemble the sourceObject.
 func (r *UDPReader) Serve() {
 	listener, err := net.ListenUDP("udp4", r.lAddr)
@@ -107,24 +144,36 @@ func (r *UDPReader) processPacket(srcAddr net.UDPAddr, data []byte) error {
 	//	Infof("Received packet:  oID %s, bID %d, TL %d, NSS %d ",
 	//		hex.EncodeToString(p.objectID[:]), p.blockID, p.transferLength, p.NumSourceSymbols)
 
+	metrics.Add(metricPacketsReceived, 1)
+
 	var m *message
 	var ok bool
 
 	if m, ok = r.objects[p.messageID]; !ok {
-		// Instantiate a new decoder for handling the packet
-		// a decoder per packet
-		d := NewDecoder(int(p.NumSourceSymbols),
-			symbolAlignmentSize, int(p.transferLength),
-			int(p.PaddingSize))
+		// The decoder is sized from the packet, so that it is checked first
+		if err := validate(p); err != nil {
+			return err
+		}
 
 		m = &message{
-			decoder:     d,
 			srcAddr:     srcAddr,
 			recv_time:   time.Now().Unix(),
 			bcastHeight: p.bcastHeight,
+			sender:      srcAddr.IP.String(),
+			size:        uint64(p.transferLength),
+		}
+
+		if !r.reserve(m) {
+			metrics.Add(metricRejected, 1)
+			return ErrTooManyObjects
 		}
 
-		// TODO: Limit max number of objects stored
+		// Instantiate a new decoder for handling the packet
+		// a decoder per packet
+		m.decoder = NewDecoder(int(p.NumSourceSymbols),
+			symbolAlignmentSize, int(p.transferLength),
+			int(p.PaddingSize))
+
 		r.objects[p.messageID] = m
 	}
 
@@ -141,11 +190,11 @@ func (r *UDPReader) processPacket(srcAddr net.UDPAddr, data []byte) error {
 
 	// Ensure the source address of this encoding symbol is the same as the primary one
 	if !addrEqual(m.srcAddr, srcAddr) {
-		err := errors.New("encoding symbols of same source object cannot be from different UDP addresses")
-		log.WithError(err).Warn("UDP Reader failed")
-		return err
+		return errors.New("encoding symbols of same source object cannot be from different UDP addresses")
 	}
 
+	m.received++
+
 	b := fountain.LTBlock{
 		BlockCode: int64(p.blockID),
 		Data:      p.block[:],
@@ -169,6 +218,9 @@ func (r *UDPReader) processPacket(srcAddr net.UDPAddr, data []byte) error {
 			return fmt.Errorf("broadcast height inconsistency")
 		}
 
+		metrics.Add(metricDecoded, 1)
+		metrics.Add(metricPacketsNeeded, int64(m.received))
+
 		go func() {
 			// At that point in time, the object(message) is already decoded and
 			// collected. However, we can not delete it immediately. This is because
@@ -186,41 +238,106 @@ func (r *UDPReader) processPacket(srcAddr net.UDPAddr, data []byte) error {
 // Cleanup checks for stale and consumed messages. If found, deletes them.
 func (r *UDPReader) cleanup() {
 	for {
-		time.Sleep(time.Duration(staleTimeout) * time.Second)
-
-		deletionList := make([][8]byte, 0)
-
-		r.lock.RLock()
-		for k, v := range r.objects {
-			// message not consumed and staleTimeout has been reached
-			if (time.Now().Unix() - v.recv_time) > staleTimeout {
-				deletionList = append(deletionList, k)
-
-				// this message is out of time. Pending to be deleted. if not
-				// collected yet, that might mean staleTimeout should be
-				// increased or message delivery simply failed
-				if !v.decoder.IsReady() {
-					d := v.decoder
-					log.WithField("receiver", r.lAddr.Port).
-						Warnf("Not collected message with msgID %s, NumSourceSymbols %d, PaddingSize %d",
-							hex.EncodeToString(k[:]), d.numSourceSymbols, d.paddingSize)
-				}
+		time.Sleep(time.Duration(r.timeout) * time.Second)
+		r.Expire()
+	}
+}
+
+// Expire deletes the stale and consumed messages.
+func (r *UDPReader) Expire() {
+	deletionList := make([][8]byte, 0)
+
+	r.lock.RLock()
+	for k, v := range r.objects {
+		// message not consumed and timeout has been reached
+		if (time.Now().Unix() - v.recv_time) > r.timeout {
+			deletionList = append(deletionList, k)
+
+			// this message is out of time. Pending to be deleted. if not
+			// collected yet, that might mean timeout should be
+			// increased or message delivery simply failed
+			if !v.decoder.IsReady() {
+				metrics.Add(metricFailed, 1)
+
+				d := v.decoder
+				log.WithField("receiver", r.lAddr.String()).
+					Warnf("Not collected message with msgID %s, NumSourceSymbols %d, PaddingSize %d",
+						hex.EncodeToString(k[:]), d.numSourceSymbols, d.paddingSize)
 			}
 		}
+	}
 
-		r.lock.RUnlock()
+	r.lock.RUnlock()
 
-		if len(deletionList) == 0 {
-			continue
-		}
+	if len(deletionList) == 0 {
+		return
+	}
 
-		// delete items
-		r.lock.Lock()
-		for _, key := range deletionList {
-			delete(r.objects, key)
+	// delete items
+	r.lock.Lock()
+	for _, key := range deletionList {
+		if m, ok := r.objects[key]; ok {
+			r.release(key, m)
 		}
+	}
+
+	r.lock.Unlock()
+}
 
-		r.lock.Unlock()
+// validate checks the decoding parameters of the first packet of a message.
+func validate(p Packet) error {
+	if uint64(p.transferLength) > maxTransferLength {
+		return fmt.Errorf("transfer length %d over the max frame size", p.transferLength)
+	}
+
+	if p.NumSourceSymbols < minSourceSymbols || p.NumSourceSymbols > maxSourceSymbols {
+		return fmt.Errorf("invalid number of source symbols %d", p.NumSourceSymbols)
+	}
+
+	if uint32(p.PaddingSize) > p.transferLength {
+		return fmt.Errorf("padding size %d over the transfer length", p.PaddingSize)
+	}
+
+	return nil
+}
+
+// reserve accounts for a new message, unless its sender or the reader are
+// over the limits. It must be called with the lock held.
+func (r *UDPReader) reserve(m *message) bool {
+	u, ok := r.usages[m.sender]
+	if !ok {
+		u = &usage{}
+	}
+
+	if u.objects >= maxObjectsPerSender || u.bytes+m.size > maxSenderBytes ||
+		r.total.objects >= maxObjects || r.total.bytes+m.size > maxObjectsBytes {
+		return false
+	}
+
+	u.objects++
+	u.bytes += m.size
+	r.usages[m.sender] = u
+
+	r.total.objects++
+	r.total.bytes += m.size
+
+	return true
+}
+
+// release removes a message. It must be called with the lock held.
+func (r *UDPReader) release(key msgID, m *message) {
+	delete(r.objects, key)
+
+	r.total.objects--
+	r.total.bytes -= m.size
+
+	if u, ok := r.usages[m.sender]; ok {
+		u.objects--
+		u.bytes -= m.size
+
+		if u.objects == 0 {
+			delete(r.usages, m.sender)
+		}
 	}
 }
 
@@ -0,0 +1,77 @@
+// This Source Code Form is subject to the terms of the MIT License.
+// If a copy of the MIT License was not distributed with this
+// file, you can obtain one at https://opensource.org/licenses/MIT.
+//
+// Copyright (c) DUSK NETWORK. All rights reserved.
+
+package rcudp
+
+import (
+	"net"
+	"testing"
+
+	crypto "github.com/dusk-network/dusk-crypto/hash"
+	"github.com/stretchr/testify/require"
+)
+
+// randomPacket returns a packet of a random message ID.
+func randomPacket(t *testing.T, transferLength uint32) []byte {
+	block, err := crypto.RandEntropy(BlockSize)
+	require.NoError(t, err)
+
+	msgID, err := crypto.RandEntropy(8)
+	require.NoError(t, err)
+
+	p := newPacket(msgID, 4, 0, transferLength, 0, block, 1)
+
+	buf, err := p.marshal()
+	require.NoError(t, err)
+
+	return buf
+}
+
+// Test that a flood of random message IDs is bounded per sender and in total.
+func TestReaderFlood(t *testing.T) {
+	assert := require.New(t)
+
+	r, err := NewUDPReader(nil, func(byte, string, []byte) error {
+		return nil
+	})
+	assert.NoError(err)
+
+	sender := net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 7000}
+
+	// The transfer length is bounded by the max frame size
+	assert.Error(r.Process(sender, randomPacket(t, uint32(maxTransferLength)+1)))
+	assert.Empty(r.objects)
+
+	for i := 0; i < maxObjectsPerSender; i++ {
+		assert.NoError(r.Process(sender, randomPacket(t, 4*BlockSize)))
+	}
+
+	// Whatever the port
+	sender.Port++
+	assert.Equal(ErrTooManyObjects, r.Process(sender, randomPacket(t, 4*BlockSize)))
+
+	// Many senders reach the total bound
+	var rejected int
+
+	for i := 0; i < 2*maxObjects; i++ {
+		addr := net.UDPAddr{IP: net.IPv4(10, 1, byte(i>>8), byte(i)), Port: 7000}
+		if r.Process(addr, randomPacket(t, 4*BlockSize)) == ErrTooManyObjects {
+			rejected++
+		}
+	}
+
+	assert.Equal(maxObjects, len(r.objects))
+	assert.Equal(maxObjects+maxObjectsPerSender, rejected)
+
+	// Expired messages release their reservations
+	r.timeout = -1
+	r.Expire()
+
+	assert.Empty(r.objects)
+	assert.Empty(r.usages)
+	assert.Equal(usage{}, r.total)
+	assert.NoError(r.Process(sender, randomPacket(t, 4*BlockSize)))
+}
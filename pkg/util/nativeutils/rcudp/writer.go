@@ -76,6 +76,8 @@ func WriteBlocks(laddr, raddr *net.UDPAddr, blocks [][]byte, height byte) error
 		}
 	}
 
+	metrics.Add(metricPacketsSent, int64(len(blocks)))
+
 	_ = conn.Close()
 
 	return err